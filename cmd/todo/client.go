@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+)
+
+// Client calls the ToDo REST API.
+type Client struct {
+	config *Config
+	http   *http.Client
+}
+
+// NewClient returns a Client for the server in config.
+func NewClient(config *Config) *Client {
+	return &Client{
+		config: config,
+		http:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// APIError is an error response from the server.
+type APIError struct {
+	Response models.ErrorResponse
+}
+
+func (e *APIError) Error() string {
+	return problem.Summary(&e.Response)
+}
+
+// List returns the items matching the query parameters of GET /todo/.
+func (c *Client) List(params url.Values) ([]models.ToDoItem, error) {
+	var items []models.ToDoItem
+	err := c.do(http.MethodGet, "/todo/?"+params.Encode(), nil, &items)
+	return items, err
+}
+
+// Get returns the item with the given ID.
+func (c *Client) Get(id string) (*models.ToDoItem, error) {
+	item := &models.ToDoItem{}
+	if err := c.do(http.MethodGet, "/todo/"+url.PathEscape(id), nil, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Create creates an item and returns its ID.
+func (c *Client) Create(item *models.ToDoItem) (string, error) {
+	var result struct {
+		InsertedID string
+	}
+	err := c.do(http.MethodPost, "/todo/", item, &result)
+	return result.InsertedID, err
+}
+
+// Update sets the given fields of the item with the given ID, leaving the others as they are. Fields are given by
+// their JSON names, so that they can be reset to their zero value, which a ToDoItem would omit.
+func (c *Client) Update(id string, fields map[string]interface{}) error {
+	return c.do(http.MethodPut, "/todo/"+url.PathEscape(id), fields, nil)
+}
+
+// Delete deletes the item with the given ID.
+func (c *Client) Delete(id string) error {
+	return c.do(http.MethodDelete, "/todo/"+url.PathEscape(id), nil, nil)
+}
+
+// Watch streams the itemChanged GraphQL subscription until ctx is done or the stream ends. It calls connected once
+// the server has accepted the subscription, and changed for every change to an item.
+func (c *Client) Watch(ctx context.Context, connected func(), changed func()) error {
+	request, err := c.newRequest(http.MethodPost, "/graphql", map[string]string{
+		"query": "subscription { itemChanged { type id } }",
+	})
+	if err != nil {
+		return err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Accept", "text/event-stream")
+
+	// The stream is open for as long as the caller wants, so the client's timeout does not apply
+	response, err := (&http.Client{Transport: c.http.Transport}).Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if err := c.check(response); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(response.Header.Get("Content-Type"), "text/event-stream") {
+		return fmt.Errorf("%s does not stream subscriptions", c.config.Server)
+	}
+	connected()
+
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		switch strings.TrimSpace(scanner.Text()) {
+		case "event: next":
+			changed()
+		case "event: complete":
+			return nil
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// do sends a request with an optional JSON body and decodes a JSON response into result, if it is not nil.
+func (c *Client) do(method string, path string, body interface{}, result interface{}) error {
+	request, err := c.newRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if err := c.check(response); err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+		return fmt.Errorf("reading response from %s: %w", c.config.Server, err)
+	}
+	return nil
+}
+
+// newRequest returns a request to the server with an optional JSON body and the configured credentials.
+func (c *Client) newRequest(method string, path string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequest(method, strings.TrimSuffix(c.config.Server, "/")+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if c.config.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.config.Token)
+	} else if c.config.Username != "" {
+		request.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+	return request, nil
+}
+
+// check returns an APIError for an error response.
+func (c *Client) check(response *http.Response) error {
+	if response.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+
+	apiError := &APIError{}
+	data, _ := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err := json.Unmarshal(data, &apiError.Response); err != nil || apiError.Response.Title == "" {
+		apiError.Response = models.ErrorResponse{
+			Status: response.StatusCode,
+			Title:  http.StatusText(response.StatusCode),
+			Detail: strings.TrimSpace(string(data)),
+		}
+	}
+	return apiError
+}
+
+// IsNotFound reports whether err is a 404 from the server.
+func IsNotFound(err error) bool {
+	var apiError *APIError
+	return errors.As(err, &apiError) && apiError.Response.Status == http.StatusNotFound
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/spf13/cobra"
+)
+
+// sortAttributes are the attributes items can be sorted by, as accepted by GET /todo/?attrib=.
+var sortAttributes = []string{"title", "completed", "createdAt", "deadline"}
+
+var priorityNames = []string{models.PriorityLow, models.PriorityMedium, models.PriorityHigh}
+
+func completeWords(words []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return words, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func newAddCommand() *cobra.Command {
+	var due, list, priority string
+	var tags []string
+
+	cmd := &cobra.Command{
+		Use:   "add <title>...",
+		Short: "Add an item",
+		Example: `  todo add "Submit expense report" --due "friday 5pm" --tag finance
+  todo add Call the bank --due tomorrow`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			item := &models.ToDoItem{
+				Title:    strings.Join(args, " "),
+				List:     list,
+				Tags:     tags,
+				Priority: priority,
+			}
+			if due != "" {
+				deadline, err := parseDate(due, time.Now().In(current.location))
+				if err != nil {
+					return err
+				}
+				item.Deadline = deadline
+			}
+
+			id, err := current.client.Create(item)
+			if err != nil {
+				return err
+			}
+			if output == "json" {
+				created, err := current.client.Get(id)
+				if err != nil {
+					return err
+				}
+				return printJSON(cmd.OutOrStdout(), created)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Added %s\n", id[:shortIDLength])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&due, "due", "", "deadline, e.g. tomorrow, \"friday 5pm\", 2023-01-31 or RFC 3339")
+	cmd.Flags().StringVar(&list, "list", "", "list the item belongs to")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "tag to add; can be repeated")
+	cmd.Flags().StringVar(&priority, "priority", "", "priority: low, medium or high")
+	_ = cmd.RegisterFlagCompletionFunc("priority", completeWords(priorityNames))
+
+	return cmd
+}
+
+func newListCommand() *cobra.Command {
+	var sortBy, before, after, start, end, list, tag string
+	var desc, pending bool
+
+	cmd := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List items",
+		Long: `List items, sorted by an attribute and optionally filtered by a date range on it.
+
+Dates are filtered on the --sort attribute, which must be createdAt or deadline, and defaults to deadline when a
+date is given.`,
+		Example: `  todo ls --sort deadline --before "next friday"
+  todo ls --pending --list work`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params := url.Values{}
+
+			if sortBy == "" && (before != "" || after != "" || start != "" || end != "") {
+				sortBy = "deadline"
+			}
+			if sortBy != "" {
+				params.Set("attrib", sortBy)
+			}
+			if sortBy != "createdAt" && sortBy != "deadline" && (before != "" || after != "" || start != "" || end != "") {
+				return fmt.Errorf("dates can only be filtered when sorting by createdAt or deadline")
+			}
+			if desc {
+				params.Set("sort", "desc")
+			}
+
+			now := time.Now().In(current.location)
+			for name, value := range map[string]string{"before": before, "after": after, "start": start, "end": end} {
+				if value == "" {
+					continue
+				}
+				millis, err := parseDate(value, now)
+				if err != nil {
+					return fmt.Errorf("--%s: %w", name, err)
+				}
+				params.Set(name, strconv.FormatInt(millis, 10))
+			}
+
+			items, err := current.client.List(params)
+			if err != nil {
+				return err
+			}
+
+			// The API filters by dates only; the rest is filtered here
+			var selected []models.ToDoItem
+			for _, item := range items {
+				if pending && item.Completed {
+					continue
+				}
+				if list != "" && !strings.EqualFold(item.List, list) {
+					continue
+				}
+				if tag != "" && !contains(item.Tags, strings.ToLower(tag)) {
+					continue
+				}
+				selected = append(selected, item)
+			}
+
+			return printItems(cmd.OutOrStdout(), selected)
+		},
+	}
+
+	cmd.Flags().StringVar(&sortBy, "sort", "", "attribute to sort by: title, completed, createdAt or deadline (default createdAt)")
+	cmd.Flags().BoolVar(&desc, "desc", false, "sort in descending order")
+	cmd.Flags().StringVar(&before, "before", "", "only items whose sort attribute is at or before this date")
+	cmd.Flags().StringVar(&after, "after", "", "only items whose sort attribute is at or after this date")
+	cmd.Flags().StringVar(&start, "start", "", "start of a date range on the sort attribute; requires --end")
+	cmd.Flags().StringVar(&end, "end", "", "end of a date range on the sort attribute; requires --start")
+	cmd.Flags().StringVar(&list, "list", "", "only items in this list")
+	cmd.Flags().StringVar(&tag, "tag", "", "only items with this tag")
+	cmd.Flags().BoolVar(&pending, "pending", false, "hide completed items")
+	cmd.MarkFlagsRequiredTogether("start", "end")
+	cmd.MarkFlagsMutuallyExclusive("before", "start")
+	cmd.MarkFlagsMutuallyExclusive("after", "start")
+	_ = cmd.RegisterFlagCompletionFunc("sort", completeWords(sortAttributes))
+
+	return cmd
+}
+
+func newShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "show <id>",
+		Short:             "Show an item",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := resolveID(current.client, args[0])
+			if err != nil {
+				return err
+			}
+			item, err := current.client.Get(id)
+			if err != nil {
+				return err
+			}
+			return printItem(cmd.OutOrStdout(), item)
+		},
+	}
+}
+
+func newDoneCommand() *cobra.Command {
+	var undo bool
+
+	cmd := &cobra.Command{
+		Use:               "done <id>...",
+		Short:             "Mark items as completed",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, arg := range args {
+				id, err := resolveID(current.client, arg)
+				if err != nil {
+					return err
+				}
+				if err := current.client.Update(id, map[string]interface{}{"completed": !undo}); err != nil {
+					return fmt.Errorf("%s: %w", id[:shortIDLength], err)
+				}
+				if undo {
+					fmt.Fprintf(cmd.OutOrStdout(), "Reopened %s\n", id[:shortIDLength])
+				} else {
+					fmt.Fprintf(cmd.OutOrStdout(), "Completed %s\n", id[:shortIDLength])
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&undo, "undo", false, "mark the items as not completed instead")
+
+	return cmd
+}
+
+func newRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "rm <id>...",
+		Aliases:           []string{"delete"},
+		Short:             "Delete items",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, arg := range args {
+				id, err := resolveID(current.client, arg)
+				if err != nil {
+					return err
+				}
+				if err := current.client.Delete(id); err != nil {
+					return fmt.Errorf("%s: %w", id[:shortIDLength], err)
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Deleted %s\n", id[:shortIDLength])
+			}
+			return nil
+		},
+	}
+}
+
+// editable is the part of an item that `todo edit` opens in an editor.
+type editable struct {
+	Title      string   `json:"title"`
+	Completed  bool     `json:"completed"`
+	Deadline   string   `json:"deadline"`
+	List       string   `json:"list"`
+	Tags       []string `json:"tags"`
+	Priority   string   `json:"priority"`
+	Recurrence string   `json:"recurrence"`
+}
+
+func newEditCommand() *cobra.Command {
+	var title, due, list, priority string
+	var tags []string
+	var clearDue bool
+
+	cmd := &cobra.Command{
+		Use:   "edit <id>",
+		Short: "Edit an item",
+		Long: `Edit an item with the given flags, or, without any, in $EDITOR as JSON.
+
+In the editor, the deadline is written in RFC 3339 and may be changed to any date accepted by --due, or emptied.`,
+		Example: `  todo edit 63a1f0c2 --due "next monday" --priority high
+  todo edit 63a1f0c2`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := resolveID(current.client, args[0])
+			if err != nil {
+				return err
+			}
+			now := time.Now().In(current.location)
+
+			fields := map[string]interface{}{}
+			flags := cmd.Flags()
+			if flags.Changed("title") {
+				fields["title"] = title
+			}
+			if flags.Changed("due") {
+				deadline, err := parseDate(due, now)
+				if err != nil {
+					return err
+				}
+				fields["deadline"] = deadline
+			}
+			if clearDue {
+				fields["deadline"] = 0
+			}
+			if flags.Changed("list") {
+				fields["list"] = list
+			}
+			if flags.Changed("tag") {
+				fields["tags"] = tags
+			}
+			if flags.Changed("priority") {
+				fields["priority"] = priority
+			}
+
+			if len(fields) == 0 {
+				if fields, err = editInEditor(id, now); err != nil {
+					return err
+				}
+				if fields == nil {
+					fmt.Fprintln(cmd.OutOrStdout(), "No changes")
+					return nil
+				}
+			}
+
+			if err := current.client.Update(id, fields); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Updated %s\n", id[:shortIDLength])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&title, "title", "", "new title")
+	cmd.Flags().StringVar(&due, "due", "", "new deadline, in any format accepted by add")
+	cmd.Flags().BoolVar(&clearDue, "clear-due", false, "remove the deadline")
+	cmd.Flags().StringVar(&list, "list", "", "new list; empty to remove it from its list")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "tag; replaces all tags, can be repeated; --tag= removes them")
+	cmd.Flags().StringVar(&priority, "priority", "", "new priority: low, medium or high; empty to remove it")
+	cmd.MarkFlagsMutuallyExclusive("due", "clear-due")
+	_ = cmd.RegisterFlagCompletionFunc("priority", completeWords(priorityNames))
+
+	return cmd
+}
+
+// editInEditor opens the item in $EDITOR (or $VISUAL, falling back to vi) and returns the fields to update, or nil
+// if the file was not changed.
+func editInEditor(id string, now time.Time) (map[string]interface{}, error) {
+	item, err := current.client.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := editable{
+		Title:      item.Title,
+		Completed:  item.Completed,
+		List:       item.List,
+		Tags:       item.Tags,
+		Priority:   item.Priority,
+		Recurrence: item.Recurrence,
+	}
+	if item.Deadline != 0 {
+		before.Deadline = time.UnixMilli(item.Deadline).In(current.location).Format(time.RFC3339)
+	}
+	if before.Tags == nil {
+		before.Tags = []string{}
+	}
+
+	original, err := json.MarshalIndent(before, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp("", "todo-*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(append(original, '\n')); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// The editor may have arguments, e.g. "code --wait"
+	command := exec.Command("sh", "-c", editor+` "$1"`, "sh", file.Name())
+	command.Stdin, command.Stdout, command.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := command.Run(); err != nil {
+		return nil, fmt.Errorf("running %s: %w", editor, err)
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(bytes.TrimSpace(edited), original) {
+		return nil, nil
+	}
+
+	after := editable{}
+	if err := json.Unmarshal(edited, &after); err != nil {
+		return nil, fmt.Errorf("reading the edited item: %w", err)
+	}
+	if strings.TrimSpace(after.Title) == "" {
+		return nil, errors.New("the title must not be empty")
+	}
+
+	var deadline int64
+	if after.Deadline != "" {
+		if deadline, err = parseDate(after.Deadline, now); err != nil {
+			return nil, err
+		}
+	}
+	if after.Tags == nil {
+		after.Tags = []string{}
+	}
+
+	return map[string]interface{}{
+		"title":      after.Title,
+		"completed":  after.Completed,
+		"deadline":   deadline,
+		"list":       after.List,
+		"tags":       after.Tags,
+		"priority":   after.Priority,
+		"recurrence": after.Recurrence,
+	}, nil
+}
+
+func newConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show or change the configuration",
+		// The config file must be usable to fix itself, so it is not loaded before these commands run
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "path",
+		Short: "Print the path of the config file",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Fprintln(cmd.OutOrStdout(), configPath)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "Print the configuration in effect, hiding secrets",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if serverURL != "" {
+				config.Server = serverURL
+			}
+			if config.Token != "" {
+				config.Token = "********"
+			}
+			if config.Password != "" {
+				config.Password = "********"
+			}
+			return printJSON(cmd.OutOrStdout(), config)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a key of the config file: " + strings.Join(configKeyNames(), ", "),
+		Args:  cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return configKeyNames(), cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if args[0] == "timeZone" && args[1] != "" {
+				if _, err := time.LoadLocation(args[1]); err != nil {
+					return fmt.Errorf("invalid time zone %q", args[1])
+				}
+			}
+			return setConfig(configPath, args[0], args[1])
+		},
+	})
+
+	return cmd
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultServer is used when neither the config file, the environment nor a flag names a server.
+const defaultServer = "http://localhost:8080"
+
+// Config is the CLI configuration, stored as JSON in the config file.
+// Token is sent as a bearer token; Username and Password, if set instead, are sent with basic authentication.
+type Config struct {
+	Server   string `json:"server,omitempty"`
+	Token    string `json:"token,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// configKeys maps the keys accepted by `todo config set` to the fields they set.
+var configKeys = map[string]func(config *Config) *string{
+	"server":   func(config *Config) *string { return &config.Server },
+	"token":    func(config *Config) *string { return &config.Token },
+	"username": func(config *Config) *string { return &config.Username },
+	"password": func(config *Config) *string { return &config.Password },
+	"timeZone": func(config *Config) *string { return &config.TimeZone },
+}
+
+// defaultConfigPath returns the path of the config file in the user's config directory, e.g. ~/.config/todo/config.json.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "todo.json"
+	}
+	return filepath.Join(dir, "todo", "config.json")
+}
+
+// loadConfig reads the config file, if it exists, and applies the TODO_SERVER and TODO_TOKEN environment variables.
+func loadConfig(path string) (*Config, error) {
+	config := &Config{}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+	}
+
+	if server := os.Getenv("TODO_SERVER"); server != "" {
+		config.Server = server
+	}
+	if token := os.Getenv("TODO_TOKEN"); token != "" {
+		config.Token = token
+	}
+	if config.Server == "" {
+		config.Server = defaultServer
+	}
+
+	return config, nil
+}
+
+// saveConfig writes the config file. It is only readable by the user, since it may hold credentials.
+func saveConfig(path string, config *Config) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// configKeyNames returns the keys accepted by `todo config set`, sorted.
+func configKeyNames() []string {
+	names := make([]string, 0, len(configKeys))
+	for name := range configKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// setConfig sets a single key of the config file, leaving the others as they are.
+func setConfig(path string, key string, value string) error {
+	field, ok := configKeys[key]
+	if !ok {
+		return fmt.Errorf("unknown key %q; must be one of: %s", key, strings.Join(configKeyNames(), ", "))
+	}
+
+	// The file is read without the environment, so that it is not written into the file
+	config := &Config{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, config); err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+	}
+
+	*field(config) = value
+	return saveConfig(path, config)
+}
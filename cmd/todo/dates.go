@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/quickadd"
+)
+
+// parseDate parses a date given on the command line into a Unix millisecond timestamp. It accepts a timestamp, an
+// RFC 3339 date-time, or any date and time understood by quick add, such as "tomorrow", "friday 5pm" or
+// "2023-01-31". Relative dates are resolved against now.
+func parseDate(value string, now time.Time) (int64, error) {
+	value = strings.TrimSpace(value)
+
+	if millis, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return millis, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UnixMilli(), nil
+	}
+
+	// Anything quick add does not recognise as part of a date becomes the title
+	item := quickadd.Parse(value, now)
+	if item.Deadline == 0 || item.Title != "" || len(item.Tags) > 0 || item.Priority != "" || item.Recurrence != "" {
+		return 0, fmt.Errorf("invalid date %q", value)
+	}
+	return item.Deadline, nil
+}
+
+// formatDate formats a Unix millisecond timestamp for tables, omitting the time at the end of a day.
+func formatDate(millis int64, location *time.Location) string {
+	if millis == 0 {
+		return ""
+	}
+	t := time.UnixMilli(millis).In(location)
+	if t.Hour() == 23 && t.Minute() == 59 {
+		return t.Format("Mon 2006-01-02")
+	}
+	return t.Format("Mon 2006-01-02 15:04")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/spf13/cobra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// shortIDLength is the number of characters of an ID shown in tables. Any unambiguous prefix can be used to refer
+// to an item.
+const shortIDLength = 8
+
+// shortID returns the prefix of an ID shown in tables.
+func shortID(id primitive.ObjectID) string {
+	return id.Hex()[:shortIDLength]
+}
+
+// resolveID returns the full ID of the item whose ID starts with prefix. A full ID is returned as is, without
+// asking the server; a prefix is matched against every item and must match exactly one.
+func resolveID(client *Client, prefix string) (string, error) {
+	prefix = strings.ToLower(prefix)
+	if _, err := primitive.ObjectIDFromHex(prefix); err == nil {
+		return prefix, nil
+	}
+
+	items, err := client.List(url.Values{})
+	if err != nil {
+		return "", err
+	}
+
+	var matches []string
+	for _, item := range items {
+		if strings.HasPrefix(item.ID.Hex(), prefix) {
+			matches = append(matches, item.ID.Hex())
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no item has an ID starting with %q", prefix)
+	case 1:
+		return matches[0], nil
+	}
+	sort.Strings(matches)
+	return "", fmt.Errorf("%q is ambiguous; it matches %s", prefix, strings.Join(matches, ", "))
+}
+
+// completeIDs completes item IDs for shell completion, describing each with its title.
+func completeIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if err := setup(); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	items, err := current.client.List(url.Values{})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, item := range items {
+		if !strings.HasPrefix(item.ID.Hex(), strings.ToLower(toComplete)) || contains(args, item.ID.Hex()) {
+			continue
+		}
+		completions = append(completions, shortID(item.ID)+"\t"+describe(&item))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// describe returns the title of an item, marked if it is completed.
+func describe(item *models.ToDoItem) string {
+	if item.Completed {
+		return "[done] " + item.Title
+	}
+	return item.Title
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
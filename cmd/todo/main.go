@@ -0,0 +1,102 @@
+// Command todo is a command-line client for the ToDo REST API.
+//
+// The server URL and credentials are read from a config file (see `todo config path`), which can be written with
+// `todo config set`, and can be overridden with the TODO_SERVER and TODO_TOKEN environment variables or the
+// --server flag. Items can be referred to by any unambiguous prefix of their ID.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Global flags.
+var (
+	configPath string
+	serverURL  string
+	output     string
+	timeZone   string
+)
+
+// app holds what the commands share once the flags have been parsed.
+type app struct {
+	config   *Config
+	client   *Client
+	location *time.Location
+}
+
+var current app
+
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:           "todo",
+		Short:         "Manage to-do items on a ToDo server",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return setup()
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&configPath, "config", defaultConfigPath(), "path of the config file")
+	flags.StringVar(&serverURL, "server", "", "URL of the ToDo server (overrides the config file and TODO_SERVER)")
+	flags.StringVarP(&output, "output", "o", "table", "output format: table or json")
+	flags.StringVar(&timeZone, "tz", "", "IANA time zone for dates (defaults to the config file, then the local time zone)")
+
+	_ = root.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"table", "json"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	root.AddCommand(
+		newAddCommand(),
+		newListCommand(),
+		newDoneCommand(),
+		newRemoveCommand(),
+		newEditCommand(),
+		newShowCommand(),
+		newTUICommand(),
+		newConfigCommand(),
+	)
+
+	return root
+}
+
+// setup loads the configuration and applies the global flags.
+func setup() error {
+	if output != "table" && output != "json" {
+		return fmt.Errorf("invalid output format %q; must be table or json", output)
+	}
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	if serverURL != "" {
+		config.Server = serverURL
+	}
+
+	location := time.Local
+	name := timeZone
+	if name == "" {
+		name = config.TimeZone
+	}
+	if name != "" {
+		if location, err = time.LoadLocation(name); err != nil {
+			return fmt.Errorf("invalid time zone %q", name)
+		}
+	}
+
+	current = app{config: config, client: NewClient(config), location: location}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+// printItems writes items as a table or as JSON, depending on the --output flag.
+func printItems(w io.Writer, items []models.ToDoItem) error {
+	if output == "json" {
+		if items == nil {
+			items = []models.ToDoItem{}
+		}
+		return printJSON(w, items)
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tDONE\tTITLE\tDUE\tLIST\tTAGS\tPRIORITY")
+	for _, item := range items {
+		done := ""
+		if item.Completed {
+			done = "x"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			shortID(item.ID), done, item.Title, formatDate(item.Deadline, current.location),
+			item.List, strings.Join(item.Tags, ","), item.Priority)
+	}
+	return table.Flush()
+}
+
+// printItem writes a single item as a list of fields or as JSON, depending on the --output flag.
+func printItem(w io.Writer, item *models.ToDoItem) error {
+	if output == "json" {
+		return printJSON(w, item)
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	field := func(name string, value string) {
+		if value != "" {
+			fmt.Fprintf(table, "%s:\t%s\n", name, value)
+		}
+	}
+
+	field("ID", item.ID.Hex())
+	field("Title", item.Title)
+	field("Completed", fmt.Sprint(item.Completed))
+	field("Due", formatDate(item.Deadline, current.location))
+	field("List", item.List)
+	field("Tags", strings.Join(item.Tags, ", "))
+	field("Priority", item.Priority)
+	field("Recurrence", item.Recurrence)
+	field("Created", formatDate(item.CreatedAt, current.location))
+	field("Updated", formatDate(item.UpdatedAt, current.location))
+	field("Completed at", formatDate(item.CompletedAt, current.location))
+	return table.Flush()
+}
+
+func printJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -0,0 +1,554 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+func newTUICommand() *cobra.Command {
+	var interval time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Manage items in an interactive terminal UI",
+		Long: `Manage items in an interactive terminal UI.
+
+The list is refreshed whenever an item changes on the server, using the GraphQL itemChanged subscription. If the
+server cannot stream it, the list is polled instead.
+
+Keys:
+  up/down, j/k      move
+  space, x          toggle completion
+  e                 edit the title
+  d                 edit the deadline; empty removes it
+  s, 1-4            sort by the next column, or by the given one
+  o                 reverse the sort order
+  f                 filter by a date range on the DUE or CREATED sort column, e.g. "today .. friday"
+  r                 refresh
+  q, ctrl+c         quit`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			program := tea.NewProgram(newBoard(current.client, interval), tea.WithAltScreen())
+
+			go func() {
+				err := current.client.Watch(ctx,
+					func() { program.Send(watchingMsg{}) },
+					func() { program.Send(changedMsg{}) },
+				)
+				program.Send(watchEndedMsg{err: err})
+			}()
+
+			_, err := program.Run()
+			return err
+		},
+	}
+
+	cmd.Flags().DurationVar(&interval, "interval", 10*time.Second, "how often to poll the server when it cannot stream changes")
+
+	return cmd
+}
+
+// Messages handled by board.
+type (
+	itemsMsg      struct{ items []models.ToDoItem }
+	errMsg        struct{ err error }
+	savedMsg      struct{ message string }
+	changedMsg    struct{}
+	tickMsg       struct{}
+	watchingMsg   struct{}
+	watchEndedMsg struct{ err error }
+)
+
+// mode is what keys currently do on the board.
+type mode int
+
+const (
+	browsing mode = iota
+	editingTitle
+	editingDeadline
+	filtering
+)
+
+// column is a column of the board. Columns with an attribute can be sorted by it, as by GET /todo/?attrib=.
+type column struct {
+	title  string
+	attrib string
+	width  int
+}
+
+// date reports whether the column's attribute is a timestamp, which GET /todo/ can filter by.
+func (c column) date() bool {
+	return c.attrib == "deadline" || c.attrib == "createdAt"
+}
+
+var columns = []column{
+	{title: "ID", width: shortIDLength},
+	{title: "✓", attrib: "completed", width: 3},
+	{title: "TITLE", attrib: "title"},
+	{title: "DUE", attrib: "deadline", width: 20},
+	{title: "CREATED", attrib: "createdAt", width: 20},
+}
+
+var (
+	headerStyle   = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	doneStyle     = lipgloss.NewStyle().Faint(true)
+	statusStyle   = lipgloss.NewStyle().Faint(true)
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+)
+
+// board is the Bubble Tea model of the TUI.
+type board struct {
+	client   *Client
+	interval time.Duration
+
+	items  []models.ToDoItem
+	cursor int
+	offset int
+
+	sortColumn int
+	desc       bool
+	filter     string
+	start, end int64
+
+	mode  mode
+	input textinput.Model
+
+	live    bool
+	polling bool
+	status  string
+	err     error
+
+	width, height int
+}
+
+func newBoard(client *Client, interval time.Duration) *board {
+	input := textinput.New()
+	input.CharLimit = 200
+
+	return &board{
+		client:     client,
+		interval:   interval,
+		sortColumn: 4, // CREATED
+		input:      input,
+		width:      120,
+		height:     24,
+	}
+}
+
+func (b *board) Init() tea.Cmd {
+	return b.load()
+}
+
+// load fetches the items with the current sort order and filter. The filter only applies while sorting by a date.
+func (b *board) load() tea.Cmd {
+	if !columns[b.sortColumn].date() {
+		b.filter, b.start, b.end = "", 0, 0
+	}
+
+	params := url.Values{}
+	params.Set("attrib", columns[b.sortColumn].attrib)
+	if b.desc {
+		params.Set("sort", "desc")
+	}
+	switch {
+	case b.start != 0 && b.end != 0:
+		params.Set("start", strconv.FormatInt(b.start, 10))
+		params.Set("end", strconv.FormatInt(b.end, 10))
+	case b.start != 0:
+		params.Set("after", strconv.FormatInt(b.start, 10))
+	case b.end != 0:
+		params.Set("before", strconv.FormatInt(b.end, 10))
+	}
+
+	client := b.client
+	return func() tea.Msg {
+		items, err := client.List(params)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return itemsMsg{items: items}
+	}
+}
+
+// update sends fields of an item to the server.
+func (b *board) update(id string, fields map[string]interface{}, message string) tea.Cmd {
+	client := b.client
+	return func() tea.Msg {
+		if err := client.Update(id, fields); err != nil {
+			return errMsg{err: err}
+		}
+		return savedMsg{message: message}
+	}
+}
+
+func (b *board) tick() tea.Cmd {
+	return tea.Tick(b.interval, func(time.Time) tea.Msg { return tickMsg{} })
+}
+
+func (b *board) selected() *models.ToDoItem {
+	if b.cursor < 0 || b.cursor >= len(b.items) {
+		return nil
+	}
+	return &b.items[b.cursor]
+}
+
+func (b *board) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.width, b.height = msg.Width, msg.Height
+		return b, nil
+
+	case itemsMsg:
+		// Keep the cursor on the same item if it is still listed
+		var id string
+		if item := b.selected(); item != nil {
+			id = item.ID.Hex()
+		}
+		b.items = msg.items
+		b.err = nil
+		for i, item := range b.items {
+			if item.ID.Hex() == id {
+				b.cursor = i
+			}
+		}
+		if b.cursor >= len(b.items) {
+			b.cursor = len(b.items) - 1
+		}
+		if b.cursor < 0 {
+			b.cursor = 0
+		}
+		return b, nil
+
+	case errMsg:
+		b.err = msg.err
+		return b, nil
+
+	case savedMsg:
+		b.status = msg.message
+		return b, b.load()
+
+	case changedMsg:
+		return b, b.load()
+
+	case watchingMsg:
+		b.live = true
+		return b, nil
+
+	case watchEndedMsg:
+		b.live = false
+		if msg.err != nil {
+			b.status = "Live updates unavailable: " + msg.err.Error()
+		}
+		if b.polling {
+			return b, nil
+		}
+		b.polling = true
+		return b, b.tick()
+
+	case tickMsg:
+		return b, tea.Batch(b.load(), b.tick())
+
+	case tea.KeyMsg:
+		if b.mode != browsing {
+			return b.updateInput(msg)
+		}
+		return b.updateBrowsing(msg)
+	}
+
+	return b, nil
+}
+
+func (b *board) updateBrowsing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key := msg.String(); key {
+	case "q", "ctrl+c":
+		return b, tea.Quit
+
+	case "up", "k":
+		if b.cursor > 0 {
+			b.cursor--
+		}
+	case "down", "j":
+		if b.cursor < len(b.items)-1 {
+			b.cursor++
+		}
+	case "home", "g":
+		b.cursor = 0
+	case "end", "G":
+		if len(b.items) > 0 {
+			b.cursor = len(b.items) - 1
+		}
+
+	case " ", "x":
+		if item := b.selected(); item != nil {
+			message := "Completed " + shortID(item.ID)
+			if item.Completed {
+				message = "Reopened " + shortID(item.ID)
+			}
+			return b, b.update(item.ID.Hex(), map[string]interface{}{"completed": !item.Completed}, message)
+		}
+
+	case "e":
+		if item := b.selected(); item != nil {
+			return b, b.prompt(editingTitle, "Title: ", item.Title)
+		}
+	case "d":
+		if item := b.selected(); item != nil {
+			deadline := ""
+			if item.Deadline != 0 {
+				deadline = time.UnixMilli(item.Deadline).In(current.location).Format("2006-01-02 15:04")
+			}
+			return b, b.prompt(editingDeadline, "Due: ", deadline)
+		}
+	case "f":
+		if !columns[b.sortColumn].date() {
+			b.err = fmt.Errorf("sort by DUE or CREATED to filter by date")
+			return b, nil
+		}
+		return b, b.prompt(filtering, "Filter "+strings.ToLower(columns[b.sortColumn].title)+" (from .. to): ", b.filter)
+
+	case "s":
+		for {
+			b.sortColumn = (b.sortColumn + 1) % len(columns)
+			if columns[b.sortColumn].attrib != "" {
+				break
+			}
+		}
+		return b, b.load()
+	case "1", "2", "3", "4":
+		n, _ := strconv.Atoi(key)
+		for i, c := range columns {
+			if c.attrib != "" {
+				if n--; n == 0 {
+					b.sortColumn = i
+				}
+			}
+		}
+		return b, b.load()
+	case "o":
+		b.desc = !b.desc
+		return b, b.load()
+
+	case "r":
+		return b, b.load()
+	}
+
+	return b, nil
+}
+
+// prompt switches to an input mode with an initial value.
+func (b *board) prompt(mode mode, prompt string, value string) tea.Cmd {
+	b.mode = mode
+	b.input.Prompt = prompt
+	b.input.SetValue(value)
+	b.input.CursorEnd()
+	b.err = nil
+	return b.input.Focus()
+}
+
+func (b *board) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		b.mode = browsing
+		b.input.Blur()
+		return b, nil
+	case tea.KeyEnter:
+		return b, b.submit()
+	}
+
+	var cmd tea.Cmd
+	b.input, cmd = b.input.Update(msg)
+	return b, cmd
+}
+
+// submit applies the value of the input. Invalid values leave the input open with an error.
+func (b *board) submit() tea.Cmd {
+	value := strings.TrimSpace(b.input.Value())
+	now := time.Now().In(current.location)
+	item := b.selected()
+
+	var cmd tea.Cmd
+	switch b.mode {
+	case editingTitle:
+		if value == "" {
+			b.err = fmt.Errorf("the title must not be empty")
+			return nil
+		}
+		if item != nil {
+			cmd = b.update(item.ID.Hex(), map[string]interface{}{"title": value}, "Renamed "+shortID(item.ID))
+		}
+
+	case editingDeadline:
+		var deadline int64
+		if value != "" {
+			var err error
+			if deadline, err = parseDate(value, now); err != nil {
+				b.err = err
+				return nil
+			}
+		}
+		if item != nil {
+			cmd = b.update(item.ID.Hex(), map[string]interface{}{"deadline": deadline}, "Rescheduled "+shortID(item.ID))
+		}
+
+	case filtering:
+		start, end, err := parseRange(value, now)
+		if err != nil {
+			b.err = err
+			return nil
+		}
+		b.filter, b.start, b.end = value, start, end
+		cmd = b.load()
+	}
+
+	b.mode = browsing
+	b.input.Blur()
+	return cmd
+}
+
+// parseRange parses "from .. to", where either side may be empty. A value without ".." is taken as from.
+func parseRange(value string, now time.Time) (int64, int64, error) {
+	if value == "" {
+		return 0, 0, nil
+	}
+
+	from, to, _ := strings.Cut(value, "..")
+	var start, end int64
+	var err error
+	if from = strings.TrimSpace(from); from != "" {
+		if start, err = parseDate(from, now); err != nil {
+			return 0, 0, err
+		}
+	}
+	if to = strings.TrimSpace(to); to != "" {
+		if end, err = parseDate(to, now); err != nil {
+			return 0, 0, err
+		}
+	}
+	if start != 0 && end != 0 && start > end {
+		return 0, 0, fmt.Errorf("the range ends before it starts")
+	}
+	return start, end, nil
+}
+
+func (b *board) View() string {
+	var view strings.Builder
+
+	// The title takes the width the other columns leave
+	titleWidth := b.width - len(columns) + 1
+	for _, c := range columns {
+		titleWidth -= c.width
+	}
+	if titleWidth < 10 {
+		titleWidth = 10
+	}
+
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		title := c.title
+		if i == b.sortColumn {
+			if b.desc {
+				title += " ↓"
+			} else {
+				title += " ↑"
+			}
+		}
+		header[i] = cell(title, c.width, titleWidth)
+	}
+	view.WriteString(headerStyle.Render(strings.Join(header, " ")) + "\n")
+
+	// Scroll so that the cursor stays within the rows that fit between the header and the status lines
+	rows := b.height - 4
+	if rows < 1 {
+		rows = 1
+	}
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+rows {
+		b.offset = b.cursor - rows + 1
+	}
+
+	for i := b.offset; i < len(b.items) && i < b.offset+rows; i++ {
+		item := &b.items[i]
+		done := ""
+		if item.Completed {
+			done = "✓"
+		}
+		values := []string{
+			shortID(item.ID), done, item.Title,
+			formatDate(item.Deadline, current.location),
+			formatDate(item.CreatedAt, current.location),
+		}
+		cells := make([]string, len(columns))
+		for j, c := range columns {
+			cells[j] = cell(values[j], c.width, titleWidth)
+		}
+
+		line := strings.Join(cells, " ")
+		switch {
+		case i == b.cursor:
+			line = selectedStyle.Render(line)
+		case item.Completed:
+			line = doneStyle.Render(line)
+		}
+		view.WriteString(line + "\n")
+	}
+	if len(b.items) == 0 {
+		view.WriteString(statusStyle.Render("No items") + "\n")
+	}
+
+	view.WriteString("\n")
+	if b.mode != browsing {
+		view.WriteString(b.input.View() + "\n")
+	} else {
+		view.WriteString(statusStyle.Render(b.statusLine()) + "\n")
+	}
+	if b.err != nil {
+		view.WriteString(errorStyle.Render("Error: "+b.err.Error()) + "\n")
+	}
+
+	return view.String()
+}
+
+func (b *board) statusLine() string {
+	parts := []string{fmt.Sprintf("%d items", len(b.items))}
+	if b.filter != "" {
+		parts = append(parts, "filter: "+b.filter)
+	}
+	if b.live {
+		parts = append(parts, "live")
+	} else if b.polling {
+		parts = append(parts, "polling every "+b.interval.String())
+	}
+	if b.status != "" {
+		parts = append(parts, b.status)
+	}
+	parts = append(parts, "x toggle  e title  d due  s/o sort  f filter  r refresh  q quit")
+	return strings.Join(parts, " · ")
+}
+
+// cell pads or truncates a value to the width of its column. A width of 0 is the title column's.
+func cell(value string, width int, titleWidth int) string {
+	if width == 0 {
+		width = titleWidth
+	}
+	runes := []rune(value)
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return value + strings.Repeat(" ", width-len(runes))
+}
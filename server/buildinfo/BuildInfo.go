@@ -0,0 +1,61 @@
+// Package buildinfo identifies the build of the server.
+//
+// Version, Commit and BuildTime are meant to be set when building, e.g.
+//
+//	go build -ldflags "-X github.com/L4TTiCe/ToDo-Go/server/buildinfo.Version=1.2.0 -X github.com/L4TTiCe/ToDo-Go/server/buildinfo.Commit=$(git rev-parse HEAD)"
+//
+// Otherwise the commit and time recorded by the Go toolchain are used, if any.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+// Set when building.
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+var (
+	info     models.BuildInfo
+	infoOnce sync.Once
+)
+
+// Get returns the build information.
+func Get() models.BuildInfo {
+	infoOnce.Do(func() {
+		info = models.BuildInfo{
+			Version:   Version,
+			Commit:    Commit,
+			BuildTime: BuildTime,
+			GoVersion: runtime.Version(),
+		}
+
+		build, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	})
+
+	return info
+}
@@ -0,0 +1,104 @@
+// Package cache keeps recently used values in memory for a limited time.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU holds up to a fixed number of values, each for a limited time. Once it is full, the least recently used value
+// is evicted to make room. It is safe for concurrent use.
+type LRU struct {
+	size int
+	ttl  time.Duration
+
+	mutex sync.Mutex
+	// order holds the entries, the most recently used first
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// NewLRU returns a cache holding up to size values, each for ttl.
+func NewLRU(size int, ttl time.Duration) *LRU {
+	return &LRU{size: size, ttl: ttl, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// Get returns the value stored for the key, if there is one that has not expired.
+func (c *LRU) Get(key string) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := element.Value.(*entry)
+	if time.Now().After(e.expires) {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return e.value, true
+}
+
+// Set stores a value for the key, replacing the one stored before, and reports whether it evicted another value to
+// make room.
+func (c *LRU) Set(key string, value interface{}) (evicted bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	expires := time.Now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		element.Value = &entry{key: key, value: value, expires: expires}
+		c.order.MoveToFront(element)
+		return false
+	}
+
+	c.entries[key] = c.order.PushFront(&entry{key: key, value: value, expires: expires})
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		return true
+	}
+	return false
+}
+
+// Delete removes the value stored for the key, if any.
+func (c *LRU) Delete(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+// Clear removes every value.
+func (c *LRU) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.order.Init()
+	c.entries = map[string]*list.Element{}
+}
+
+// Len returns the number of values stored, including expired ones that have not been removed yet.
+func (c *LRU) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.order.Len()
+}
+
+// remove removes an entry. It must be called with the mutex held.
+func (c *LRU) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*entry).key)
+}
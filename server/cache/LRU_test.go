@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEviction(t *testing.T) {
+	c := NewLRU(3, time.Hour)
+
+	for _, key := range []string{"a", "b", "c"} {
+		if c.Set(key, key) {
+			t.Errorf("Set(%q) evicted a value from a cache that was not full", key)
+		}
+	}
+
+	// Reading a makes b the least recently used, and replacing c makes it the most recently used
+	c.Get("a")
+	c.Set("c", "C")
+	if !c.Set("d", "d") {
+		t.Error("Set(\"d\") evicted nothing from a full cache")
+	}
+	if !c.Set("e", "e") {
+		t.Error("Set(\"e\") evicted nothing from a full cache")
+	}
+
+	tests := []struct {
+		key   string
+		value interface{}
+		ok    bool
+	}{
+		{key: "a", ok: false},
+		{key: "b", ok: false},
+		{key: "c", value: "C", ok: true},
+		{key: "d", value: "d", ok: true},
+		{key: "e", value: "e", ok: true},
+	}
+	for _, test := range tests {
+		if value, ok := c.Get(test.key); value != test.value || ok != test.ok {
+			t.Errorf("Get(%q) = %v, %t, want %v, %t", test.key, value, ok, test.value, test.ok)
+		}
+	}
+	if c.Len() != 3 {
+		t.Errorf("Len = %d, want 3", c.Len())
+	}
+}
+
+func TestLRUExpiry(t *testing.T) {
+	c := NewLRU(3, 100*time.Millisecond)
+	c.Set("a", 1)
+	time.Sleep(60 * time.Millisecond)
+	c.Set("b", 2)
+
+	// Reading a value does not extend its life, but replacing it does
+	time.Sleep(60 * time.Millisecond)
+	if _, ok := c.Get("a"); ok {
+		t.Error("Get(\"a\") found a value that has expired")
+	}
+	if value, ok := c.Get("b"); value != 2 || !ok {
+		t.Errorf("Get(\"b\") = %v, %t, want 2, true", value, ok)
+	}
+	c.Set("b", 3)
+
+	time.Sleep(60 * time.Millisecond)
+	if value, ok := c.Get("b"); value != 3 || !ok {
+		t.Errorf("Get(\"b\") = %v, %t after it was replaced, want 3, true", value, ok)
+	}
+
+	// Expired values are removed when they are read
+	if c.Len() != 1 {
+		t.Errorf("Len = %d, want 1", c.Len())
+	}
+}
+
+func TestLRUDeleteAndClear(t *testing.T) {
+	c := NewLRU(3, time.Hour)
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	c.Delete("a")
+	c.Delete("missing")
+	if _, ok := c.Get("a"); ok || c.Len() != 1 {
+		t.Errorf("after Delete: Get(\"a\") = %t, Len = %d, want false, 1", ok, c.Len())
+	}
+
+	c.Clear()
+	if _, ok := c.Get("b"); ok || c.Len() != 0 {
+		t.Errorf("after Clear: Get(\"b\") = %t, Len = %d, want false, 0", ok, c.Len())
+	}
+
+	// The cache is usable after it was cleared
+	c.Set("c", 3)
+	if value, ok := c.Get("c"); value != 3 || !ok {
+		t.Errorf("Get(\"c\") = %v, %t, want 3, true", value, ok)
+	}
+}
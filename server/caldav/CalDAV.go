@@ -0,0 +1,269 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// XML namespaces used by WebDAV, CalDAV and the CalendarServer extensions.
+const (
+	NamespaceDAV            = "DAV:"
+	NamespaceCalDAV         = "urn:ietf:params:xml:ns:caldav"
+	NamespaceCalendarServer = "http://calendarserver.org/ns/"
+)
+
+// prefixes are the namespace prefixes declared on every multistatus response.
+var prefixes = map[string]string{
+	NamespaceDAV:            "d",
+	NamespaceCalDAV:         "c",
+	NamespaceCalendarServer: "cs",
+}
+
+// Name returns the XML name of an element in the given namespace.
+func Name(space string, local string) xml.Name {
+	return xml.Name{Space: space, Local: local}
+}
+
+// Node is a generic XML element, used to read request bodies whose structure varies by method.
+type Node struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []Node     `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+// ParseBody reads an XML request body. It returns nil, without an error, if the body is empty.
+func ParseBody(r io.Reader) (*Node, error) {
+	node := &Node{}
+	err := xml.NewDecoder(r).Decode(node)
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// Is reports whether the element has the given name.
+func (n *Node) Is(space string, local string) bool {
+	return n != nil && n.XMLName.Space == space && n.XMLName.Local == local
+}
+
+// Child returns the first child element with the given name, or nil.
+func (n *Node) Child(space string, local string) *Node {
+	if n == nil {
+		return nil
+	}
+	for i := range n.Children {
+		if n.Children[i].Is(space, local) {
+			return &n.Children[i]
+		}
+	}
+	return nil
+}
+
+// All returns every child element with the given name.
+func (n *Node) All(space string, local string) []*Node {
+	var children []*Node
+	if n == nil {
+		return children
+	}
+	for i := range n.Children {
+		if n.Children[i].Is(space, local) {
+			children = append(children, &n.Children[i])
+		}
+	}
+	return children
+}
+
+// Attr returns the value of the attribute with the given local name, or "".
+func (n *Node) Attr(local string) string {
+	if n == nil {
+		return ""
+	}
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// PropRequest lists the properties requested by a PROPFIND or REPORT.
+// If AllProp is set, every property that is not expensive to compute is returned.
+type PropRequest struct {
+	AllProp bool
+	Names   []xml.Name
+}
+
+// ParsePropRequest reads the properties requested by a PROPFIND or REPORT body. An empty body requests all properties.
+func ParsePropRequest(root *Node) PropRequest {
+	if root == nil || root.Child(NamespaceDAV, "allprop") != nil {
+		return PropRequest{AllProp: true}
+	}
+
+	request := PropRequest{}
+	if prop := root.Child(NamespaceDAV, "prop"); prop != nil {
+		for _, child := range prop.Children {
+			request.Names = append(request.Names, child.XMLName)
+		}
+	}
+	return request
+}
+
+// Props maps property names to their values, as inner XML using the prefixes d:, c: and cs:.
+type Props map[xml.Name]string
+
+// Href returns a property value holding a single href.
+func Href(href string) string {
+	return "<d:href>" + escape(href) + "</d:href>"
+}
+
+// Text returns a property value holding escaped character data.
+func Text(s string) string {
+	return escape(s)
+}
+
+// response is a single resource in a multistatus response.
+type response struct {
+	href   string
+	status int
+	found  Props
+	absent []xml.Name
+}
+
+// Multistatus is a WebDAV 207 Multi-Status response.
+type Multistatus struct {
+	responses []response
+
+	// SyncToken is written for sync-collection reports.
+	SyncToken string
+}
+
+// Add adds a resource with the requested properties. Requested properties that the resource does not have are
+// reported with 404, and unrequested ones are omitted.
+func (m *Multistatus) Add(href string, props Props, request PropRequest) {
+	r := response{href: href, found: Props{}}
+
+	if request.AllProp {
+		r.found = props
+	} else {
+		for _, name := range request.Names {
+			if value, ok := props[name]; ok {
+				r.found[name] = value
+			} else {
+				r.absent = append(r.absent, name)
+			}
+		}
+	}
+
+	m.responses = append(m.responses, r)
+}
+
+// AddStatus adds a resource with a status but no properties, e.g. 404 for a deleted resource.
+func (m *Multistatus) AddStatus(href string, status int) {
+	m.responses = append(m.responses, response{href: href, status: status})
+}
+
+// Write writes the multistatus response with status 207.
+func (m *Multistatus) Write(w http.ResponseWriter) error {
+	var b strings.Builder
+
+	b.WriteString(xml.Header)
+	b.WriteString(`<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:cs="http://calendarserver.org/ns/">`)
+	for _, r := range m.responses {
+		b.WriteString("<d:response>")
+		b.WriteString(Href(r.href))
+		if r.status != 0 {
+			b.WriteString(status(r.status))
+		} else {
+			writePropstat(&b, r.found, nil, http.StatusOK)
+			writePropstat(&b, nil, r.absent, http.StatusNotFound)
+		}
+		b.WriteString("</d:response>")
+	}
+	if m.SyncToken != "" {
+		b.WriteString("<d:sync-token>" + escape(m.SyncToken) + "</d:sync-token>")
+	}
+	b.WriteString("</d:multistatus>")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteError writes a WebDAV error response with a single precondition element, e.g. valid-sync-token.
+func WriteError(w http.ResponseWriter, code int, condition xml.Name) error {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(code)
+	_, err := io.WriteString(w, xml.Header+`<d:error xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">`+emptyElement(condition)+`</d:error>`)
+	return err
+}
+
+// writePropstat writes a propstat element for either found properties or absent property names, if there are any.
+func writePropstat(b *strings.Builder, found Props, absent []xml.Name, code int) {
+	if len(found) == 0 && len(absent) == 0 {
+		return
+	}
+
+	b.WriteString("<d:propstat><d:prop>")
+
+	// Properties are written in a stable order
+	names := append([]xml.Name{}, absent...)
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i].Space != names[j].Space {
+			return names[i].Space < names[j].Space
+		}
+		return names[i].Local < names[j].Local
+	})
+
+	for _, name := range names {
+		value, ok := found[name]
+		if !ok || value == "" {
+			b.WriteString(emptyElement(name))
+			continue
+		}
+		start, end := element(name)
+		b.WriteString(start + value + end)
+	}
+
+	b.WriteString("</d:prop>")
+	b.WriteString(status(code))
+	b.WriteString("</d:propstat>")
+}
+
+// element returns the opening and closing tags of an element, declaring its namespace if it has no known prefix.
+func element(name xml.Name) (string, string) {
+	if prefix, ok := prefixes[name.Space]; ok {
+		return "<" + prefix + ":" + name.Local + ">", "</" + prefix + ":" + name.Local + ">"
+	}
+	return `<x:` + name.Local + ` xmlns:x="` + escape(name.Space) + `">`, "</x:" + name.Local + ">"
+}
+
+// emptyElement returns a self-closing element.
+func emptyElement(name xml.Name) string {
+	start, _ := element(name)
+	return strings.TrimSuffix(start, ">") + "/>"
+}
+
+// status returns a status element for the given HTTP status code.
+func status(code int) string {
+	return "<d:status>HTTP/1.1 " + strconv.Itoa(code) + " " + http.StatusText(code) + "</d:status>"
+}
+
+// escape escapes character data for inclusion in XML.
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package caldav
+
+import (
+	"strings"
+	"time"
+)
+
+// Filter is the subset of a calendar-query filter (RFC 4791, section 9.7) that applies to to-dos:
+// the component type, a time-range on DUE, and the completion state via COMPLETED or STATUS.
+type Filter struct {
+	// Component is the component type requested inside VCALENDAR, usually VTODO.
+	Component string
+
+	// Start and End bound the DUE date as Unix millisecond timestamps; 0 leaves a bound open.
+	Start int64
+	End   int64
+
+	// Completed restricts results to completed (true) or open (false) items if it is set.
+	Completed *bool
+}
+
+// ParseFilter reads the filter of a calendar-query report. A missing filter matches every to-do.
+func ParseFilter(query *Node) Filter {
+	filter := Filter{Component: "VTODO"}
+
+	calendar := query.Child(NamespaceCalDAV, "filter").Child(NamespaceCalDAV, "comp-filter")
+	component := calendar.Child(NamespaceCalDAV, "comp-filter")
+	if component == nil {
+		return filter
+	}
+	filter.Component = strings.ToUpper(component.Attr("name"))
+
+	if timeRange := component.Child(NamespaceCalDAV, "time-range"); timeRange != nil {
+		filter.Start = parseUTC(timeRange.Attr("start"))
+		filter.End = parseUTC(timeRange.Attr("end"))
+	}
+
+	for _, prop := range component.All(NamespaceCalDAV, "prop-filter") {
+		switch strings.ToUpper(prop.Attr("name")) {
+		case "COMPLETED":
+			completed := prop.Child(NamespaceCalDAV, "is-not-defined") == nil
+			filter.Completed = &completed
+		case "STATUS":
+			match := prop.Child(NamespaceCalDAV, "text-match")
+			if match == nil {
+				continue
+			}
+			completed := strings.EqualFold(strings.TrimSpace(match.Text), "COMPLETED")
+			if match.Attr("negate-condition") == "yes" {
+				completed = !completed
+			}
+			filter.Completed = &completed
+		}
+	}
+
+	return filter
+}
+
+// parseUTC parses a UTC DATE-TIME attribute value, returning 0 if it is missing or invalid.
+func parseUTC(value string) int64 {
+	t, err := time.Parse("20060102T150405Z", value)
+	if err != nil {
+		return 0
+	}
+	return t.UnixMilli()
+}
@@ -0,0 +1,73 @@
+package compression
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// encoder compresses what is written to it into the writer it was last reset to.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoders pools the encoders of every encoding, which are costly to create.
+var encoders = map[string]*sync.Pool{
+	"zstd": {New: func() interface{} {
+		// A single goroutine per encoder, and a window small enough for browsers to decode
+		e, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithWindowSize(1<<20))
+		return e
+	}},
+	"br": {New: func() interface{} {
+		return brotli.NewWriterLevel(nil, brotli.DefaultCompression)
+	}},
+	"gzip": {New: func() interface{} {
+		e, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return e
+	}},
+}
+
+// negotiate picks the encoding to compress a response in out of offered, most preferred first: the one the client
+// accepts with the highest quality in its Accept-Encoding header, the most preferred of those that tie. It returns ""
+// if the client accepts none of them.
+func negotiate(acceptEncoding string, offered []string) string {
+	qualities := map[string]float64{}
+	for _, element := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(element, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(param, "=")
+			if strings.TrimSpace(name) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		qualities[coding] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, encoding := range offered {
+		quality, ok := qualities[encoding]
+		if !ok {
+			// * stands for every encoding not listed
+			quality = qualities["*"]
+		}
+		if quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
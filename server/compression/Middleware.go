@@ -0,0 +1,180 @@
+// Package compression compresses responses in the encoding the client prefers out of those it accepts in its
+// Accept-Encoding header: zstd, brotli or gzip.
+//
+// Only responses of compressible types, such as JSON and text, are compressed, and only once they reach the minimum
+// size; until then they are held back, even if the handler flushes them. Streamed responses are compressed as they
+// are written, and flushed through the encoder. Server-sent events are never compressed, so that every event is sent
+// as soon as it is flushed.
+package compression
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/L4TTiCe/ToDo-Go/server/config"
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware compresses responses as settings say.
+func Middleware(settings *config.CompressionConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !settings.Enabled {
+			c.Next()
+			return
+		}
+
+		// Caches must not give a compressed response to a client that does not accept it
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiate(c.GetHeader("Accept-Encoding"), settings.Encodings)
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &writer{ResponseWriter: c.Writer, encoding: encoding, minSize: settings.MinSize}
+		c.Writer = w
+		c.Next()
+
+		if err := w.finish(); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
+// writer compresses the response written to it, once it knows that it is worth it.
+type writer struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+
+	// started is set once it has been decided whether to compress the response, before which it is buffered
+	started bool
+	buffer  []byte
+	// encoder is nil unless the response is compressed
+	encoder encoder
+}
+
+func (w *writer) Write(data []byte) (int, error) {
+	if !w.started {
+		w.buffer = append(w.buffer, data...)
+		if len(w.buffer) < w.minSize {
+			return len(data), nil
+		}
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *writer) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *writer) WriteHeaderNow() {
+	if !w.started {
+		w.start(false)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *writer) Flush() {
+	if !w.started {
+		// Hold back a response that may still reach the minimum size
+		if w.compressible() {
+			return
+		}
+		if err := w.start(false); err != nil {
+			return
+		}
+	}
+
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// start decides whether to compress the response, only if compress is set, and writes what has been buffered.
+func (w *writer) start(compress bool) error {
+	w.started = true
+
+	if compress && w.compressible() {
+		header := w.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		// The compressed body differs from the one the strong ETag was computed for, though it means the same
+		if etag := header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			header.Set("ETag", "W/"+etag)
+		}
+
+		w.encoder = encoders[w.encoding].Get().(encoder)
+		w.encoder.Reset(w.ResponseWriter)
+	}
+
+	buffered := w.buffer
+	w.buffer = nil
+	if len(buffered) == 0 {
+		return nil
+	}
+	if w.encoder != nil {
+		_, err := w.encoder.Write(buffered)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buffered)
+	return err
+}
+
+// finish writes the rest of the response once the handler is done.
+func (w *writer) finish() error {
+	if !w.started {
+		// The response is smaller than the minimum size
+		return w.start(false)
+	}
+	if w.encoder == nil {
+		return nil
+	}
+
+	err := w.encoder.Close()
+	// An encoder left broken by a failed write is not reused
+	if err == nil {
+		encoders[w.encoding].Put(w.encoder)
+	}
+	return err
+}
+
+// compressible reports whether the response has a body of a type worth compressing, which has not been encoded yet.
+func (w *writer) compressible() bool {
+	status := w.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	if w.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "text/event-stream":
+		return false
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/x-ndjson", "application/xml", "application/javascript":
+		return true
+	}
+	return false
+}
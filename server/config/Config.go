@@ -0,0 +1,320 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environments select the defaults of the settings that should differ between development and production.
+const (
+	Development = "development"
+	Production  = "production"
+)
+
+// Config is the configuration of the server. Every setting can be given in the config file, by the environment
+// variable named in its env tag, and by a flag named after its path in the file, e.g. --database.name; see Load.
+type Config struct {
+	Environment string `yaml:"environment" toml:"environment" env:"APP_ENV"`
+
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	Database    DatabaseConfig    `yaml:"database" toml:"database"`
+	Log         LogConfig         `yaml:"log" toml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing" toml:"tracing"`
+	CORS        CORSConfig        `yaml:"cors" toml:"cors"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	RateLimit   RateLimitConfig   `yaml:"rateLimit" toml:"rateLimit"`
+	Cache       CacheConfig       `yaml:"cache" toml:"cache"`
+	Compression CompressionConfig `yaml:"compression" toml:"compression"`
+	Feed        FeedConfig        `yaml:"feed" toml:"feed"`
+}
+
+// ServerConfig configures the HTTP and gRPC servers and their shutdown.
+type ServerConfig struct {
+	// PORT and GRPC_PORT, which only set the port, are still honoured
+	Address     string `yaml:"address" toml:"address" env:"HTTP_ADDRESS"`
+	GRPCAddress string `yaml:"grpcAddress" toml:"grpcAddress" env:"GRPC_ADDRESS"`
+	// The IPs or CIDR ranges of the proxies whose X-Forwarded-For headers are believed, e.g. 10.0.0.0/8. The client
+	// IP of requests through other proxies is the proxy's
+	TrustedProxies []string `yaml:"trustedProxies" toml:"trustedProxies" env:"TRUSTED_PROXIES"`
+
+	ReadTimeout Duration `yaml:"readTimeout" toml:"readTimeout" env:"HTTP_READ_TIMEOUT"`
+	// Disabled by default, since subscriptions and exports stream their responses for as long as they need
+	WriteTimeout Duration `yaml:"writeTimeout" toml:"writeTimeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout  Duration `yaml:"idleTimeout" toml:"idleTimeout" env:"HTTP_IDLE_TIMEOUT"`
+	// How long to keep serving after readiness fails, so that load balancers stop sending requests first
+	ShutdownDelay   Duration `yaml:"shutdownDelay" toml:"shutdownDelay" env:"SHUTDOWN_DELAY"`
+	ShutdownTimeout Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+// DatabaseConfig configures the connection to MongoDB: either URI, or the parts it is built from.
+type DatabaseConfig struct {
+	URI      string `yaml:"uri" toml:"uri" env:"MONGODB_URI" secret:"url"`
+	Prefix   string `yaml:"prefix" toml:"prefix" env:"MONGODB_PREFIX"`
+	User     string `yaml:"user" toml:"user" env:"MONGODB_USER"`
+	Password string `yaml:"password" toml:"password" env:"MONGODB_PASSWORD" secret:"true"`
+	Host     string `yaml:"host" toml:"host" env:"MONGODB_HOST"`
+	Port     string `yaml:"port" toml:"port" env:"MONGODB_DOCKER_PORT"`
+
+	Name        string            `yaml:"name" toml:"name" env:"MONGODB_DATABASE"`
+	Collections CollectionsConfig `yaml:"collections" toml:"collections"`
+
+	// Timeout limits every operation, unless OperationTimeouts, keyed by DAO and function, e.g.
+	// ToDoItemDao.RetrieveAll, gives it a timeout of its own
+	Timeout           Duration            `yaml:"timeout" toml:"timeout" env:"DB_TIMEOUT"`
+	OperationTimeouts map[string]Duration `yaml:"operationTimeouts" toml:"operationTimeouts" env:"DB_OPERATION_TIMEOUTS"`
+
+	// MigrateOnStartup applies the pending migrations before serving; without it, they are applied by the migrate
+	// command. MigrationTimeout limits applying them, including waiting for another instance applying them already
+	MigrateOnStartup bool     `yaml:"migrateOnStartup" toml:"migrateOnStartup" env:"DB_MIGRATE_ON_STARTUP"`
+	MigrationTimeout Duration `yaml:"migrationTimeout" toml:"migrationTimeout" env:"DB_MIGRATION_TIMEOUT"`
+}
+
+// CollectionsConfig names the collections of the database.
+type CollectionsConfig struct {
+	ToDoItems       string `yaml:"todoItems" toml:"todoItems" env:"MONGODB_COLLECTION_TODOITEMS"`
+	Tombstones      string `yaml:"tombstones" toml:"tombstones" env:"MONGODB_COLLECTION_TOMBSTONES"`
+	FeedTokens      string `yaml:"feedTokens" toml:"feedTokens" env:"MONGODB_COLLECTION_FEEDTOKENS"`
+	Counters        string `yaml:"counters" toml:"counters" env:"MONGODB_COLLECTION_COUNTERS"`
+	IdempotencyKeys string `yaml:"idempotencyKeys" toml:"idempotencyKeys" env:"MONGODB_COLLECTION_IDEMPOTENCYKEYS"`
+	Migrations      string `yaml:"migrations" toml:"migrations" env:"MONGODB_COLLECTION_MIGRATIONS"`
+}
+
+// LogConfig configures the logger.
+type LogConfig struct {
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+}
+
+// TracingConfig configures where traces are exported to. The exporters themselves are configured by the standard
+// OTEL_* environment variables, e.g. OTEL_EXPORTER_OTLP_ENDPOINT.
+type TracingConfig struct {
+	Exporter string `yaml:"exporter" toml:"exporter" env:"OTEL_TRACES_EXPORTER"`
+}
+
+// CORSConfig configures which browser origins may call the API, and what they may send and see.
+type CORSConfig struct {
+	// Each origin is * for any, an origin such as https://example.com, or one with a wildcard subdomain such as
+	// https://*.example.com
+	AllowedOrigins []string `yaml:"allowedOrigins" toml:"allowedOrigins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods []string `yaml:"allowedMethods" toml:"allowedMethods" env:"CORS_ALLOWED_METHODS"`
+	// The headers of the API itself, such as Idempotency-Key and X-Request-ID, are always allowed and exposed
+	AllowedHeaders   []string `yaml:"allowedHeaders" toml:"allowedHeaders" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders   []string `yaml:"exposedHeaders" toml:"exposedHeaders" env:"CORS_EXPOSED_HEADERS"`
+	AllowCredentials bool     `yaml:"allowCredentials" toml:"allowCredentials" env:"CORS_ALLOW_CREDENTIALS"`
+	// How long browsers may cache the result of a preflight request
+	MaxAge Duration `yaml:"maxAge" toml:"maxAge" env:"CORS_MAX_AGE"`
+}
+
+// IdempotencyConfig configures how long Idempotency-Keys are remembered.
+type IdempotencyConfig struct {
+	TTL Duration `yaml:"ttl" toml:"ttl" env:"IDEMPOTENCY_TTL"`
+}
+
+// RateLimitConfig configures how many requests each client, identified by its API key, user or IP, may make.
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	// Default limits the requests to the routes without a limit of their own in Routes, keyed by method and route,
+	// e.g. GET /todo/:id
+	Default Limit            `yaml:"default" toml:"default" env:"RATE_LIMIT_DEFAULT"`
+	Routes  map[string]Limit `yaml:"routes" toml:"routes" env:"RATE_LIMIT_ROUTES"`
+	// PerIP limits the requests from each IP to every route together, whatever API keys or users they are made
+	// with, since those are not verified
+	PerIP Limit `yaml:"perIP" toml:"perIP" env:"RATE_LIMIT_PER_IP"`
+	// Store is memory, or redis to share the limits between every instance of the server
+	Store    string `yaml:"store" toml:"store" env:"RATE_LIMIT_STORE"`
+	RedisURL string `yaml:"redisURL" toml:"redisURL" env:"RATE_LIMIT_REDIS_URL" secret:"url"`
+}
+
+// FeedConfig configures who may create the tokens of the iCalendar feed.
+type FeedConfig struct {
+	// AdminToken, sent as a Bearer token, creates feed tokens for any user. Without it, tokens can only be created by
+	// users who hold one already, so no new users can subscribe
+	AdminToken string `yaml:"adminToken" toml:"adminToken" env:"FEED_ADMIN_TOKEN" secret:"true"`
+}
+
+// CacheConfig configures the in-process cache of items and lists of items read from the database, and how long
+// clients may cache responses.
+type CacheConfig struct {
+	// Items and Lists are how many items and lists are cached at most; 0 disables caching them
+	Items int      `yaml:"items" toml:"items" env:"CACHE_ITEMS"`
+	Lists int      `yaml:"lists" toml:"lists" env:"CACHE_LISTS"`
+	TTL   Duration `yaml:"ttl" toml:"ttl" env:"CACHE_TTL"`
+	// MaxAge is how long clients may use a response before revalidating it with its ETag
+	MaxAge Duration `yaml:"maxAge" toml:"maxAge" env:"CACHE_MAX_AGE"`
+}
+
+// CompressionConfig configures the compression of responses, in the encodings the client accepts.
+type CompressionConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"COMPRESSION_ENABLED"`
+	// Encodings are the encodings offered, most preferred first, out of zstd, br and gzip
+	Encodings []string `yaml:"encodings" toml:"encodings" env:"COMPRESSION_ENCODINGS"`
+	// MinSize is the size in bytes below which responses are not worth compressing
+	MinSize int `yaml:"minSize" toml:"minSize" env:"COMPRESSION_MIN_SIZE"`
+}
+
+// Default returns the configuration used for settings that are not given in the environment, which is Development
+// unless it is Production.
+func Default(environment string) *Config {
+	if environment != Production {
+		environment = Development
+	}
+
+	config := &Config{
+		Environment: environment,
+		Server: ServerConfig{
+			Address:         ":8080",
+			GRPCAddress:     ":9090",
+			ReadTimeout:     Duration(30 * time.Second),
+			IdleTimeout:     Duration(120 * time.Second),
+			ShutdownTimeout: Duration(30 * time.Second),
+		},
+		Database: DatabaseConfig{
+			Name: "test",
+			Collections: CollectionsConfig{
+				ToDoItems:       "ToDoItems",
+				Tombstones:      "Tombstones",
+				FeedTokens:      "FeedTokens",
+				Counters:        "Counters",
+				IdempotencyKeys: "IdempotencyKeys",
+				Migrations:      "Migrations",
+			},
+			// The same as dao.DefaultTimeout, which cannot be referred to here since the DAOs depend on this package
+			Timeout:           Duration(10 * time.Second),
+			OperationTimeouts: map[string]Duration{},
+			MigrateOnStartup:  true,
+			// Building the indexes of a large collection takes a while
+			MigrationTimeout: Duration(10 * time.Minute),
+		},
+		Log: LogConfig{
+			Format: "console",
+			Level:  "info",
+		},
+		Tracing: TracingConfig{
+			Exporter: "none",
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+			AllowedHeaders: []string{"Origin", "Accept", "Authorization", "Content-Type", "Content-Length"},
+			ExposedHeaders: []string{},
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: Duration(24 * time.Hour),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Default: Limit{Requests: 600, Period: time.Minute},
+			// Listing, exporting and importing every item is far more expensive than handling a single one
+			Routes: map[string]Limit{
+				"GET /todo/":        {Requests: 60, Period: time.Minute},
+				"GET /todo/export":  {Requests: 10, Period: time.Minute},
+				"POST /todo/import": {Requests: 10, Period: time.Minute},
+			},
+			PerIP: Limit{Requests: 1200, Period: time.Minute},
+			Store: "memory",
+		},
+		Cache: CacheConfig{
+			Items: 1000,
+			Lists: 100,
+			// Writes through other instances of the server are only seen once the entries expire
+			TTL: Duration(30 * time.Second),
+		},
+		Compression: CompressionConfig{
+			Enabled:   true,
+			Encodings: []string{"zstd", "br", "gzip"},
+			MinSize:   1024,
+		},
+	}
+
+	switch environment {
+	case Development:
+		// Any page may call the API, e.g. a frontend served by a development server on another port
+		config.CORS.AllowedOrigins = []string{"*"}
+		config.CORS.MaxAge = Duration(10 * time.Minute)
+	case Production:
+		// Only pages on the API's own origin may call it, until the frontend's origins are allowed
+		config.CORS.AllowedOrigins = []string{}
+		config.CORS.MaxAge = Duration(2 * time.Hour)
+	}
+
+	return config
+}
+
+// ConnectionURI returns URI, or the URI built from its parts if it is not set.
+func (database *DatabaseConfig) ConnectionURI() string {
+	if database.URI != "" {
+		return database.URI
+	}
+
+	uri := database.Prefix + "://" + url.UserPassword(database.User, database.Password).String() + "@" + database.Host
+	if database.Port != "" {
+		uri += ":" + database.Port
+	}
+	return uri
+}
+
+// Timeouts returns OperationTimeouts as plain durations.
+func (database *DatabaseConfig) Timeouts() map[string]time.Duration {
+	timeouts := make(map[string]time.Duration, len(database.OperationTimeouts))
+	for operation, timeout := range database.OperationTimeouts {
+		timeouts[operation] = time.Duration(timeout)
+	}
+	return timeouts
+}
+
+// Duration is a time.Duration written as a string such as 30s or 1h30m in the config file, the environment and flags.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(strings.TrimSpace(string(text)))
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// Limit is a rate limit written as requests/period, e.g. 100/1m: a client may make all of the requests at once, and
+// then one more every period/requests. The zero Limit, written as none, does not limit anything.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (l Limit) String() string {
+	if l.Requests == 0 {
+		return "none"
+	}
+	return strconv.Itoa(l.Requests) + "/" + l.Period.String()
+}
+
+func (l Limit) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *Limit) UnmarshalText(text []byte) error {
+	value := strings.TrimSpace(string(text))
+	if value == "none" {
+		*l = Limit{}
+		return nil
+	}
+
+	requests, period, found := strings.Cut(value, "/")
+	parsedRequests, err := strconv.Atoi(requests)
+	if !found || err != nil || parsedRequests <= 0 {
+		return fmt.Errorf("%q must be none, or a positive number of requests per period such as 100/1m", value)
+	}
+	parsedPeriod, err := time.ParseDuration(period)
+	if err != nil || parsedPeriod <= 0 {
+		return fmt.Errorf("%q must be none, or a positive number of requests per period such as 100/1m", value)
+	}
+
+	*l = Limit{Requests: parsedRequests, Period: parsedPeriod}
+	return nil
+}
@@ -2,48 +2,40 @@ package config
 
 import (
 	"context"
-	"log"
-	"os"
 
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/metrics"
+	"github.com/L4TTiCe/ToDo-Go/server/tracing"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.uber.org/zap"
 )
 
 var DB *mongo.Client
 
 var ToDoItemsCollection *mongo.Collection
 
-func constructURI() string {
-	uri := os.Getenv("MONGODB_URI")
-
-	MONGODB_USER := os.Getenv("MONGODB_USER")
-	MONGODB_PASSWORD := os.Getenv("MONGODB_PASSWORD")
-	MONGODB_PREFIX := os.Getenv("MONGODB_PREFIX")
-	MONGODB_HOST := os.Getenv("MONGODB_HOST")
-	MONGODB_PORT := os.Getenv("MONGODB_DOCKER_PORT")
-
-	if uri == "" {
-		if (MONGODB_USER != "") && (MONGODB_PASSWORD != "") && (MONGODB_PREFIX != "") && (MONGODB_HOST != "") {
-			log.Println("Using environment variables for DB connection")
-			uri = MONGODB_PREFIX + "://" + MONGODB_USER + ":" + MONGODB_PASSWORD + "@" + MONGODB_HOST
-			if MONGODB_PORT != "" {
-				uri += ":" + MONGODB_PORT
-			}
-		} else {
-			log.Fatal("You must set your 'MONGODB_URI' environmental variable. See\n\t https://www.mongodb.com/docs/drivers/go/current/usage-examples/#environment-variable")
-		}
+var TombstonesCollection *mongo.Collection
+
+var FeedTokensCollection *mongo.Collection
+
+var CountersCollection *mongo.Collection
+
+var IdempotencyKeysCollection *mongo.Collection
+
+var MigrationsCollection *mongo.Collection
+
+// ConnectMongoDB connects to the database, and links the collections.
+func ConnectMongoDB(database *DatabaseConfig) {
+	if database.URI != "" {
+		logging.L().Info("Using the database URI for DB connection")
 	} else {
-		log.Println("Using 'MONGODB_URI' environmental variable for DB connection")
+		logging.L().Info("Using the database host and credentials for DB connection")
 	}
-	return uri
-}
-
-func ConnectMongoDB() {
-	uri := constructURI()
 
-	log.Println("Connecting to DB")
-	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri))
+	logging.L().Info("Connecting to DB", zap.String("database", database.Name))
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(database.ConnectionURI()).SetPoolMonitor(metrics.PoolMonitor()).SetMonitor(tracing.CommandMonitor()))
 	if err != nil {
 		panic(err)
 	}
@@ -51,20 +43,31 @@ func ConnectMongoDB() {
 	if err := client.Ping(context.Background(), readpref.Primary()); err != nil {
 		panic(err)
 	}
-	log.Println("Successfully connected and pinged DB")
+	logging.L().Info("Successfully connected and pinged DB")
 
 	DB = client
-	linkCollections()
+	linkCollections(database)
+}
+
+// Ping checks that the DB answers, for health checks.
+func Ping(ctx context.Context) error {
+	return DB.Ping(ctx, readpref.Primary())
 }
 
 func CloseClientDB() {
-	log.Println("Disconnecting from DB")
+	logging.L().Info("Disconnecting from DB")
 	if err := DB.Disconnect(context.Background()); err != nil {
 		panic(err)
 	}
 }
 
-func linkCollections() {
-	log.Println("Linking Collections...")
-	ToDoItemsCollection = DB.Database("test").Collection("ToDoItems")
+func linkCollections(database *DatabaseConfig) {
+	logging.L().Info("Linking Collections...")
+	db := DB.Database(database.Name)
+	ToDoItemsCollection = db.Collection(database.Collections.ToDoItems)
+	TombstonesCollection = db.Collection(database.Collections.Tombstones)
+	FeedTokensCollection = db.Collection(database.Collections.FeedTokens)
+	CountersCollection = db.Collection(database.Collections.Counters)
+	IdempotencyKeysCollection = db.Collection(database.Collections.IdempotencyKeys)
+	MigrationsCollection = db.Collection(database.Collections.Migrations)
 }
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// Errors lists every problem found with the configuration, so that they can all be fixed at once.
+type Errors []string
+
+func (errs Errors) Error() string {
+	return "invalid configuration:\n  " + strings.Join(errs, "\n  ")
+}
+
+// Load reads the configuration from, in increasing order of precedence, the defaults of the environment, the config
+// file, environment variables and flags. The config file is named by the --config flag or the CONFIG_FILE environment
+// variable, and is read as YAML or TOML depending on its extension. args are the command line arguments, without the
+// program's name.
+//
+// printConfig reports whether --print-config was given. If the configuration is invalid, it is returned along with
+// Errors listing every problem, so that it can still be printed. flag.ErrHelp is returned for --help.
+func Load(name string, args []string) (config *Config, printConfig bool, err error) {
+	// Flags are applied last, so they are only recorded while parsing
+	type assignment struct {
+		path  string
+		value string
+	}
+	var assignments []assignment
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	path := flags.String("config", os.Getenv("CONFIG_FILE"), "`file` to read the configuration from, in YAML or TOML (env CONFIG_FILE)")
+	flags.BoolVar(&printConfig, "print-config", false, "print the configuration, with secrets redacted, and exit")
+	for _, s := range settingsOf(Default(Development)) {
+		s := s
+		flags.Func(s.path, "sets "+s.path+" (env "+s.env+")", func(value string) error {
+			assignments = append(assignments, assignment{s.path, value})
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, false, err
+	}
+	if flags.NArg() > 0 {
+		return nil, false, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+
+	var file []byte
+	if *path != "" {
+		if file, err = os.ReadFile(*path); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// apply overrides the defaults in config with the config file, the environment and the flags
+	apply := func(config *Config) (errs Errors, err error) {
+		if file != nil {
+			if err := decodeFile(*path, file, config); err != nil {
+				return nil, err
+			}
+		}
+
+		// PORT and GRPC_PORT predate the addresses, which take precedence over them
+		if port := os.Getenv("PORT"); port != "" {
+			config.Server.Address = ":" + port
+		}
+		if port := os.Getenv("GRPC_PORT"); port != "" {
+			config.Server.GRPCAddress = ":" + port
+		}
+
+		settings := map[string]setting{}
+		for _, s := range settingsOf(config) {
+			settings[s.path] = s
+			if value := os.Getenv(s.env); value != "" {
+				if err := s.set(value); err != nil {
+					errs = append(errs, fmt.Sprintf("%s: %v", s.env, err))
+				}
+			}
+		}
+
+		for _, a := range assignments {
+			if err := settings[a.path].set(a.value); err != nil {
+				errs = append(errs, fmt.Sprintf("--%s: %v", a.path, err))
+			}
+		}
+		return errs, nil
+	}
+
+	// The environment can be set like any other setting, so everything is applied once to find it, and then again
+	// over its defaults
+	probe := Default(Development)
+	if _, err := apply(probe); err != nil {
+		return nil, false, err
+	}
+
+	config = Default(probe.Environment)
+	errs, err := apply(config)
+	if err != nil {
+		return nil, false, err
+	}
+
+	errs = append(errs, config.validate()...)
+	if len(errs) > 0 {
+		return config, printConfig, errs
+	}
+	return config, printConfig, nil
+}
+
+// decodeFile reads the config file into config, rejecting settings it does not know so that typos do not go
+// unnoticed.
+func decodeFile(path string, data []byte, config *Config) (err error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, config)
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(config)
+	default:
+		return fmt.Errorf("reading %s: unknown format, must be one of the following: .yaml, .yml, .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	return nil
+}
+
+// Print writes the configuration as YAML, in the format of the config file, with secrets redacted.
+func (config *Config) Print(w io.Writer) error {
+	redacted := *config
+	for _, s := range settingsOf(&redacted) {
+		s.redact()
+	}
+
+	data, err := yaml.Marshal(&redacted)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// setting is a single setting of a Config.
+type setting struct {
+	// path is the setting's key in the config file, with the keys of the sections it is in, e.g. database.name
+	path   string
+	env    string
+	secret string
+	value  reflect.Value
+}
+
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// settingsOf returns the settings of config, which are set through the returned values.
+func settingsOf(config *Config) []setting {
+	var settings []setting
+
+	var walk func(prefix string, section reflect.Value)
+	walk = func(prefix string, section reflect.Value) {
+		for i := 0; i < section.NumField(); i++ {
+			field := section.Type().Field(i)
+			path := prefix + field.Tag.Get("yaml")
+			// Sections are structs; settings such as a Limit that are written as text are not
+			if field.Type.Kind() == reflect.Struct && !reflect.PtrTo(field.Type).Implements(textUnmarshaler) {
+				walk(path+".", section.Field(i))
+				continue
+			}
+			settings = append(settings, setting{
+				path:   path,
+				env:    field.Tag.Get("env"),
+				secret: field.Tag.Get("secret"),
+				value:  section.Field(i),
+			})
+		}
+	}
+	walk("", reflect.ValueOf(config).Elem())
+
+	return settings
+}
+
+// set parses value, as given in an environment variable or flag, into the setting. Lists are comma separated, and
+// maps are comma separated key=value pairs; see setMap.
+func (s setting) set(value string) error {
+	switch target := s.value.Addr().Interface().(type) {
+	case *string:
+		*target = value
+	case *bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q must be true or false", value)
+		}
+		*target = parsed
+	case *int:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q must be a whole number", value)
+		}
+		*target = parsed
+	case encoding.TextUnmarshaler:
+		return target.UnmarshalText([]byte(value))
+	case *[]string:
+		*target = nil
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*target = append(*target, item)
+			}
+		}
+	default:
+		if s.value.Kind() == reflect.Map && reflect.PtrTo(s.value.Type().Elem()).Implements(textUnmarshaler) {
+			return s.setMap(value)
+		}
+		return errors.New("unsupported type " + s.value.Type().String())
+	}
+	return nil
+}
+
+// setMap parses comma separated key=value pairs into a map setting.
+func (s setting) setMap(value string) error {
+	entries := reflect.MakeMap(s.value.Type())
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		key, text, found := strings.Cut(entry, "=")
+		if !found {
+			return fmt.Errorf("%q must be of the form key=value", entry)
+		}
+		element := reflect.New(s.value.Type().Elem())
+		if err := element.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+			return fmt.Errorf("%q: %w", entry, err)
+		}
+		entries.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)), element.Elem())
+	}
+	s.value.Set(entries)
+	return nil
+}
+
+// redact hides the setting's value if it is a secret: entirely, or just the password of a URL.
+func (s setting) redact() {
+	text, ok := s.value.Addr().Interface().(*string)
+	if s.secret == "" || !ok || *text == "" {
+		return
+	}
+
+	original := *text
+	*text = "REDACTED"
+	if s.secret == "url" {
+		if u, err := url.Parse(original); err == nil {
+			*text = u.Redacted()
+		}
+	}
+}
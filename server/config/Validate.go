@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// validator collects the problems found with a Config, naming each setting by its key and environment variable.
+type validator struct {
+	env  map[string]string
+	errs Errors
+}
+
+func (v *validator) check(ok bool, path string, format string, args ...interface{}) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Sprintf("%s (%s) %s", path, v.env[path], fmt.Sprintf(format, args...)))
+	}
+}
+
+func (v *validator) oneOf(path string, value string, options ...string) {
+	for _, option := range options {
+		if value == option {
+			return
+		}
+	}
+	v.check(false, path, "is %q, must be one of the following: %s", value, strings.Join(options, ", "))
+}
+
+func (v *validator) address(path string, value string) {
+	_, _, err := net.SplitHostPort(value)
+	v.check(err == nil, path, "is %q, must be a host and port such as :8080", value)
+}
+
+func (v *validator) positive(path string, value Duration) {
+	v.check(value > 0, path, "must be a positive duration such as 30s")
+}
+
+func (v *validator) notNegative(path string, value Duration) {
+	v.check(value >= 0, path, "must not be negative")
+}
+
+func (v *validator) notEmpty(path string, value string) {
+	v.check(value != "", path, "must be set")
+}
+
+// validate returns every problem found with the configuration.
+func (config *Config) validate() Errors {
+	v := &validator{env: map[string]string{}}
+	for _, s := range settingsOf(config) {
+		v.env[s.path] = s.env
+	}
+
+	v.oneOf("environment", config.Environment, Development, Production)
+
+	server := &config.Server
+	v.address("server.address", server.Address)
+	v.address("server.grpcAddress", server.GRPCAddress)
+	for _, proxy := range server.TrustedProxies {
+		_, _, err := net.ParseCIDR(proxy)
+		v.check(err == nil || net.ParseIP(proxy) != nil, "server.trustedProxies", "has %q, which must be an IP or CIDR range such as 10.0.0.0/8", proxy)
+	}
+	v.notNegative("server.readTimeout", server.ReadTimeout)
+	v.notNegative("server.writeTimeout", server.WriteTimeout)
+	v.notNegative("server.idleTimeout", server.IdleTimeout)
+	v.notNegative("server.shutdownDelay", server.ShutdownDelay)
+	v.positive("server.shutdownTimeout", server.ShutdownTimeout)
+
+	database := &config.Database
+	if database.URI == "" {
+		v.check(database.Prefix != "" && database.User != "" && database.Password != "" && database.Host != "", "database.uri",
+			"must be set, or else database.prefix, database.user, database.password and database.host; see https://www.mongodb.com/docs/drivers/go/current/usage-examples/#environment-variable")
+	} else {
+		_, err := url.Parse(database.URI)
+		v.check(err == nil, "database.uri", "must be a MongoDB connection string")
+	}
+	v.notEmpty("database.name", database.Name)
+	v.notEmpty("database.collections.todoItems", database.Collections.ToDoItems)
+	v.notEmpty("database.collections.tombstones", database.Collections.Tombstones)
+	v.notEmpty("database.collections.feedTokens", database.Collections.FeedTokens)
+	v.notEmpty("database.collections.counters", database.Collections.Counters)
+	v.notEmpty("database.collections.idempotencyKeys", database.Collections.IdempotencyKeys)
+	v.notEmpty("database.collections.migrations", database.Collections.Migrations)
+	v.positive("database.timeout", database.Timeout)
+	for operation, timeout := range database.OperationTimeouts {
+		v.check(strings.Contains(operation, "."), "database.operationTimeouts", "has %q, which must name a DAO and function such as ToDoItemDao.RetrieveAll", operation)
+		v.positive("database.operationTimeouts", timeout)
+	}
+	v.positive("database.migrationTimeout", database.MigrationTimeout)
+
+	v.oneOf("log.format", config.Log.Format, "console", "json")
+	v.oneOf("log.level", config.Log.Level, "debug", "info", "warn", "error")
+	v.oneOf("tracing.exporter", config.Tracing.Exporter, "none", "otlp", "stdout", "console")
+
+	cors := &config.CORS
+	for _, origin := range cors.AllowedOrigins {
+		if origin == "*" {
+			v.check(!cors.AllowCredentials, "cors.allowedOrigins", "must not allow any origin with * while cors.allowCredentials is set")
+			continue
+		}
+		u, err := url.Parse(origin)
+		valid := err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.User == nil && u.Path == "" && u.RawQuery == ""
+		if valid {
+			host := strings.TrimPrefix(u.Hostname(), "*.")
+			valid = host != "" && !strings.Contains(host, "*")
+		}
+		v.check(valid, "cors.allowedOrigins", "has %q, which must be *, an origin such as https://example.com or one with a wildcard subdomain such as https://*.example.com", origin)
+	}
+	for _, method := range cors.AllowedMethods {
+		v.check(method != "" && strings.ToUpper(method) == method && !strings.ContainsAny(method, " ,"), "cors.allowedMethods",
+			"has %q, which must be an HTTP method in upper case such as GET", method)
+	}
+	v.notNegative("cors.maxAge", cors.MaxAge)
+
+	v.positive("idempotency.ttl", config.Idempotency.TTL)
+
+	rateLimit := &config.RateLimit
+	for route := range rateLimit.Routes {
+		method, path, _ := strings.Cut(route, " ")
+		v.check(method != "" && strings.ToUpper(method) == method && strings.HasPrefix(path, "/"), "rateLimit.routes",
+			"has %q, which must be a method and route such as GET /todo/:id", route)
+	}
+	v.oneOf("rateLimit.store", rateLimit.Store, "memory", "redis")
+	if rateLimit.Store == "redis" {
+		u, err := url.Parse(rateLimit.RedisURL)
+		v.check(err == nil && (u.Scheme == "redis" || u.Scheme == "rediss") && u.Host != "", "rateLimit.redisURL", "must be a Redis URL such as redis://localhost:6379/0 when rateLimit.store is redis")
+	}
+
+	v.check(config.Cache.Items >= 0, "cache.items", "must not be negative")
+	v.check(config.Cache.Lists >= 0, "cache.lists", "must not be negative")
+	v.positive("cache.ttl", config.Cache.TTL)
+	v.notNegative("cache.maxAge", config.Cache.MaxAge)
+
+	for _, encoding := range config.Compression.Encodings {
+		v.check(encoding == "zstd" || encoding == "br" || encoding == "gzip", "compression.encodings",
+			"has %q, which must be one of the following: zstd, br, gzip", encoding)
+	}
+	v.check(config.Compression.MinSize >= 0, "compression.minSize", "must not be negative")
+
+	return v.errs
+}
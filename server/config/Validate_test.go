@@ -0,0 +1,92 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(config *Config)
+		// errs are the beginnings of the expected errors, in order
+		errs []string
+	}{
+		{name: "valid", change: func(config *Config) {}},
+		{name: "database from parts", change: func(config *Config) {
+			config.Database = Default(Development).Database
+			config.Database.Prefix, config.Database.User, config.Database.Password, config.Database.Host = "mongodb", "todo", "secret", "localhost"
+		}},
+		{name: "no database", change: func(config *Config) { config.Database.URI = "" }, errs: []string{"database.uri (MONGODB_URI) must be set"}},
+		{name: "environment", change: func(config *Config) { config.Environment = "staging" }, errs: []string{"environment (APP_ENV)"}},
+		{name: "addresses", change: func(config *Config) { config.Server.Address, config.Server.GRPCAddress = "8080", "localhost" }, errs: []string{
+			"server.address (HTTP_ADDRESS)", "server.grpcAddress (GRPC_ADDRESS)",
+		}},
+		{name: "trusted proxies", change: func(config *Config) { config.Server.TrustedProxies = []string{"10.0.0.0/8", "::1", "proxy"} }, errs: []string{
+			`server.trustedProxies (TRUSTED_PROXIES) has "proxy"`,
+		}},
+		{name: "durations", change: func(config *Config) {
+			config.Server.ReadTimeout = Duration(-time.Second)
+			config.Server.ShutdownTimeout = 0
+			config.Database.OperationTimeouts = map[string]Duration{"RetrieveAll": Duration(time.Second)}
+		}, errs: []string{
+			"server.readTimeout (HTTP_READ_TIMEOUT) must not be negative", "server.shutdownTimeout (SHUTDOWN_TIMEOUT) must be a positive duration",
+			`database.operationTimeouts (DB_OPERATION_TIMEOUTS) has "RetrieveAll"`,
+		}},
+		{name: "any origin with credentials", change: func(config *Config) { config.CORS.AllowCredentials = true }, errs: []string{
+			"cors.allowedOrigins (CORS_ALLOWED_ORIGINS) must not allow any origin",
+		}},
+		{name: "origins", change: func(config *Config) {
+			config.CORS.AllowedOrigins = []string{"https://example.com", "https://*.example.com", "example.com", "https://example.com/app", "https://*.*.example.com", "ftp://example.com"}
+		}, errs: []string{
+			`cors.allowedOrigins (CORS_ALLOWED_ORIGINS) has "example.com"`, `cors.allowedOrigins (CORS_ALLOWED_ORIGINS) has "https://example.com/app"`,
+			`cors.allowedOrigins (CORS_ALLOWED_ORIGINS) has "https://*.*.example.com"`, `cors.allowedOrigins (CORS_ALLOWED_ORIGINS) has "ftp://example.com"`,
+		}},
+		{name: "methods", change: func(config *Config) { config.CORS.AllowedMethods = []string{"GET", "post", "PUT, DELETE"} }, errs: []string{
+			`cors.allowedMethods (CORS_ALLOWED_METHODS) has "post"`, `cors.allowedMethods (CORS_ALLOWED_METHODS) has "PUT, DELETE"`,
+		}},
+		{name: "rate limit routes", change: func(config *Config) { config.RateLimit.Routes = map[string]Limit{"/todo/": {}} }, errs: []string{
+			`rateLimit.routes (RATE_LIMIT_ROUTES) has "/todo/"`,
+		}},
+		{name: "redis", change: func(config *Config) { config.RateLimit.Store, config.RateLimit.RedisURL = "redis", "localhost:6379" }, errs: []string{
+			"rateLimit.redisURL (RATE_LIMIT_REDIS_URL) must be a Redis URL",
+		}},
+		{name: "cache and compression", change: func(config *Config) {
+			config.Cache.Items = -1
+			config.Compression.Encodings = []string{"gzip", "deflate"}
+		}, errs: []string{"cache.items (CACHE_ITEMS) must not be negative", `compression.encodings (COMPRESSION_ENCODINGS) has "deflate"`}},
+		// Every problem is reported, not just the first
+		{name: "several", change: func(config *Config) {
+			config.Log.Level = "loud"
+			config.Log.Format = "xml"
+			config.Tracing.Exporter = "jaeger"
+		}, errs: []string{"log.format (LOG_FORMAT)", "log.level (LOG_LEVEL)", "tracing.exporter (OTEL_TRACES_EXPORTER)"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := Default(Development)
+			config.Database.URI = "mongodb://localhost:27017"
+			test.change(config)
+
+			errs := config.validate()
+			if len(errs) != len(test.errs) {
+				t.Fatalf("validate = %v, want %d errors", errs, len(test.errs))
+			}
+			for i, err := range errs {
+				if !strings.HasPrefix(err, test.errs[i]) {
+					t.Errorf("error %d = %q, want it to begin with %q", i+1, err, test.errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateProductionDefaults(t *testing.T) {
+	config := Default(Production)
+	config.Database.URI = "mongodb://localhost:27017"
+	if errs := config.validate(); len(errs) != 0 {
+		t.Errorf("validate = %v, want no errors", errs)
+	}
+}
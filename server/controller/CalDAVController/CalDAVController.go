@@ -0,0 +1,650 @@
+package CalDAVController
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/caldav"
+	"github.com/L4TTiCe/ToDo-Go/server/controller"
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/SequenceDao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/ToDoItemDao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/TombstoneDao"
+	"github.com/L4TTiCe/ToDo-Go/server/ical"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// HomePath is both the principal and the calendar home of the (single) user.
+	HomePath = "/caldav/"
+
+	// CollectionPath is the calendar collection holding one VTODO resource per ToDoItem.
+	CollectionPath = "/caldav/todos/"
+
+	// syncTokenPrefix turns a position in the change sequence into a sync-token URI.
+	syncTokenPrefix = "http://todo-go/ns/sync/"
+
+	// maxObjectSize is the largest calendar object accepted by Put.
+	maxObjectSize = 1 << 20
+
+	calendarContentType = "text/calendar; charset=utf-8; component=vtodo"
+)
+
+// Property names served by this controller
+var (
+	resourceType                  = caldav.Name(caldav.NamespaceDAV, "resourcetype")
+	displayName                   = caldav.Name(caldav.NamespaceDAV, "displayname")
+	getETag                       = caldav.Name(caldav.NamespaceDAV, "getetag")
+	getContentType                = caldav.Name(caldav.NamespaceDAV, "getcontenttype")
+	getLastModified               = caldav.Name(caldav.NamespaceDAV, "getlastmodified")
+	currentUserPrincipal          = caldav.Name(caldav.NamespaceDAV, "current-user-principal")
+	principalURL                  = caldav.Name(caldav.NamespaceDAV, "principal-URL")
+	currentUserPrivilegeSet       = caldav.Name(caldav.NamespaceDAV, "current-user-privilege-set")
+	supportedReportSet            = caldav.Name(caldav.NamespaceDAV, "supported-report-set")
+	syncToken                     = caldav.Name(caldav.NamespaceDAV, "sync-token")
+	calendarHomeSet               = caldav.Name(caldav.NamespaceCalDAV, "calendar-home-set")
+	supportedCalendarComponentSet = caldav.Name(caldav.NamespaceCalDAV, "supported-calendar-component-set")
+	calendarData                  = caldav.Name(caldav.NamespaceCalDAV, "calendar-data")
+	getCTag                       = caldav.Name(caldav.NamespaceCalendarServer, "getctag")
+)
+
+// calendarStore holds the to-dos served as the calendar collection, and the tombstones of those deleted.
+type calendarStore interface {
+	// Stable returns the point of the change sequence up to which every write has finished.
+	Stable(ctx context.Context) (int64, error)
+	// RetrieveMatching retrieves the to-dos matching a calendar-query filter, in the order they were created.
+	RetrieveMatching(ctx context.Context, filter caldav.Filter) ([]models.ToDoItem, error)
+	RetrieveOne(ctx context.Context, id string) (*models.ToDoItem, error)
+	RetrieveByResourceName(ctx context.Context, name string) (*models.ToDoItem, error)
+	RetrieveByExternalID(ctx context.Context, externalID string) (*models.ToDoItem, error)
+	RetrieveChangedAfter(ctx context.Context, after int64, upTo int64) ([]models.ToDoItem, error)
+	RetrieveTombstonesAfter(ctx context.Context, after int64, upTo int64) ([]models.Tombstone, error)
+	Create(ctx context.Context, item *models.ToDoItem) (*mongo.InsertOneResult, error)
+	Update(ctx context.Context, id string, item *models.ToDoItem) error
+	Delete(ctx context.Context, id string) error
+}
+
+// daoStore is the calendarStore of the DB.
+type daoStore struct{}
+
+func (daoStore) Stable(ctx context.Context) (int64, error) {
+	return SequenceDao.Stable(ctx)
+}
+
+// RetrieveMatching queries the completion state and the time-range of the filter in the DB, which only holds to-dos.
+func (daoStore) RetrieveMatching(ctx context.Context, filter caldav.Filter) ([]models.ToDoItem, error) {
+	list, err := ToDoItemDao.ListAll("createdAt", 1)
+	if err != nil {
+		return nil, err
+	}
+	return list.Where(filter.Completed, "", "").DueWithin(filter.Start, filter.End).Retrieve(ctx)
+}
+
+func (daoStore) RetrieveOne(ctx context.Context, id string) (*models.ToDoItem, error) {
+	return ToDoItemDao.RetrieveOne(ctx, id)
+}
+
+func (daoStore) RetrieveByResourceName(ctx context.Context, name string) (*models.ToDoItem, error) {
+	return ToDoItemDao.RetrieveByResourceName(ctx, name)
+}
+
+func (daoStore) RetrieveByExternalID(ctx context.Context, externalID string) (*models.ToDoItem, error) {
+	return ToDoItemDao.RetrieveByExternalID(ctx, externalID)
+}
+
+func (daoStore) RetrieveChangedAfter(ctx context.Context, after int64, upTo int64) ([]models.ToDoItem, error) {
+	return ToDoItemDao.RetrieveChangedAfter(ctx, after, upTo, 0)
+}
+
+func (daoStore) RetrieveTombstonesAfter(ctx context.Context, after int64, upTo int64) ([]models.Tombstone, error) {
+	return TombstoneDao.RetrieveAfter(ctx, after, upTo, 0)
+}
+
+func (daoStore) Create(ctx context.Context, item *models.ToDoItem) (*mongo.InsertOneResult, error) {
+	return ToDoItemDao.CreateImported(ctx, item)
+}
+
+func (daoStore) Update(ctx context.Context, id string, item *models.ToDoItem) error {
+	_, err := ToDoItemDao.UpdateOne(ctx, id, item)
+	return err
+}
+
+func (daoStore) Delete(ctx context.Context, id string) error {
+	_, err := ToDoItemDao.DeleteOne(ctx, id)
+	return err
+}
+
+// todos is the store the calendar collection is served from.
+var todos calendarStore = daoStore{}
+
+// Options is a handler function that advertises the DAV capabilities of the server.
+func Options(c *gin.Context) {
+	c.Header("DAV", "1, 3, calendar-access")
+	c.Header("Allow", "OPTIONS, GET, PUT, DELETE, PROPFIND, REPORT")
+	c.Status(http.StatusOK)
+}
+
+// WellKnown is a handler function that redirects service discovery (RFC 6764) to the calendar home.
+func WellKnown(c *gin.Context) {
+	c.Redirect(http.StatusMovedPermanently, HomePath)
+}
+
+// PropfindHome is a handler function that describes the principal and calendar home and, with Depth: 1, the collection.
+func PropfindHome(c *gin.Context) {
+	depth, ok := propfindDepth(c)
+	if !ok {
+		return
+	}
+	request, ok := propRequest(c)
+	if !ok {
+		return
+	}
+
+	multistatus := &caldav.Multistatus{}
+	multistatus.Add(HomePath, homeProps(), request)
+
+	if depth == 1 {
+		props, err := collectionProps(c.Request.Context())
+		if err != nil {
+			abort(c, err)
+			return
+		}
+		multistatus.Add(CollectionPath, props, request)
+	}
+
+	multistatus.Write(c.Writer)
+}
+
+// PropfindCollection is a handler function that describes the collection and, with Depth: 1, every to-do in it.
+func PropfindCollection(c *gin.Context) {
+	depth, ok := propfindDepth(c)
+	if !ok {
+		return
+	}
+	request, ok := propRequest(c)
+	if !ok {
+		return
+	}
+
+	props, err := collectionProps(c.Request.Context())
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	multistatus := &caldav.Multistatus{}
+	multistatus.Add(CollectionPath, props, request)
+
+	if depth == 1 {
+		items, err := todos.RetrieveMatching(c.Request.Context(), caldav.Filter{Component: "VTODO"})
+		if err != nil {
+			abort(c, err)
+			return
+		}
+		for i := range items {
+			multistatus.Add(href(&items[i]), objectProps(&items[i], false), request)
+		}
+	}
+
+	multistatus.Write(c.Writer)
+}
+
+// PropfindObject is a handler function that describes a single to-do.
+func PropfindObject(c *gin.Context) {
+	request, ok := propRequest(c)
+	if !ok {
+		return
+	}
+
+	item, err := resolve(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	multistatus := &caldav.Multistatus{}
+	multistatus.Add(href(item), objectProps(item, false), request)
+	multistatus.Write(c.Writer)
+}
+
+// Report is a handler function for the calendar-query, calendar-multiget and sync-collection reports on the collection.
+func Report(c *gin.Context) {
+	root, err := caldav.ParseBody(c.Request.Body)
+	if err != nil || root == nil {
+		reject(c, problem.InvalidRequest.New("Request body must be a calendar-query, calendar-multiget or sync-collection report"))
+		return
+	}
+
+	request := caldav.ParsePropRequest(root)
+	request.AllProp = false
+
+	switch {
+	case root.Is(caldav.NamespaceCalDAV, "calendar-query"):
+		calendarQuery(c, root, request)
+	case root.Is(caldav.NamespaceCalDAV, "calendar-multiget"):
+		calendarMultiget(c, root, request)
+	case root.Is(caldav.NamespaceDAV, "sync-collection"):
+		syncCollection(c, root, request)
+	default:
+		caldav.WriteError(c.Writer, http.StatusForbidden, caldav.Name(caldav.NamespaceDAV, "supported-report"))
+	}
+}
+
+// calendarQuery returns every to-do matching the query's filter.
+func calendarQuery(c *gin.Context, root *caldav.Node, request caldav.PropRequest) {
+	filter := caldav.ParseFilter(root)
+
+	multistatus := &caldav.Multistatus{}
+	// The collection holds nothing but to-dos
+	if filter.Component != "VTODO" {
+		multistatus.Write(c.Writer)
+		return
+	}
+
+	items, err := todos.RetrieveMatching(c.Request.Context(), filter)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	for i := range items {
+		multistatus.Add(href(&items[i]), objectProps(&items[i], true), request)
+	}
+	multistatus.Write(c.Writer)
+}
+
+// calendarMultiget returns the to-dos with the given hrefs, and 404 for those that do not exist.
+func calendarMultiget(c *gin.Context, root *caldav.Node, request caldav.PropRequest) {
+	multistatus := &caldav.Multistatus{}
+
+	for _, node := range root.All(caldav.NamespaceDAV, "href") {
+		target := strings.TrimSpace(node.Text)
+
+		name, ok := nameFromHref(target)
+		if !ok {
+			multistatus.AddStatus(target, http.StatusNotFound)
+			continue
+		}
+
+		item, err := resolve(c.Request.Context(), name)
+		if err != nil {
+			if !errors.Is(err, dao.ErrNotFound) {
+				abort(c, err)
+				return
+			}
+			multistatus.AddStatus(target, http.StatusNotFound)
+			continue
+		}
+
+		multistatus.Add(href(item), objectProps(item, true), request)
+	}
+
+	multistatus.Write(c.Writer)
+}
+
+// syncCollection returns the to-dos changed and deleted since the given sync-token, or every to-do without one.
+func syncCollection(c *gin.Context, root *caldav.Node, request caldav.PropRequest) {
+	ctx := c.Request.Context()
+
+	var after int64
+	if node := root.Child(caldav.NamespaceDAV, "sync-token"); node != nil && strings.TrimSpace(node.Text) != "" {
+		token := strings.TrimSpace(node.Text)
+		var ok bool
+		after, ok = parseSyncToken(token)
+		if !ok {
+			caldav.WriteError(c.Writer, http.StatusForbidden, caldav.Name(caldav.NamespaceDAV, "valid-sync-token"))
+			return
+		}
+	}
+
+	// Changes up to the stable point of the sequence are reported, and later ones next time, so that a write that
+	// finishes after a later one is not skipped
+	stable, err := todos.Stable(ctx)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	// A token from the future comes from a different or restored DB, so the client has to start over
+	if after > stable {
+		caldav.WriteError(c.Writer, http.StatusForbidden, caldav.Name(caldav.NamespaceDAV, "valid-sync-token"))
+		return
+	}
+
+	multistatus := &caldav.Multistatus{SyncToken: formatSyncToken(stable)}
+
+	items, err := todos.RetrieveChangedAfter(ctx, after, stable)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	for i := range items {
+		multistatus.Add(href(&items[i]), objectProps(&items[i], true), request)
+	}
+
+	// Deletions only matter to a client that has seen the collection before
+	if after != 0 {
+		tombstones, err := todos.RetrieveTombstonesAfter(ctx, after, stable)
+		if err != nil {
+			abort(c, err)
+			return
+		}
+		for _, tombstone := range tombstones {
+			name := tombstone.ResourceName
+			if name == "" {
+				name = tombstone.ExternalID
+			}
+			if name == "" {
+				name = tombstone.ItemID.Hex()
+			}
+			multistatus.AddStatus(CollectionPath+url.PathEscape(name)+".ics", http.StatusNotFound)
+		}
+	}
+
+	multistatus.Write(c.Writer)
+}
+
+// Get is a handler function that returns a single to-do as a VCALENDAR.
+func Get(c *gin.Context) {
+	item, err := resolve(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.Header("Last-Modified", lastModified(item))
+	controller.CacheableData(c, http.StatusOK, calendarContentType, calendar(item))
+}
+
+// Put is a handler function that creates or replaces a to-do from a VCALENDAR holding a single VTODO.
+// It honours If-Match and If-None-Match, and returns the new ETag.
+func Put(c *gin.Context) {
+	name := strings.TrimSuffix(c.Param("name"), ".ics")
+
+	component, err := ical.Decode(http.MaxBytesReader(c.Writer, c.Request.Body, maxObjectSize))
+	if err != nil || component.Name != "VCALENDAR" {
+		reject(c, &models.ErrorResponse{
+			Status: http.StatusUnsupportedMediaType,
+			Title:  "Invalid Calendar Data",
+			Detail: "Request body must be an iCalendar object",
+		})
+		return
+	}
+
+	vtodos := component.Children("VTODO")
+	if len(vtodos) != 1 {
+		caldav.WriteError(c.Writer, http.StatusForbidden, caldav.Name(caldav.NamespaceCalDAV, "supported-calendar-component"))
+		return
+	}
+
+	parsed, err := ical.ToItem(vtodos[0], time.UTC)
+	if err != nil {
+		caldav.WriteError(c.Writer, http.StatusForbidden, caldav.Name(caldav.NamespaceCalDAV, "valid-calendar-data"))
+		return
+	}
+
+	existing, err := resolve(c.Request.Context(), name)
+	if err != nil && !errors.Is(err, dao.ErrNotFound) {
+		abort(c, err)
+		return
+	}
+
+	if !preconditions(c, existing) {
+		c.Status(http.StatusPreconditionFailed)
+		return
+	}
+
+	var id string
+	status := http.StatusNoContent
+
+	if existing != nil {
+		existing.Title = parsed.Title
+		existing.Completed = parsed.Completed
+		existing.CompletedAt = parsed.CompletedAt
+		existing.Deadline = parsed.Deadline
+		existing.Tags = parsed.Tags
+		existing.Priority = parsed.Priority
+		existing.Recurrence = parsed.Recurrence
+
+		if err = ToDoItemDao.Validate(existing); err == nil {
+			err = todos.Update(c.Request.Context(), existing.ID.Hex(), existing)
+		}
+		id = existing.ID.Hex()
+	} else {
+		// New resources are found again by the name the client put them under, which need not be their UID
+		parsed.ResourceName = name
+
+		var result *mongo.InsertOneResult
+		result, err = todos.Create(c.Request.Context(), &parsed)
+		if err == nil {
+			id = result.InsertedID.(primitive.ObjectID).Hex()
+		}
+		status = http.StatusCreated
+	}
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	stored, err := todos.RetrieveOne(c.Request.Context(), id)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.Header("ETag", controller.ETag(calendar(stored)))
+	c.Status(status)
+}
+
+// Delete is a handler function that deletes a to-do, honouring If-Match.
+func Delete(c *gin.Context) {
+	item, err := resolve(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	if !preconditions(c, item) {
+		c.Status(http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := todos.Delete(c.Request.Context(), item.ID.Hex()); err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// propfindDepth reads the Depth header of a PROPFIND: 0, or 1 if it is missing. Depth: infinity is refused, as
+// RFC 4918 allows. It writes an error response and returns false if the depth is refused or invalid.
+func propfindDepth(c *gin.Context) (int, bool) {
+	switch c.GetHeader("Depth") {
+	case "0":
+		return 0, true
+	case "1", "":
+		return 1, true
+	case "infinity":
+		caldav.WriteError(c.Writer, http.StatusForbidden, caldav.Name(caldav.NamespaceDAV, "propfind-finite-depth"))
+		return 0, false
+	}
+	reject(c, problem.InvalidRequest.New("Depth must be one of the following: 0, 1"))
+	return 0, false
+}
+
+// propRequest reads the body of a PROPFIND. It writes an error response and returns false if the body is invalid.
+func propRequest(c *gin.Context) (caldav.PropRequest, bool) {
+	root, err := caldav.ParseBody(c.Request.Body)
+	if err != nil || (root != nil && !root.Is(caldav.NamespaceDAV, "propfind")) {
+		reject(c, problem.InvalidRequest.New("Request body must be empty or a DAV:propfind element"))
+		return caldav.PropRequest{}, false
+	}
+	return caldav.ParsePropRequest(root), true
+}
+
+// preconditions evaluates If-Match and If-None-Match against the current version of a resource, which may be nil.
+func preconditions(c *gin.Context, item *models.ToDoItem) bool {
+	current := ""
+	if item != nil {
+		current = controller.ETag(calendar(item))
+	}
+
+	if match := c.GetHeader("If-Match"); match != "" {
+		if item == nil || (match != "*" && match != current) {
+			return false
+		}
+	}
+
+	if noneMatch := c.GetHeader("If-None-Match"); noneMatch != "" {
+		if item != nil && (noneMatch == "*" || noneMatch == current) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resolve finds the ToDoItem behind a resource name: an ObjectID for items created through the API, the
+// ResourceName for items created through CalDAV, or the ExternalID (usually the UID) for items imported.
+func resolve(ctx context.Context, name string) (*models.ToDoItem, error) {
+	name = strings.TrimSuffix(name, ".ics")
+
+	if _, err := primitive.ObjectIDFromHex(name); err == nil {
+		item, err := todos.RetrieveOne(ctx, name)
+		if err == nil || !errors.Is(err, dao.ErrNotFound) {
+			return item, err
+		}
+	}
+
+	item, err := todos.RetrieveByResourceName(ctx, name)
+	if err == nil || !errors.Is(err, dao.ErrNotFound) {
+		return item, err
+	}
+
+	return todos.RetrieveByExternalID(ctx, name)
+}
+
+// href returns the path of the resource for a ToDoItem.
+func href(item *models.ToDoItem) string {
+	name := item.ResourceName
+	if name == "" {
+		name = item.ExternalID
+	}
+	if name == "" {
+		name = item.ID.Hex()
+	}
+	return CollectionPath + url.PathEscape(name) + ".ics"
+}
+
+// nameFromHref extracts the resource name from an href within the collection, which may be a full URL.
+func nameFromHref(target string) (string, bool) {
+	parsed, err := url.Parse(target)
+	if err != nil || !strings.HasPrefix(parsed.Path, CollectionPath) {
+		return "", false
+	}
+	name := strings.TrimPrefix(parsed.Path, CollectionPath)
+	return name, name != "" && !strings.Contains(name, "/")
+}
+
+// calendar returns the iCalendar representation of a ToDoItem.
+func calendar(item *models.ToDoItem) []byte {
+	var b bytes.Buffer
+
+	cal := ical.NewCalendar()
+	cal.Components = append(cal.Components, ical.FromItem(item, ical.UID(item)))
+	ical.NewEncoder(&b).Component(cal)
+
+	return b.Bytes()
+}
+
+// lastModified returns the HTTP date at which a ToDoItem was last written.
+func lastModified(item *models.ToDoItem) string {
+	updatedAt := item.UpdatedAt
+	if updatedAt == 0 {
+		updatedAt = item.CreatedAt
+	}
+	return time.UnixMilli(updatedAt).UTC().Format(http.TimeFormat)
+}
+
+// homeProps returns the properties of the principal and calendar home.
+func homeProps() caldav.Props {
+	return caldav.Props{
+		resourceType:         "<d:collection/><d:principal/>",
+		displayName:          caldav.Text("ToDo-Go"),
+		currentUserPrincipal: caldav.Href(HomePath),
+		principalURL:         caldav.Href(HomePath),
+		calendarHomeSet:      caldav.Href(HomePath),
+	}
+}
+
+// collectionProps returns the properties of the calendar collection.
+func collectionProps(ctx context.Context) (caldav.Props, error) {
+	stable, err := todos.Stable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return caldav.Props{
+		resourceType:                  "<d:collection/><c:calendar/>",
+		displayName:                   caldav.Text("ToDo"),
+		currentUserPrincipal:          caldav.Href(HomePath),
+		currentUserPrivilegeSet:       "<d:privilege><d:all/></d:privilege><d:privilege><d:read/></d:privilege><d:privilege><d:write/></d:privilege>",
+		supportedCalendarComponentSet: `<c:comp name="VTODO"/>`,
+		supportedReportSet: "<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>" +
+			"<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>" +
+			"<d:supported-report><d:report><d:sync-collection/></d:report></d:supported-report>",
+		syncToken: caldav.Text(formatSyncToken(stable)),
+		getCTag:   caldav.Text(strconv.FormatInt(stable, 10)),
+	}, nil
+}
+
+// objectProps returns the properties of a to-do resource. The calendar data is only included in reports.
+func objectProps(item *models.ToDoItem, withData bool) caldav.Props {
+	data := calendar(item)
+
+	props := caldav.Props{
+		resourceType:    "",
+		getETag:         caldav.Text(controller.ETag(data)),
+		getContentType:  caldav.Text(calendarContentType),
+		getLastModified: caldav.Text(lastModified(item)),
+	}
+	if withData {
+		props[calendarData] = caldav.Text(string(data))
+	}
+	return props
+}
+
+func formatSyncToken(latest int64) string {
+	return syncTokenPrefix + strconv.FormatInt(latest, 10)
+}
+
+func parseSyncToken(token string) (int64, bool) {
+	if !strings.HasPrefix(token, syncTokenPrefix) {
+		return 0, false
+	}
+	after, err := strconv.ParseInt(strings.TrimPrefix(token, syncTokenPrefix), 10, 64)
+	return after, err == nil && after >= 0
+}
+
+// abort sends the ErrorResponse for an error returned by a DAO.
+func abort(c *gin.Context, err error) {
+	reject(c, problem.From(err))
+}
+
+// reject sends an ErrorResponse.
+func reject(c *gin.Context, errorResponse *models.ErrorResponse) {
+	// Populate error response before sending to client
+	controller.PopulateErrorResponse(c, errorResponse)
+
+	c.JSON(errorResponse.Status, errorResponse)
+}
@@ -0,0 +1,376 @@
+package CalDAVController
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/caldav"
+	"github.com/L4TTiCe/ToDo-Go/server/controller"
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryStore is a calendarStore in memory. It returns every item for any filter, and records the last filter.
+type memoryStore struct {
+	items      []models.ToDoItem
+	tombstones []models.Tombstone
+	sequence   int64
+
+	filter *caldav.Filter
+}
+
+func (s *memoryStore) Stable(ctx context.Context) (int64, error) {
+	return s.sequence, nil
+}
+
+func (s *memoryStore) RetrieveMatching(ctx context.Context, filter caldav.Filter) ([]models.ToDoItem, error) {
+	s.filter = &filter
+	return append([]models.ToDoItem(nil), s.items...), nil
+}
+
+func (s *memoryStore) find(match func(item *models.ToDoItem) bool) (*models.ToDoItem, error) {
+	for i := range s.items {
+		if match(&s.items[i]) {
+			item := s.items[i]
+			return &item, nil
+		}
+	}
+	return nil, dao.NotFound("Item not found")
+}
+
+func (s *memoryStore) RetrieveOne(ctx context.Context, id string) (*models.ToDoItem, error) {
+	return s.find(func(item *models.ToDoItem) bool { return item.ID.Hex() == id })
+}
+
+func (s *memoryStore) RetrieveByResourceName(ctx context.Context, name string) (*models.ToDoItem, error) {
+	return s.find(func(item *models.ToDoItem) bool { return item.ResourceName == name })
+}
+
+func (s *memoryStore) RetrieveByExternalID(ctx context.Context, externalID string) (*models.ToDoItem, error) {
+	return s.find(func(item *models.ToDoItem) bool { return item.ExternalID == externalID })
+}
+
+func (s *memoryStore) RetrieveChangedAfter(ctx context.Context, after int64, upTo int64) ([]models.ToDoItem, error) {
+	var items []models.ToDoItem
+	for _, item := range s.items {
+		if item.Sequence > after && item.Sequence <= upTo {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (s *memoryStore) RetrieveTombstonesAfter(ctx context.Context, after int64, upTo int64) ([]models.Tombstone, error) {
+	var tombstones []models.Tombstone
+	for _, tombstone := range s.tombstones {
+		if tombstone.Sequence > after && tombstone.Sequence <= upTo {
+			tombstones = append(tombstones, tombstone)
+		}
+	}
+	return tombstones, nil
+}
+
+func (s *memoryStore) Create(ctx context.Context, item *models.ToDoItem) (*mongo.InsertOneResult, error) {
+	item.ID = primitive.NewObjectID()
+	s.sequence++
+	item.Sequence = s.sequence
+	s.items = append(s.items, *item)
+	return &mongo.InsertOneResult{InsertedID: item.ID}, nil
+}
+
+func (s *memoryStore) Update(ctx context.Context, id string, item *models.ToDoItem) error {
+	for i := range s.items {
+		if s.items[i].ID.Hex() == id {
+			s.sequence++
+			item.Sequence = s.sequence
+			s.items[i] = *item
+			return nil
+		}
+	}
+	return dao.NotFound("Item with ID " + id + " not found")
+}
+
+func (s *memoryStore) Delete(ctx context.Context, id string) error {
+	for i, item := range s.items {
+		if item.ID.Hex() == id {
+			s.items = append(s.items[:i], s.items[i+1:]...)
+			s.sequence++
+			s.tombstones = append(s.tombstones, models.Tombstone{ItemID: item.ID, ExternalID: item.ExternalID, ResourceName: item.ResourceName, Sequence: s.sequence})
+			return nil
+		}
+	}
+	return dao.NotFound("Item with ID " + id + " not found")
+}
+
+var rentID = primitive.NewObjectID()
+
+// newMemoryStore returns a store with an item created through the API, one imported, and one created and one deleted
+// through CalDAV, in that order of the change sequence.
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		items: []models.ToDoItem{
+			{ID: rentID, Title: "Pay rent", Sequence: 1},
+			{ID: primitive.NewObjectID(), ExternalID: "milk", Title: "Buy milk", Completed: true, Sequence: 3},
+			{ID: primitive.NewObjectID(), ExternalID: "uid-1", ResourceName: "call-mum", Title: "Call mum", Sequence: 5},
+		},
+		tombstones: []models.Tombstone{{ItemID: primitive.NewObjectID(), ResourceName: "old", Sequence: 4}},
+		sequence:   5,
+	}
+}
+
+var (
+	rentHref = CollectionPath + rentID.Hex() + ".ics"
+	milkHref = CollectionPath + "milk.ics"
+	mumHref  = CollectionPath + "call-mum.ics"
+	oldHref  = CollectionPath + "old.ics"
+)
+
+// serve sends a request to the CalDAV routes, backed by store.
+func serve(store *memoryStore, method string, path string, headers map[string]string, body string) *httptest.ResponseRecorder {
+	defer func(store calendarStore) { todos = store }(todos)
+	todos = store
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Handle("PROPFIND", HomePath, PropfindHome)
+	router.Handle("PROPFIND", CollectionPath, PropfindCollection)
+	router.Handle("REPORT", CollectionPath, Report)
+	router.Handle("PROPFIND", CollectionPath+":name", PropfindObject)
+	router.GET(CollectionPath+":name", Get)
+	router.PUT(CollectionPath+":name", Put)
+	router.DELETE(CollectionPath+":name", Delete)
+
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	return w
+}
+
+// hasHref reports whether a multistatus response holds a response for href.
+func hasHref(body string, href string) bool {
+	return strings.Contains(body, "<d:href>"+href+"</d:href>")
+}
+
+func TestPropfind(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		depth    string
+		status   int
+		hrefs    []string
+		excluded []string
+		// body is a part of the response body, for errors
+		body string
+	}{
+		{name: "home", path: HomePath, depth: "0", status: http.StatusMultiStatus, hrefs: []string{HomePath}, excluded: []string{CollectionPath}},
+		{name: "home and collection", path: HomePath, depth: "1", status: http.StatusMultiStatus, hrefs: []string{HomePath, CollectionPath}, excluded: []string{rentHref}},
+		{name: "collection", path: CollectionPath, depth: "0", status: http.StatusMultiStatus, hrefs: []string{CollectionPath}, excluded: []string{rentHref}},
+		{name: "collection and to-dos", path: CollectionPath, depth: "1", status: http.StatusMultiStatus, hrefs: []string{CollectionPath, rentHref, milkHref, mumHref}},
+		// A missing Depth would mean infinity, which clients leaving it out do not expect
+		{name: "collection without depth", path: CollectionPath, status: http.StatusMultiStatus, hrefs: []string{CollectionPath, rentHref}},
+		{name: "home with infinite depth", path: HomePath, depth: "infinity", status: http.StatusForbidden, body: "<d:propfind-finite-depth/>"},
+		{name: "collection with infinite depth", path: CollectionPath, depth: "infinity", status: http.StatusForbidden, body: "<d:propfind-finite-depth/>"},
+		{name: "invalid depth", path: CollectionPath, depth: "2", status: http.StatusBadRequest},
+		{name: "to-do", path: mumHref, depth: "0", status: http.StatusMultiStatus, hrefs: []string{mumHref}},
+		{name: "to-do by UID", path: CollectionPath + "uid-1.ics", depth: "0", status: http.StatusMultiStatus, hrefs: []string{mumHref}},
+		{name: "unknown to-do", path: CollectionPath + "unknown.ics", depth: "0", status: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if test.depth != "" {
+				headers["Depth"] = test.depth
+			}
+			w := serve(newMemoryStore(), "PROPFIND", test.path, headers, "")
+
+			if w.Code != test.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, test.status, w.Body)
+			}
+			for _, href := range test.hrefs {
+				if !hasHref(w.Body.String(), href) {
+					t.Errorf("response has no %s: %s", href, w.Body)
+				}
+			}
+			for _, href := range test.excluded {
+				if hasHref(w.Body.String(), href) {
+					t.Errorf("response has %s: %s", href, w.Body)
+				}
+			}
+			if !strings.Contains(w.Body.String(), test.body) {
+				t.Errorf("body = %s, want %s in it", w.Body, test.body)
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	const (
+		query = `<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/></d:prop>` +
+			`<c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VTODO">` +
+			`<c:time-range start="20220601T000000Z" end="20220701T000000Z"/>` +
+			`<c:prop-filter name="COMPLETED"><c:is-not-defined/></c:prop-filter>` +
+			`</c:comp-filter></c:comp-filter></c:filter></c:calendar-query>`
+		eventQuery = `<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/></d:prop>` +
+			`<c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VEVENT"/></c:comp-filter></c:filter></c:calendar-query>`
+		multiget = `<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/></d:prop>` +
+			`<d:href>/caldav/todos/milk.ics</d:href><d:href>/caldav/todos/unknown.ics</d:href><d:href>/elsewhere/milk.ics</d:href>` +
+			`</c:calendar-multiget>`
+	)
+	syncCollection := func(token string) string {
+		return `<d:sync-collection xmlns:d="DAV:"><d:sync-token>` + token + `</d:sync-token><d:prop><d:getetag/></d:prop></d:sync-collection>`
+	}
+
+	open := false
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		hrefs    []string
+		excluded []string
+		// contains is a part of the response body
+		contains string
+		// filter is the filter the store was queried with, if it was
+		filter *caldav.Filter
+	}{
+		{
+			name: "calendar-query", body: query, status: http.StatusMultiStatus, hrefs: []string{rentHref, milkHref, mumHref},
+			filter: &caldav.Filter{
+				Component: "VTODO", Completed: &open,
+				Start: time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC).UnixMilli(), End: time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC).UnixMilli(),
+			},
+		},
+		{name: "calendar-query for events", body: eventQuery, status: http.StatusMultiStatus, excluded: []string{rentHref}},
+		{
+			name: "calendar-multiget", body: multiget, status: http.StatusMultiStatus, hrefs: []string{milkHref, CollectionPath + "unknown.ics", "/elsewhere/milk.ics"},
+			contains: "HTTP/1.1 404 Not Found",
+		},
+		// The first sync receives every to-do, and no deletes
+		{name: "initial sync", body: syncCollection(""), status: http.StatusMultiStatus, hrefs: []string{rentHref, milkHref, mumHref}, excluded: []string{oldHref}, contains: "<d:sync-token>" + syncTokenPrefix + "5</d:sync-token>"},
+		{name: "sync", body: syncCollection(syncTokenPrefix + "2"), status: http.StatusMultiStatus, hrefs: []string{milkHref, mumHref, oldHref}, excluded: []string{rentHref}},
+		{name: "sync up to date", body: syncCollection(syncTokenPrefix + "5"), status: http.StatusMultiStatus, excluded: []string{rentHref, milkHref, mumHref, oldHref}},
+		{name: "sync with an invalid token", body: syncCollection("token"), status: http.StatusForbidden, contains: "<d:valid-sync-token/>"},
+		{name: "sync with a token from the future", body: syncCollection(syncTokenPrefix + "9"), status: http.StatusForbidden, contains: "<d:valid-sync-token/>"},
+		{name: "unknown report", body: `<d:expand-property xmlns:d="DAV:"/>`, status: http.StatusForbidden, contains: "<d:supported-report/>"},
+		{name: "no report", status: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := newMemoryStore()
+			w := serve(store, "REPORT", CollectionPath, nil, test.body)
+
+			if w.Code != test.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, test.status, w.Body)
+			}
+			for _, href := range test.hrefs {
+				if !hasHref(w.Body.String(), href) {
+					t.Errorf("response has no %s: %s", href, w.Body)
+				}
+			}
+			for _, href := range test.excluded {
+				if hasHref(w.Body.String(), href) {
+					t.Errorf("response has %s: %s", href, w.Body)
+				}
+			}
+			if !strings.Contains(w.Body.String(), test.contains) {
+				t.Errorf("body = %s, want %s in it", w.Body, test.contains)
+			}
+			if !reflect.DeepEqual(store.filter, test.filter) {
+				t.Errorf("queried with %+v, want %+v", store.filter, test.filter)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	const (
+		plants = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:uid-2\r\nSUMMARY:Water plants\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+		rent   = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:uid-3\r\nSUMMARY:Pay the rent\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+		two    = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nSUMMARY:A\r\nEND:VTODO\r\nBEGIN:VTODO\r\nSUMMARY:B\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	)
+	rentTag := controller.ETag(calendar(&newMemoryStore().items[0]))
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		body    string
+		status  int
+		// title is the title of the item at the path afterwards, or "" if there is none
+		title string
+	}{
+		{name: "create", method: http.MethodPut, path: CollectionPath + "plants.ics", headers: map[string]string{"If-None-Match": "*"}, body: plants, status: http.StatusCreated, title: "Water plants"},
+		{name: "replace", method: http.MethodPut, path: rentHref, body: rent, status: http.StatusNoContent, title: "Pay the rent"},
+		{name: "replace the current version", method: http.MethodPut, path: rentHref, headers: map[string]string{"If-Match": rentTag}, body: rent, status: http.StatusNoContent, title: "Pay the rent"},
+		{name: "replace an old version", method: http.MethodPut, path: rentHref, headers: map[string]string{"If-Match": `"old"`}, body: rent, status: http.StatusPreconditionFailed, title: "Pay rent"},
+		{name: "create over an existing to-do", method: http.MethodPut, path: rentHref, headers: map[string]string{"If-None-Match": "*"}, body: rent, status: http.StatusPreconditionFailed, title: "Pay rent"},
+		{name: "replace a missing to-do", method: http.MethodPut, path: CollectionPath + "plants.ics", headers: map[string]string{"If-Match": "*"}, body: plants, status: http.StatusPreconditionFailed},
+		{name: "not a calendar", method: http.MethodPut, path: rentHref, body: "Pay rent", status: http.StatusUnsupportedMediaType, title: "Pay rent"},
+		{name: "two to-dos", method: http.MethodPut, path: rentHref, body: two, status: http.StatusForbidden, title: "Pay rent"},
+		{name: "delete", method: http.MethodDelete, path: rentHref, status: http.StatusNoContent},
+		{name: "delete the current version", method: http.MethodDelete, path: rentHref, headers: map[string]string{"If-Match": rentTag}, status: http.StatusNoContent},
+		{name: "delete an old version", method: http.MethodDelete, path: rentHref, headers: map[string]string{"If-Match": `"old"`}, status: http.StatusPreconditionFailed, title: "Pay rent"},
+		{name: "delete a missing to-do", method: http.MethodDelete, path: CollectionPath + "plants.ics", status: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := newMemoryStore()
+			w := serve(store, test.method, test.path, test.headers, test.body)
+
+			if w.Code != test.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, test.status, w.Body)
+			}
+
+			// The to-do is read back through GET, whose ETag is the one a successful write returned
+			get := serve(store, http.MethodGet, test.path, nil, "")
+			title := ""
+			if get.Code == http.StatusOK {
+				title = strings.TrimSpace(strings.SplitN(strings.SplitN(get.Body.String(), "SUMMARY:", 2)[1], "\n", 2)[0])
+			}
+			if title != test.title {
+				t.Errorf("title = %q, want %q", title, test.title)
+			}
+			if tag := w.Header().Get("ETag"); w.Code < 300 && test.method == http.MethodPut && tag != get.Header().Get("ETag") {
+				t.Errorf("ETag = %s, want %s", tag, get.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	store := newMemoryStore()
+	w := serve(store, http.MethodGet, rentHref, nil, "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == "" || w.Header().Get("Content-Type") != calendarContentType {
+		t.Fatalf("GET = %d, %v", w.Code, w.Header())
+	}
+
+	revalidated := serve(store, http.MethodGet, rentHref, map[string]string{"If-None-Match": w.Header().Get("ETag")}, "")
+	if revalidated.Code != http.StatusNotModified || revalidated.Body.Len() != 0 {
+		t.Errorf("revalidating GET = %d, %q, want 304 without a body", revalidated.Code, revalidated.Body)
+	}
+}
+
+// TestDeleteTombstone checks that a to-do deleted through CalDAV is reported as deleted to the next sync.
+func TestDeleteTombstone(t *testing.T) {
+	store := newMemoryStore()
+	serve(store, http.MethodDelete, mumHref, nil, "")
+
+	w := serve(store, "REPORT", CollectionPath, nil, `<d:sync-collection xmlns:d="DAV:"><d:sync-token>`+syncTokenPrefix+`5</d:sync-token></d:sync-collection>`)
+	if !hasHref(w.Body.String(), mumHref) || !strings.Contains(w.Body.String(), "HTTP/1.1 404 Not Found") {
+		t.Errorf("sync after delete = %s, want %s as deleted", w.Body, mumHref)
+	}
+}
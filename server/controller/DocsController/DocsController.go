@@ -0,0 +1,67 @@
+package DocsController
+
+import (
+	"net/http"
+
+	"github.com/L4TTiCe/ToDo-Go/server/controller"
+	"github.com/L4TTiCe/ToDo-Go/server/openapi"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+)
+
+// OpenAPI is a handler function that returns the OpenAPI document describing the ToDo API.
+func OpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, openapi.Spec())
+}
+
+// Problem is a handler function that describes the kind of problem with the code in the path. It is what the type
+// URI of an ErrorResponse resolves to.
+func Problem(c *gin.Context) {
+	p, ok := problem.Lookup(c.Param("code"))
+	if !ok {
+		errorResponse := problem.NotFound.New("There is no problem type " + c.Param("code"))
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	c.JSON(http.StatusOK, &p)
+}
+
+// SwaggerUI is a handler function that serves Swagger UI, bundled into the binary, showing the OpenAPI document.
+func SwaggerUI(c *gin.Context) {
+	file := c.Param("file")
+	if file == "/" || file == "/index.html" {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUI))
+		return
+	}
+
+	c.FileFromFS(file, swaggerFiles.HTTP)
+}
+
+// swaggerUI is the Swagger UI page, pointed at /openapi.json instead of the demo document of the bundled initializer.
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>ToDo-Go API</title>
+  <link rel="stylesheet" href="swagger-ui.css">
+  <link rel="icon" type="image/png" href="favicon-32x32.png" sizes="32x32">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="swagger-ui-bundle.js"></script>
+  <script src="swagger-ui-standalone-preset.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({
+      url: "/openapi.json",
+      dom_id: "#swagger-ui",
+      presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+      layout: "StandaloneLayout"
+    });
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,148 @@
+package FeedTokenController
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/L4TTiCe/ToDo-Go/server/controller"
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/FeedTokenDao"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// adminToken is the Bearer token that creates feed tokens for any user, or "" if there is none.
+var adminToken string
+
+// SetAdminToken sets the Bearer token that creates feed tokens for any user. An empty token disables it.
+func SetAdminToken(token string) {
+	adminToken = token
+}
+
+// Create is a handler function that creates a new feed token for a user.
+// It takes a JSON body with a user field and returns the FeedToken, including the secret which is only shown once.
+// It requires a Bearer token: the admin token creates tokens for any user, and a feed token creates another token
+// for its own user.
+func Create(c *gin.Context) {
+	var feedToken models.FeedToken
+
+	// Bind JSON to struct
+	err := c.ShouldBindJSON(&feedToken)
+	if err != nil {
+		errorResponse := problem.InvalidRequest.New("Error parsing JSON: " + err.Error())
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	if !isAdmin(c) {
+		caller, errorResponse := authenticate(c)
+		if errorResponse == nil && feedToken.User != caller.User {
+			errorResponse = problem.Unauthorized.New("The Bearer token belongs to a different user")
+		}
+		if errorResponse != nil {
+			controller.PopulateErrorResponse(c, errorResponse)
+
+			c.JSON(errorResponse.Status, errorResponse)
+			return
+		}
+	}
+
+	result, err := FeedTokenDao.Create(c.Request.Context(), feedToken.User)
+	if err != nil {
+		errorResponse := problem.From(err)
+
+		// Populate error response before sending to client
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	c.JSON(http.StatusCreated, &result)
+}
+
+// RetrieveAll is a handler function that lists the feed tokens of the user whose token is sent as a Bearer token.
+func RetrieveAll(c *gin.Context) {
+	caller, errorResponse := authenticate(c)
+	if errorResponse != nil {
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	result, err := FeedTokenDao.RetrieveAll(c.Request.Context(), caller.User)
+	if err != nil {
+		errorResponse := problem.From(err)
+
+		// Populate error response before sending to client
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Revoke is a handler function that revokes a feed token of the user whose token is sent as a Bearer token.
+// Tokens of other users are not found.
+func Revoke(c *gin.Context) {
+	caller, errorResponse := authenticate(c)
+	if errorResponse != nil {
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	result, err := FeedTokenDao.Revoke(c.Request.Context(), c.Param("id"), caller.User)
+	if err != nil {
+		errorResponse := problem.From(err)
+
+		// Populate error response before sending to client
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	c.JSON(http.StatusOK, &result)
+}
+
+// isAdmin reports whether the admin token is sent as the Bearer token.
+func isAdmin(c *gin.Context) bool {
+	token := bearerToken(c)
+	return adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1
+}
+
+// authenticate returns the unrevoked feed token sent in the Authorization header as a Bearer token, which identifies
+// the user that makes the request, or an ErrorResponse if there is none.
+func authenticate(c *gin.Context) (*models.FeedToken, *models.ErrorResponse) {
+	feedToken, err := FeedTokenDao.Authenticate(c.Request.Context(), bearerToken(c))
+	if errors.Is(err, dao.ErrNotFound) {
+		c.Header("WWW-Authenticate", "Bearer")
+		return nil, problem.Unauthorized.New("Send a feed token of the user as a Bearer token in the Authorization header")
+	}
+	if err != nil {
+		return nil, problem.From(err)
+	}
+
+	logging.SetUser(c.Request.Context(), feedToken.User)
+	return feedToken, nil
+}
+
+// bearerToken returns the Bearer token of the Authorization header, or "" if there is none.
+func bearerToken(c *gin.Context) string {
+	authorization := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return ""
+	}
+	return strings.TrimPrefix(authorization, "Bearer ")
+}
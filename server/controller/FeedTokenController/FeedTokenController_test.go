@@ -0,0 +1,79 @@
+package FeedTokenController
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestCreateRequiresToken checks that feed tokens cannot be created without a Bearer token, whether or not the
+// server has an admin token. Requests with a token are checked against the DB, which these tests do not have.
+func TestCreateRequiresToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		admin         string
+		authorization string
+	}{
+		{name: "no token"},
+		{name: "no token, with an admin token", admin: "secret"},
+		{name: "not a Bearer token", admin: "secret", authorization: "Basic secret"},
+		{name: "empty Bearer token", admin: "secret", authorization: "Bearer "},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/todo/feed/tokens", Create)
+	defer SetAdminToken("")
+
+	for _, test := range tests {
+		SetAdminToken(test.admin)
+
+		request := httptest.NewRequest(http.MethodPost, "/todo/feed/tokens", strings.NewReader(`{"user": "alice"}`))
+		if test.authorization != "" {
+			request.Header.Set("Authorization", test.authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request)
+
+		if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("%s: status = %d, WWW-Authenticate = %q, want %d, Bearer", test.name, w.Code, w.Header().Get("WWW-Authenticate"), http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	tests := []struct {
+		admin         string
+		authorization string
+		want          bool
+	}{
+		{admin: "secret", authorization: "Bearer secret", want: true},
+		{admin: "secret", authorization: "Bearer other"},
+		{admin: "secret", authorization: "Bearer secret2"},
+		{admin: "secret", authorization: "secret"},
+		{admin: "secret"},
+		// Without an admin token, nobody is the admin
+		{admin: "", authorization: "Bearer "},
+		{admin: ""},
+	}
+
+	gin.SetMode(gin.TestMode)
+	defer SetAdminToken("")
+
+	for _, test := range tests {
+		SetAdminToken(test.admin)
+
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/todo/feed/tokens", nil)
+		if test.authorization != "" {
+			c.Request.Header.Set("Authorization", test.authorization)
+		}
+
+		if got := isAdmin(c); got != test.want {
+			t.Errorf("isAdmin with admin token %q and Authorization %q = %t, want %t", test.admin, test.authorization, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package GraphQLController
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/L4TTiCe/ToDo-Go/server/controller"
+	"github.com/L4TTiCe/ToDo-Go/server/gql"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+// Query is a handler function that executes a GraphQL request.
+// POST takes a JSON body with query, variables and operationName; GET takes the same as query parameters, with
+// variables as a JSON string, and cannot run mutations. Subscriptions are streamed as Server-Sent Events, each result
+// as a "next" event, until the client disconnects.
+// In debug mode, a GET from a browser without a query is answered with the GraphiQL explorer.
+func Query(c *gin.Context) {
+	request, errorResponse := bind(c)
+	if errorResponse != nil {
+		// Populate error response before sending to client
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	if request == nil {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(graphiQL))
+		return
+	}
+
+	if err := gql.CheckLimits(request.Query, request.OperationName); err != nil {
+		c.JSON(http.StatusBadRequest, &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(err.Error())}})
+		return
+	}
+
+	params := graphql.Params{
+		Schema:         gql.Schema,
+		RequestString:  request.Query,
+		VariableValues: request.Variables,
+		OperationName:  request.OperationName,
+		Context:        c.Request.Context(),
+	}
+
+	switch gql.Operation(request.Query, request.OperationName) {
+	case "mutation":
+		if c.Request.Method == http.MethodGet {
+			c.Header("Allow", http.MethodPost)
+			errorResponse := problem.MethodNotAllowed.New("Mutations must be sent with POST")
+			controller.PopulateErrorResponse(c, errorResponse)
+
+			c.JSON(errorResponse.Status, errorResponse)
+			return
+		}
+	case "subscription":
+		stream(c, graphql.Subscribe(params))
+		return
+	}
+
+	c.JSON(http.StatusOK, graphql.Do(params))
+}
+
+// bind reads a GraphQL request from the body of a POST or the query string of a GET. It returns nil, without an
+// error, if GraphiQL should be served instead.
+func bind(c *gin.Context) (*gql.Request, *models.ErrorResponse) {
+	request := &gql.Request{}
+
+	if c.Request.Method == http.MethodPost {
+		if err := json.NewDecoder(c.Request.Body).Decode(request); err != nil {
+			return nil, problem.InvalidRequest.New("Error parsing JSON: " + err.Error())
+		}
+	} else {
+		request.Query = c.Query("query")
+		request.OperationName = c.Query("operationName")
+
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				return nil, problem.InvalidRequest.New("Error parsing variables: " + err.Error())
+			}
+		}
+
+		if request.Query == "" && gin.Mode() == gin.DebugMode && strings.Contains(c.GetHeader("Accept"), "text/html") {
+			return nil, nil
+		}
+	}
+
+	if request.Query == "" {
+		return nil, problem.InvalidRequest.New("A GraphQL request must have a query")
+	}
+
+	return request, nil
+}
+
+// stream writes subscription results as Server-Sent Events until the subscription ends.
+func stream(c *gin.Context, results chan *graphql.Result) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	// The results are drained even after a write fails, so that the subscription can shut down
+	failed := false
+	for result := range results {
+		if failed {
+			continue
+		}
+
+		data, err := json.Marshal(result)
+		if err == nil {
+			_, err = io.WriteString(c.Writer, "event: next\ndata: "+string(data)+"\n\n")
+		}
+		if err != nil {
+			failed = true
+			continue
+		}
+		c.Writer.Flush()
+	}
+
+	if !failed {
+		_, _ = io.WriteString(c.Writer, "event: complete\ndata:\n\n")
+		c.Writer.Flush()
+	}
+}
+
+// graphiQL is the GraphiQL explorer, loaded from a CDN.
+const graphiQL = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>ToDo-Go GraphiQL</title>
+  <style>body { height: 100vh; margin: 0; } #graphiql { height: 100vh; }</style>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@2/graphiql.min.css">
+</head>
+<body>
+  <div id="graphiql">Loading…</div>
+  <script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphiql@2/graphiql.min.js"></script>
+  <script>
+    const fetcher = GraphiQL.createFetcher({ url: window.location.pathname });
+    ReactDOM.createRoot(document.getElementById("graphiql")).render(React.createElement(GraphiQL, { fetcher }));
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,32 @@
+package HealthController
+
+import (
+	"net/http"
+
+	"github.com/L4TTiCe/ToDo-Go/server/health"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Liveness is a handler function for liveness probes. It answers 200 for as long as the server can handle requests,
+// and checks no dependency, since restarting the server would not fix the dependency.
+func Liveness(c *gin.Context) {
+	report := health.Live()
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, &report)
+}
+
+// Readiness is a handler function for readiness probes. It answers 503 if a dependency is failing or the server is
+// shutting down, so that no more requests are sent to it, and 200 otherwise.
+func Readiness(c *gin.Context) {
+	report := health.Report(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status != models.HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, &report)
+}
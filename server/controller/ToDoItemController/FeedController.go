@@ -0,0 +1,84 @@
+package ToDoItemController
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/L4TTiCe/ToDo-Go/server/controller"
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/FeedTokenDao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/ToDoItemDao"
+	"github.com/L4TTiCe/ToDo-Go/server/ical"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// Feed is a handler function that serves a read-only iCalendar feed of the deadlines of open ToDoItems,
+// for calendar clients to subscribe to. It requires a feed token in the token query parameter. Items are not owned
+// by users, so the feed is the same whichever user the token was issued to.
+// The optional list and tag query parameters filter the items, component selects between VTODO (the default)
+// and all-day VEVENT entries, and tz sets the time zone used for the dates of all-day events.
+func Feed(c *gin.Context) {
+	feedToken, err := FeedTokenDao.Authenticate(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		errorResponse := problem.From(err)
+		if errors.Is(err, dao.ErrNotFound) {
+			errorResponse = problem.Unauthorized.New("The feed token is missing, unknown or has been revoked")
+		}
+
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+	logging.SetUser(c.Request.Context(), feedToken.User)
+
+	component := c.DefaultQuery("component", "vtodo")
+	if component != "vtodo" && component != "vevent" {
+		errorResponse := problem.InvalidRequest.New("Component must be one of the following: vtodo, vevent")
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	location, errorResponse := timeZone(c)
+	if errorResponse != nil {
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	items, err := ToDoItemDao.RetrieveDue(c.Request.Context(), c.Query("list"), c.Query("tag"))
+	if err != nil {
+		errorResponse := problem.From(err)
+
+		// Populate error response before sending to client
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	calendar := ical.NewCalendar()
+	calendar.Set("X-WR-CALNAME", "ToDo")
+	for i := range items {
+		// UIDs are derived from the ObjectID so that they stay stable across refreshes
+		uid := ical.ObjectUID(items[i].ID)
+		if component == "vevent" {
+			calendar.Components = append(calendar.Components, ical.DueEvent(&items[i], uid, location))
+		} else {
+			calendar.Components = append(calendar.Components, ical.FromItem(&items[i], uid))
+		}
+	}
+
+	// The token is part of the URL, so the feed must not be stored by shared caches
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := ical.NewEncoder(c.Writer).Component(calendar); err != nil {
+		c.Error(err)
+	}
+}
@@ -0,0 +1,121 @@
+package ToDoItemController
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/L4TTiCe/ToDo-Go/server/controller"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/ToDoItemDao"
+	"github.com/L4TTiCe/ToDo-Go/server/interchange"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// NDJSONContentType is the media type of newline-delimited JSON, which clients ask for in Accept to read the items
+// of a list one line at a time, as they arrive.
+const NDJSONContentType = "application/x-ndjson"
+
+// flushInterval is how many items are streamed between flushes. Flushing every item would cost a write for each,
+// and spoil compressing them.
+const flushInterval = 100
+
+// streamList streams the items of a list to the client as they are read from the database, encoded by the Encoder
+// newEncoder returns. The status and the headers set by header are only sent with the first item, so that a query
+// that fails at once still gets an error response.
+func streamList(c *gin.Context, list *ToDoItemDao.List, header func(), newEncoder func(w io.Writer) interchange.Encoder) {
+	var encoder interchange.Encoder
+	begin := func() {
+		header()
+		c.Status(http.StatusOK)
+		encoder = newEncoder(c.Writer)
+	}
+
+	count := 0
+	err := list.Stream(c.Request.Context(), func(item *models.ToDoItem) error {
+		if encoder == nil {
+			begin()
+		}
+		if err := encoder.Encode(item); err != nil {
+			return err
+		}
+		if count++; count%flushInterval == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if encoder == nil {
+			errorResponse := problem.From(err)
+
+			// Populate error response before sending to client
+			controller.PopulateErrorResponse(c, errorResponse)
+
+			c.JSON(errorResponse.Status, errorResponse)
+			return
+		}
+
+		// Headers have been sent, so the stream can only be cut short. The document is left unterminated, so that
+		// the client sees that it is incomplete
+		c.Error(err)
+		return
+	}
+
+	if encoder == nil {
+		begin()
+	}
+	if err := encoder.Close(); err != nil {
+		c.Error(err)
+	}
+}
+
+// listFormat picks the format of a list from the Accept header of the request: NDJSON if the client accepts it,
+// else a JSON array.
+func listFormat(accept string) (contentType string, newEncoder func(w io.Writer) interchange.Encoder) {
+	if acceptsNDJSON(accept) {
+		return NDJSONContentType, func(w io.Writer) interchange.Encoder {
+			return &ndjsonEncoder{w: w}
+		}
+	}
+	return interchange.JSON.ContentType(), func(w io.Writer) interchange.Encoder {
+		return interchange.NewEncoder(interchange.JSON, w, nil)
+	}
+}
+
+// acceptsNDJSON reports whether an Accept header lists NDJSON, with a quality above 0.
+func acceptsNDJSON(accept string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil || mediaType != NDJSONContentType {
+			continue
+		}
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// ndjsonEncoder writes items as newline-delimited JSON, one item per line.
+type ndjsonEncoder struct {
+	w io.Writer
+}
+
+func (e *ndjsonEncoder) Encode(item *models.ToDoItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(append(data, '\n'))
+	return err
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
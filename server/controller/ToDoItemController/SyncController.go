@@ -0,0 +1,345 @@
+package ToDoItemController
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/controller"
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/SequenceDao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/ToDoItemDao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/TombstoneDao"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/offline"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxSyncChanges is the largest number of changes accepted in a single SyncRequest.
+const maxSyncChanges = 1000
+
+// syncPageSize is the largest number of items and tombstones returned in a single SyncResponse.
+const syncPageSize = 500
+
+// mergeAttempts is how often a change is merged again when the item is written concurrently.
+const mergeAttempts = 3
+
+// syncStore holds the items and tombstones that offline changes are applied to.
+type syncStore interface {
+	RetrieveOne(ctx context.Context, id string) (*models.ToDoItem, error)
+	RetrieveByClientID(ctx context.Context, clientID string) (*models.ToDoItem, error)
+	RetrieveTombstone(ctx context.Context, id primitive.ObjectID, clientID string) (*models.Tombstone, error)
+	CreateSynced(ctx context.Context, item *models.ToDoItem) (*mongo.InsertOneResult, error)
+	ReplaceSynced(ctx context.Context, item *models.ToDoItem, sequence int64) error
+	DeleteOne(ctx context.Context, id string) (interface{}, error)
+}
+
+// daoStore is the syncStore of the DB.
+type daoStore struct{}
+
+func (daoStore) RetrieveOne(ctx context.Context, id string) (*models.ToDoItem, error) {
+	return ToDoItemDao.RetrieveOne(ctx, id)
+}
+
+func (daoStore) RetrieveByClientID(ctx context.Context, clientID string) (*models.ToDoItem, error) {
+	return ToDoItemDao.RetrieveByClientID(ctx, clientID)
+}
+
+func (daoStore) RetrieveTombstone(ctx context.Context, id primitive.ObjectID, clientID string) (*models.Tombstone, error) {
+	return TombstoneDao.RetrieveForItem(ctx, id, clientID)
+}
+
+func (daoStore) CreateSynced(ctx context.Context, item *models.ToDoItem) (*mongo.InsertOneResult, error) {
+	return ToDoItemDao.CreateSynced(ctx, item)
+}
+
+func (daoStore) ReplaceSynced(ctx context.Context, item *models.ToDoItem, sequence int64) error {
+	return ToDoItemDao.ReplaceSynced(ctx, item, sequence)
+}
+
+func (daoStore) DeleteOne(ctx context.Context, id string) (interface{}, error) {
+	return ToDoItemDao.DeleteOne(ctx, id)
+}
+
+// syncItems is the store that Sync applies changes to.
+var syncItems syncStore = daoStore{}
+
+// Sync is a handler function that reconciles an offline client with the server.
+// It applies the client's changes in order, each resolved field by field against the server's (see package offline),
+// and returns the outcome of each, followed by the items written and deleted since the client's token. Replaying a
+// request is harmless: items created by the client are found by their client ID, and changes that were already
+// applied leave the items unchanged.
+func Sync(c *gin.Context) {
+	request := models.SyncRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errorResponse := problem.InvalidRequest.New("Error parsing JSON: " + err.Error())
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	after, err := strconv.ParseInt(request.Token, 10, 64)
+	if request.Token == "" {
+		after, err = 0, nil
+	}
+	if err != nil || after < 0 {
+		errorResponse := problem.InvalidRequest.New("Invalid sync token")
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	if request.Strategy == "" {
+		request.Strategy = models.SyncLastWriterWins
+	}
+	var violations []models.Violation
+	if request.Strategy != models.SyncLastWriterWins && request.Strategy != models.SyncReport {
+		violations = append(violations, models.Violation{Pointer: "/strategy", Message: "must be one of the following: lww, report"})
+	}
+	if len(request.Changes) > maxSyncChanges {
+		violations = append(violations, models.Violation{Pointer: "/changes", Message: "must have at most " + strconv.Itoa(maxSyncChanges) + " changes"})
+	}
+	if len(violations) > 0 {
+		errorResponse := problem.ValidationFailed.New("One or more fields are invalid")
+		errorResponse.Violations = violations
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	ctx := c.Request.Context()
+	response := models.SyncResponse{
+		Results: make([]models.SyncResult, len(request.Changes)),
+		Items:   []models.ToDoItem{},
+		Deleted: []models.Tombstone{},
+	}
+	for i := range request.Changes {
+		response.Results[i] = applyChange(ctx, &request.Changes[i], request.Strategy)
+	}
+
+	// The changes are read after applying the client's, so that the client receives the items as the server has them
+	if err := changesSince(ctx, after, &response); err != nil {
+		errorResponse := problem.From(err)
+
+		// Populate error response before sending to client
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	c.JSON(http.StatusOK, &response)
+}
+
+// changesSince fills the response with the items written and deleted after the given point of the change sequence,
+// in order, up to syncPageSize of them, and the token to continue from.
+func changesSince(ctx context.Context, after int64, response *models.SyncResponse) error {
+	stable, err := SequenceDao.Stable(ctx)
+	if err != nil {
+		return err
+	}
+
+	// A token from the future comes from a different or restored DB, so the client has to start over
+	if after > stable {
+		after = 0
+		response.Reset = true
+	}
+
+	items, err := ToDoItemDao.RetrieveChangedAfter(ctx, after, stable, syncPageSize+1)
+	if err != nil {
+		return err
+	}
+	var tombstones []models.Tombstone
+	// A client syncing from scratch has nothing to delete
+	if after > 0 {
+		if tombstones, err = TombstoneDao.RetrieveAfter(ctx, after, stable, syncPageSize+1); err != nil {
+			return err
+		}
+	}
+
+	// Both are in sequence order, and are merged until the page is full
+	last := after
+	i, j := 0, 0
+	for i+j < syncPageSize && (i < len(items) || j < len(tombstones)) {
+		if j == len(tombstones) || (i < len(items) && items[i].Sequence < tombstones[j].Sequence) {
+			response.Items = append(response.Items, items[i])
+			last = items[i].Sequence
+			i++
+		} else {
+			response.Deleted = append(response.Deleted, tombstones[j])
+			last = tombstones[j].Sequence
+			j++
+		}
+	}
+
+	response.More = i < len(items) || j < len(tombstones)
+	if response.More {
+		response.Token = strconv.FormatInt(last, 10)
+	} else {
+		response.Token = strconv.FormatInt(stable, 10)
+	}
+	return nil
+}
+
+// applyChange applies a single change from an offline client and describes the outcome.
+func applyChange(ctx context.Context, change *models.SyncChange, strategy string) models.SyncResult {
+	result := models.SyncResult{ID: change.ID, ClientID: change.ClientID}
+	reject := func(err error) models.SyncResult {
+		result.Status = models.SyncRejected
+		result.Error = problem.From(err)
+		return result
+	}
+
+	// Clients date changes with their own clock, which must not place them ahead of every later change
+	now := time.Now().UnixMilli()
+	modifiedAt := change.ModifiedAt
+	if modifiedAt <= 0 || modifiedAt > now {
+		modifiedAt = now
+	}
+
+	var id primitive.ObjectID
+	if change.ID != "" {
+		var err error
+		if id, err = primitive.ObjectIDFromHex(change.ID); err != nil {
+			return reject(dao.InvalidID(change.ID))
+		}
+	} else if change.ClientID == "" {
+		return reject(dao.Validation("One or more fields are invalid", models.Violation{Pointer: "/id", Message: "or clientId is required"}))
+	}
+
+	for attempt := 1; ; attempt++ {
+		item, err := retrieveSynced(ctx, change)
+		if errors.Is(err, dao.ErrNotFound) {
+			missing, created := applyToMissing(ctx, change, id, modifiedAt, result)
+			// A replay of the change created the item meanwhile, and the change is merged into it instead
+			if created && attempt < mergeAttempts {
+				continue
+			}
+			return missing
+		}
+		if err != nil {
+			return reject(err)
+		}
+		result.ID = item.ID.Hex()
+		result.ClientID = item.ClientID
+
+		if change.Deleted {
+			return applyDelete(ctx, item, modifiedAt, result)
+		}
+
+		sequence := item.Sequence
+		changed, conflicts := offline.Merge(item, change.Fields, modifiedAt, strategy)
+		result.Conflicts = conflicts
+		if !changed {
+			result.Status = models.SyncUnchanged
+			if len(conflicts) > 0 {
+				result.Status = models.SyncConflicted
+			}
+			return result
+		}
+
+		err = syncItems.ReplaceSynced(ctx, item, sequence)
+		if errors.Is(err, dao.ErrConflict) && attempt < mergeAttempts {
+			continue
+		}
+		if err != nil {
+			return reject(err)
+		}
+
+		result.Status = models.SyncApplied
+		return result
+	}
+}
+
+// retrieveSynced retrieves the item a change refers to, by ID or by client ID.
+func retrieveSynced(ctx context.Context, change *models.SyncChange) (*models.ToDoItem, error) {
+	if change.ID != "" {
+		return syncItems.RetrieveOne(ctx, change.ID)
+	}
+	return syncItems.RetrieveByClientID(ctx, change.ClientID)
+}
+
+// applyToMissing applies a change to an item that does not exist: one the client created, or one that was deleted.
+// It reports whether the item has been created with the change's client ID since it was found missing, in which case
+// the change has not been applied.
+func applyToMissing(ctx context.Context, change *models.SyncChange, id primitive.ObjectID, modifiedAt int64, result models.SyncResult) (_ models.SyncResult, created bool) {
+	tombstone, err := syncItems.RetrieveTombstone(ctx, id, change.ClientID)
+	if err != nil && !errors.Is(err, dao.ErrNotFound) {
+		result.Status = models.SyncRejected
+		result.Error = problem.From(err)
+		return result, false
+	}
+
+	// Deletes win over changes, whenever they were made
+	if tombstone != nil {
+		result.ID = tombstone.ItemID.Hex()
+		if change.Deleted {
+			result.Status = models.SyncUnchanged
+		} else {
+			result.Status = models.SyncConflicted
+			result.Conflicts = []models.SyncConflict{{ModifiedAt: tombstone.DeletedAt}}
+		}
+		return result, false
+	}
+
+	switch {
+	case !id.IsZero():
+		result.Status = models.SyncRejected
+		result.Error = problem.NotFound.New("Item with ID " + change.ID + " not found")
+	case change.Deleted:
+		// The client created and deleted the item before it was ever synced
+		result.Status = models.SyncUnchanged
+	default:
+		item := &models.ToDoItem{ClientID: change.ClientID}
+		offline.Apply(item, change.Fields, modifiedAt)
+
+		inserted, err := syncItems.CreateSynced(ctx, item)
+		// Client IDs are unique, so the item was created by a concurrent replay of the change
+		if errors.Is(err, dao.ErrConflict) {
+			result.Status = models.SyncRejected
+			result.Error = problem.From(err)
+			return result, true
+		}
+		if err != nil {
+			result.Status = models.SyncRejected
+			result.Error = problem.From(err)
+			return result, false
+		}
+		if objectID, ok := inserted.InsertedID.(primitive.ObjectID); ok {
+			result.ID = objectID.Hex()
+		}
+		result.Status = models.SyncApplied
+	}
+	return result, false
+}
+
+// applyDelete deletes an item unless one of its fields was written after the client deleted it, in which case the
+// item is kept and those fields are reported as conflicts.
+func applyDelete(ctx context.Context, item *models.ToDoItem, modifiedAt int64, result models.SyncResult) models.SyncResult {
+	result.Conflicts = offline.Newer(item, modifiedAt)
+	if len(result.Conflicts) > 0 {
+		result.Status = models.SyncConflicted
+		return result
+	}
+
+	if _, err := syncItems.DeleteOne(ctx, item.ID.Hex()); err != nil {
+		if errors.Is(err, dao.ErrNotFound) {
+			result.Status = models.SyncUnchanged
+			return result
+		}
+		result.Status = models.SyncRejected
+		result.Error = problem.From(err)
+		return result
+	}
+
+	result.Status = models.SyncApplied
+	return result
+}
@@ -0,0 +1,397 @@
+package ToDoItemController
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryStore is a syncStore in memory, which keeps client IDs unique and tombstones of deleted items like the DB.
+type memoryStore struct {
+	items      map[primitive.ObjectID]models.ToDoItem
+	tombstones []models.Tombstone
+	sequence   int64
+
+	// beforeCreate and beforeReplace run once before the next write, to write the item concurrently.
+	beforeCreate  func(s *memoryStore)
+	beforeReplace func(s *memoryStore)
+}
+
+func (s *memoryStore) RetrieveOne(ctx context.Context, id string) (*models.ToDoItem, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, dao.InvalidID(id)
+	}
+	item, ok := s.items[objectID]
+	if !ok {
+		return nil, dao.NotFound("Item with ID " + id + " not found")
+	}
+	return clone(item), nil
+}
+
+func (s *memoryStore) RetrieveByClientID(ctx context.Context, clientID string) (*models.ToDoItem, error) {
+	for _, item := range s.items {
+		if item.ClientID == clientID {
+			return clone(item), nil
+		}
+	}
+	return nil, dao.NotFound("Item with client ID " + clientID + " not found")
+}
+
+func (s *memoryStore) RetrieveTombstone(ctx context.Context, id primitive.ObjectID, clientID string) (*models.Tombstone, error) {
+	for _, tombstone := range s.tombstones {
+		if tombstone.ItemID == id && !id.IsZero() || id.IsZero() && tombstone.ClientID == clientID {
+			return &tombstone, nil
+		}
+	}
+	return nil, dao.NotFound("Item has not been deleted")
+}
+
+func (s *memoryStore) CreateSynced(ctx context.Context, item *models.ToDoItem) (*mongo.InsertOneResult, error) {
+	if s.beforeCreate != nil {
+		s.beforeCreate(s)
+		s.beforeCreate = nil
+	}
+	for _, stored := range s.items {
+		if stored.ClientID == item.ClientID {
+			return nil, dao.Conflict("Item with client ID " + item.ClientID + " exists already")
+		}
+	}
+
+	item.ID = primitive.NewObjectID()
+	s.write(item)
+	return &mongo.InsertOneResult{InsertedID: item.ID}, nil
+}
+
+func (s *memoryStore) ReplaceSynced(ctx context.Context, item *models.ToDoItem, sequence int64) error {
+	if s.beforeReplace != nil {
+		s.beforeReplace(s)
+		s.beforeReplace = nil
+	}
+	if s.items[item.ID].Sequence != sequence {
+		return dao.Conflict("Item with ID " + item.ID.Hex() + " was changed concurrently")
+	}
+
+	s.write(item)
+	return nil
+}
+
+func (s *memoryStore) DeleteOne(ctx context.Context, id string) (interface{}, error) {
+	item, err := s.RetrieveOne(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(s.items, item.ID)
+	s.sequence++
+	s.tombstones = append(s.tombstones, models.Tombstone{ItemID: item.ID, ClientID: item.ClientID, DeletedAt: time.Now().UnixMilli(), Sequence: s.sequence})
+	return &mongo.DeleteResult{DeletedCount: 1}, nil
+}
+
+// write stores the item as the DB does, numbering the write in the change sequence.
+func (s *memoryStore) write(item *models.ToDoItem) {
+	s.sequence++
+	item.Sequence = s.sequence
+	item.UpdatedAt = time.Now().UnixMilli()
+	s.items[item.ID] = *clone(*item)
+}
+
+// clone copies an item, so that changes to the copy do not reach the store.
+func clone(item models.ToDoItem) *models.ToDoItem {
+	item.Tags = append([]string(nil), item.Tags...)
+	versions := make(map[string]int64, len(item.Versions))
+	for name, version := range item.Versions {
+		versions[name] = version
+	}
+	item.Versions = versions
+	return &item
+}
+
+var (
+	storedID  = primitive.NewObjectID()
+	deletedID = primitive.NewObjectID()
+)
+
+// newMemoryStore returns a store with the item "Pay rent", created offline as client-1, whose title was written at
+// 1000 and completion at 2000, and with client-2, which was deleted at 1500.
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		items: map[primitive.ObjectID]models.ToDoItem{
+			storedID: {
+				ID:        storedID,
+				ClientID:  "client-1",
+				Title:     "Pay rent",
+				UpdatedAt: 2000,
+				Sequence:  5,
+				Versions: map[string]int64{
+					"title": 1000, "completed": 2000, "deadline": 1000, "list": 1000, "tags": 1000, "priority": 1000, "recurrence": 1000,
+				},
+			},
+		},
+		tombstones: []models.Tombstone{{ItemID: deletedID, ClientID: "client-2", DeletedAt: 1500, Sequence: 4}},
+		sequence:   5,
+	}
+}
+
+func text(s string) *string { return &s }
+func flag(b bool) *bool     { return &b }
+
+func TestApplyChange(t *testing.T) {
+	// The item client-3 as created by the change below
+	createdConcurrently := func(s *memoryStore) {
+		item := &models.ToDoItem{ID: primitive.NewObjectID(), ClientID: "client-3", Title: "Call mum", Versions: map[string]int64{}}
+		for _, name := range []string{"title", "completed", "deadline", "list", "tags", "priority", "recurrence"} {
+			item.Versions[name] = 3000
+		}
+		s.write(item)
+	}
+	writtenConcurrently := func(s *memoryStore) {
+		item := s.items[storedID]
+		s.write(&item)
+	}
+
+	tests := []struct {
+		name     string
+		change   models.SyncChange
+		strategy string
+		prepare  func(s *memoryStore)
+		// status, conflicts and the ID of the result, if known
+		status    string
+		conflicts []models.SyncConflict
+		id        primitive.ObjectID
+		// errorStatus is the Status of the result's Error, if it has one
+		errorStatus int
+		// title is the title of the stored item afterwards, or "" if it is deleted
+		title string
+	}{
+		{
+			name:   "create",
+			change: models.SyncChange{ClientID: "client-3", ModifiedAt: 3000, Fields: &models.SyncFields{Title: text("Call mum")}},
+			status: models.SyncApplied,
+		},
+		{
+			// The client did not receive the response to its first sync, and sends the change again
+			name:   "replayed create",
+			change: models.SyncChange{ClientID: "client-1", ModifiedAt: 1000, Fields: &models.SyncFields{Title: text("Pay rent")}},
+			status: models.SyncUnchanged,
+			id:     storedID,
+			title:  "Pay rent",
+		},
+		{
+			name:    "create replayed concurrently",
+			change:  models.SyncChange{ClientID: "client-3", ModifiedAt: 3000, Fields: &models.SyncFields{Title: text("Call mum")}},
+			prepare: func(s *memoryStore) { s.beforeCreate = createdConcurrently },
+			status:  models.SyncUnchanged,
+		},
+		{
+			name:   "newer update",
+			change: models.SyncChange{ID: storedID.Hex(), ModifiedAt: 3000, Fields: &models.SyncFields{Title: text("Pay the rent")}},
+			status: models.SyncApplied,
+			id:     storedID,
+			title:  "Pay the rent",
+		},
+		{
+			name:   "update by client ID",
+			change: models.SyncChange{ClientID: "client-1", ModifiedAt: 3000, Fields: &models.SyncFields{Title: text("Pay the rent")}},
+			status: models.SyncApplied,
+			id:     storedID,
+			title:  "Pay the rent",
+		},
+		{
+			name:      "older update",
+			change:    models.SyncChange{ID: storedID.Hex(), ModifiedAt: 1500, Fields: &models.SyncFields{Completed: flag(true)}},
+			status:    models.SyncConflicted,
+			conflicts: []models.SyncConflict{{Field: "completed", Value: false, ModifiedAt: 2000}},
+			id:        storedID,
+			title:     "Pay rent",
+		},
+		{
+			name:      "concurrent edits",
+			change:    models.SyncChange{ID: storedID.Hex(), ModifiedAt: 1500, Fields: &models.SyncFields{Title: text("Pay the rent"), Completed: flag(true)}},
+			status:    models.SyncApplied,
+			conflicts: []models.SyncConflict{{Field: "completed", Value: false, ModifiedAt: 2000}},
+			id:        storedID,
+			title:     "Pay the rent",
+		},
+		{
+			name:      "concurrent edits reported",
+			change:    models.SyncChange{ID: storedID.Hex(), ModifiedAt: 1500, Fields: &models.SyncFields{Title: text("Pay the rent"), Completed: flag(true)}},
+			strategy:  models.SyncReport,
+			status:    models.SyncConflicted,
+			conflicts: []models.SyncConflict{{Field: "completed", Value: false, ModifiedAt: 2000}},
+			id:        storedID,
+			title:     "Pay rent",
+		},
+		{
+			// The item is written between reading and replacing it, and the change is merged again
+			name:    "update written concurrently",
+			change:  models.SyncChange{ID: storedID.Hex(), ModifiedAt: 3000, Fields: &models.SyncFields{Title: text("Pay the rent")}},
+			prepare: func(s *memoryStore) { s.beforeReplace = writtenConcurrently },
+			status:  models.SyncApplied,
+			id:      storedID,
+			title:   "Pay the rent",
+		},
+		{
+			name:   "delete",
+			change: models.SyncChange{ID: storedID.Hex(), ModifiedAt: 3000, Deleted: true},
+			status: models.SyncApplied,
+			id:     storedID,
+		},
+		{
+			// The item was completed after the client deleted it, and is kept
+			name:      "delete before a newer update",
+			change:    models.SyncChange{ID: storedID.Hex(), ModifiedAt: 1500, Deleted: true},
+			status:    models.SyncConflicted,
+			conflicts: []models.SyncConflict{{Field: "completed", Value: false, ModifiedAt: 2000}},
+			id:        storedID,
+			title:     "Pay rent",
+		},
+		{
+			// Deletes win over changes, whenever they were made
+			name:      "update of a deleted item",
+			change:    models.SyncChange{ID: deletedID.Hex(), ModifiedAt: 3000, Fields: &models.SyncFields{Title: text("Call mum")}},
+			status:    models.SyncConflicted,
+			conflicts: []models.SyncConflict{{ModifiedAt: 1500}},
+			id:        deletedID,
+		},
+		{
+			name:      "update of a deleted item by client ID",
+			change:    models.SyncChange{ClientID: "client-2", ModifiedAt: 3000, Fields: &models.SyncFields{Title: text("Call mum")}},
+			status:    models.SyncConflicted,
+			conflicts: []models.SyncConflict{{ModifiedAt: 1500}},
+			id:        deletedID,
+		},
+		{
+			name:   "replayed delete",
+			change: models.SyncChange{ID: deletedID.Hex(), ModifiedAt: 1500, Deleted: true},
+			status: models.SyncUnchanged,
+			id:     deletedID,
+		},
+		{
+			name:   "created and deleted offline",
+			change: models.SyncChange{ClientID: "client-3", ModifiedAt: 3000, Deleted: true},
+			status: models.SyncUnchanged,
+		},
+		{
+			name:        "unknown ID",
+			change:      models.SyncChange{ID: primitive.NewObjectID().Hex(), ModifiedAt: 3000, Fields: &models.SyncFields{Title: text("Call mum")}},
+			status:      models.SyncRejected,
+			errorStatus: http.StatusNotFound,
+		},
+		{
+			name:        "invalid ID",
+			change:      models.SyncChange{ID: "42", ModifiedAt: 3000, Fields: &models.SyncFields{Title: text("Call mum")}},
+			status:      models.SyncRejected,
+			errorStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "no ID",
+			change:      models.SyncChange{ModifiedAt: 3000, Fields: &models.SyncFields{Title: text("Call mum")}},
+			status:      models.SyncRejected,
+			errorStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	defer func(store syncStore) { syncItems = store }(syncItems)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := newMemoryStore()
+			if test.prepare != nil {
+				test.prepare(store)
+			}
+			syncItems = store
+
+			strategy := test.strategy
+			if strategy == "" {
+				strategy = models.SyncLastWriterWins
+			}
+			result := applyChange(context.Background(), &test.change, strategy)
+
+			if result.Status != test.status {
+				t.Errorf("status = %s, want %s", result.Status, test.status)
+			}
+			if !reflect.DeepEqual(result.Conflicts, test.conflicts) {
+				t.Errorf("conflicts = %+v, want %+v", result.Conflicts, test.conflicts)
+			}
+			if !test.id.IsZero() && result.ID != test.id.Hex() {
+				t.Errorf("ID = %s, want %s", result.ID, test.id.Hex())
+			}
+			if test.errorStatus != 0 && (result.Error == nil || result.Error.Status != test.errorStatus) {
+				t.Errorf("error = %+v, want status %d", result.Error, test.errorStatus)
+			}
+			if test.errorStatus == 0 && result.Error != nil {
+				t.Errorf("error = %+v, want none", result.Error)
+			}
+
+			if test.id == storedID {
+				if item, ok := store.items[storedID]; item.Title != test.title || ok != (test.title != "") {
+					t.Errorf("stored title = %q, want %q", item.Title, test.title)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyChangeCreates(t *testing.T) {
+	defer func(store syncStore) { syncItems = store }(syncItems)
+	store := newMemoryStore()
+	syncItems = store
+
+	change := models.SyncChange{ClientID: "client-3", ModifiedAt: 3000, Fields: &models.SyncFields{Title: text("Call mum"), Tags: &[]string{"family"}}}
+	result := applyChange(context.Background(), &change, models.SyncLastWriterWins)
+
+	id, err := primitive.ObjectIDFromHex(result.ID)
+	if err != nil {
+		t.Fatalf("ID = %q, want the ID of the created item", result.ID)
+	}
+	item := store.items[id]
+	if item.ClientID != "client-3" || item.Title != "Call mum" || !reflect.DeepEqual(item.Tags, []string{"family"}) || item.Versions["title"] != 3000 {
+		t.Errorf("created %+v, want the item of the change", item)
+	}
+
+	deleted := models.SyncChange{ID: storedID.Hex(), ModifiedAt: 3000, Deleted: true}
+	applyChange(context.Background(), &deleted, models.SyncLastWriterWins)
+	if tombstone, err := store.RetrieveTombstone(context.Background(), storedID, ""); err != nil || tombstone.ClientID != "client-1" {
+		t.Errorf("tombstone = %+v, %v, want the tombstone of client-1", tombstone, err)
+	}
+}
+
+// TestApplyChangeReplay applies the changes of a sync twice, as a client does that did not receive the response.
+func TestApplyChangeReplay(t *testing.T) {
+	defer func(store syncStore) { syncItems = store }(syncItems)
+	store := newMemoryStore()
+	syncItems = store
+
+	changes := []models.SyncChange{
+		{ClientID: "client-3", ModifiedAt: 3000, Fields: &models.SyncFields{Title: text("Call mum")}},
+		{ClientID: "client-3", ModifiedAt: 3100, Fields: &models.SyncFields{Completed: flag(true)}},
+		{ID: storedID.Hex(), ModifiedAt: 3000, Fields: &models.SyncFields{Title: text("Pay the rent")}},
+		{ClientID: "client-4", ModifiedAt: 3000, Fields: &models.SyncFields{Title: text("Buy milk")}},
+		{ClientID: "client-4", ModifiedAt: 3200, Deleted: true},
+	}
+	for i := range changes {
+		if result := applyChange(context.Background(), &changes[i], models.SyncLastWriterWins); result.Status != models.SyncApplied {
+			t.Fatalf("change %d: status = %s, want %s", i+1, result.Status, models.SyncApplied)
+		}
+	}
+
+	// The item created and then deleted by the client stays deleted, which is reported as a conflict
+	replayed := []string{models.SyncUnchanged, models.SyncUnchanged, models.SyncUnchanged, models.SyncConflicted, models.SyncUnchanged}
+	sequence := store.sequence
+	for i := range changes {
+		if result := applyChange(context.Background(), &changes[i], models.SyncLastWriterWins); result.Status != replayed[i] {
+			t.Errorf("replayed change %d: status = %s, want %s", i+1, result.Status, replayed[i])
+		}
+	}
+	if store.sequence != sequence {
+		t.Errorf("replay wrote %d times, want none", store.sequence-sequence)
+	}
+}
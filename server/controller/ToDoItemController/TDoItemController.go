@@ -1,12 +1,17 @@
 package ToDoItemController
 
 import (
+	"bytes"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/L4TTiCe/ToDo-Go/server/controller"
 	"github.com/L4TTiCe/ToDo-Go/server/dao/ToDoItemDao"
+	"github.com/L4TTiCe/ToDo-Go/server/listing"
 	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+	"github.com/L4TTiCe/ToDo-Go/server/quickadd"
 	"github.com/gin-gonic/gin"
 )
 
@@ -22,13 +27,9 @@ func Create(c *gin.Context) {
 	var item models.ToDoItem
 
 	// Bind JSON to struct
-	err := c.BindJSON(&item)
+	err := c.ShouldBindJSON(&item)
 	if err != nil {
-		errorResponse := &models.ErrorResponse{
-			Status: http.StatusInternalServerError,
-			Title:  err.Error(),
-			Detail: "Error parsing JSON",
-		}
+		errorResponse := problem.InvalidRequest.New("Error parsing JSON: " + err.Error())
 		controller.PopulateErrorResponse(c, errorResponse)
 
 		c.JSON(errorResponse.Status, errorResponse)
@@ -36,8 +37,10 @@ func Create(c *gin.Context) {
 	}
 
 	// Attempt to create item in DB using DAO
-	result, errorResponse := ToDoItemDao.Create(&item)
-	if errorResponse != nil {
+	result, err := ToDoItemDao.Create(c.Request.Context(), &item)
+	if err != nil {
+		errorResponse := problem.From(err)
+
 		// Populate error response before sending to client
 		controller.PopulateErrorResponse(c, errorResponse)
 
@@ -49,141 +52,70 @@ func Create(c *gin.Context) {
 	c.JSON(http.StatusCreated, &result)
 }
 
-func RetrieveAll(c *gin.Context) {
-	attrib := c.Request.URL.Query().Get("attrib")
-
-	sort := c.Request.URL.Query().Get("sort")
-	before := c.Request.URL.Query().Get("before")
-	after := c.Request.URL.Query().Get("after")
-	start := c.Request.URL.Query().Get("start")
-	end := c.Request.URL.Query().Get("end")
-
-	var sortOrder int
-
-	switch sort {
-	case "asc", "1":
-		sortOrder = 1
-	case "desc", "-1":
-		sortOrder = -1
-	case "":
-		sortOrder = 1
-	default:
-		sortOrder = 0
-	}
+// QuickAdd is a handler function that creates a new ToDoItem from a free-form string.
+// It takes a JSON body with a text field, parses it into a ToDoItem and creates it in the DB.
+// If the dryRun query parameter is true, the parsed ToDoItem is returned without being created.
+// The optional tz query parameter is an IANA time zone used to resolve relative dates.
+func QuickAdd(c *gin.Context) {
+	var request models.QuickAddRequest
 
-	var result []models.ToDoItem
-	var errorResponse *models.ErrorResponse
-
-	if attrib != "" {
-		var verb string
-		var date int64
-
-		var startDate int64
-		var endDate int64
-
-		// Either before or after must be specified, together with a verb
-		if before != "" && after == "" && start == "" && end == "" { // before
-			verb = "lte"
-			intVal, err := strconv.Atoi(before)
-			if err != nil {
-				errorResponse = &models.ErrorResponse{
-					Status: http.StatusBadRequest,
-					Title:  "Invalid Date",
-					Detail: "Date must be a positive integer",
-				}
-				controller.PopulateErrorResponse(c, errorResponse)
-				c.JSON(errorResponse.Status, errorResponse)
-				return
-			}
+	// Bind JSON to struct
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		errorResponse := problem.InvalidRequest.New("Error parsing JSON: " + err.Error())
+		controller.PopulateErrorResponse(c, errorResponse)
 
-			date = int64(intVal)
-		} else if after != "" && before == "" && start == "" && end == "" { // after
-			verb = "gte"
-			intVal, err := strconv.Atoi(after)
-			if err != nil {
-				errorResponse = &models.ErrorResponse{
-					Status: http.StatusBadRequest,
-					Title:  "Invalid Date",
-					Detail: "Date must be a positive integer",
-				}
-				controller.PopulateErrorResponse(c, errorResponse)
-				c.JSON(errorResponse.Status, errorResponse)
-				return
-			}
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
 
-			date = int64(intVal)
-		} else if after != "" && before != "" && start == "" && end == "" { // after and before
-			errorResponse = &models.ErrorResponse{
-				Status: http.StatusBadRequest,
-				Title:  "Invalid Query",
-				Detail: "Must specify either before or after",
-			}
-			controller.PopulateErrorResponse(c, errorResponse)
-			c.JSON(errorResponse.Status, errorResponse)
-			return
-		} else if (after != "" || before != "") && start != "" && end != "" { // start and end and after or before
-			errorResponse = &models.ErrorResponse{
-				Status: http.StatusBadRequest,
-				Title:  "Invalid Query",
-				Detail: "Must specify either before / after or start and end",
-			}
+	dryRun := false
+	if value := c.Query("dryRun"); value != "" {
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			errorResponse := problem.InvalidRequest.New("dryRun must be a boolean")
 			controller.PopulateErrorResponse(c, errorResponse)
+
 			c.JSON(errorResponse.Status, errorResponse)
 			return
-		} else if after == "" && before == "" && start != "" && end != "" { // start and end
-			// validate start and end
-			intVal, err := strconv.Atoi(start)
-			if err != nil {
-				errorResponse = &models.ErrorResponse{
-					Status: http.StatusBadRequest,
-					Title:  "Invalid Date",
-					Detail: "Date must be a positive integer. Check start.",
-				}
-				controller.PopulateErrorResponse(c, errorResponse)
-				c.JSON(errorResponse.Status, errorResponse)
-				return
-			}
+		}
+	}
 
-			startDate = int64(intVal)
+	location, errorResponse := timeZone(c)
+	if errorResponse != nil {
+		controller.PopulateErrorResponse(c, errorResponse)
 
-			intVal, err = strconv.Atoi(end)
-			if err != nil {
-				errorResponse = &models.ErrorResponse{
-					Status: http.StatusBadRequest,
-					Title:  "Invalid Date",
-					Detail: "Date must be a positive integer. Check end.",
-				}
-				controller.PopulateErrorResponse(c, errorResponse)
-				c.JSON(errorResponse.Status, errorResponse)
-				return
-			}
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
 
-			endDate = int64(intVal)
-		}
+	item := quickadd.Parse(request.Text, time.Now().In(location))
 
-		if before == "" && after == "" && start == "" && end == "" { // no params (before, after, start, end)
-			result, errorResponse = ToDoItemDao.RetrieveAll(attrib, sortOrder)
-		} else if (before != "" || after != "") && start == "" && end == "" { // before or after
-			result, errorResponse = ToDoItemDao.RetrieveWithParams(attrib, verb, date, sortOrder)
-		} else if start != "" && end != "" { // start and end
-			result, errorResponse = ToDoItemDao.RetrieveBetween(attrib, startDate, endDate, sortOrder)
-		}
+	if dryRun {
+		c.JSON(http.StatusOK, &item)
+		return
+	}
 
-	} else {
-		if before != "" || after != "" || start != "" || end != "" {
-			errorResponse = &models.ErrorResponse{
-				Status: http.StatusBadRequest,
-				Title:  "Invalid Query",
-				Detail: "Must specify an attribute to use with the before, after, start, or end parameters",
-			}
-			controller.PopulateErrorResponse(c, errorResponse)
-			c.JSON(errorResponse.Status, errorResponse)
-			return
-		}
+	// Attempt to create item in DB using DAO
+	result, err := ToDoItemDao.Create(c.Request.Context(), &item)
+	if err != nil {
+		errorResponse := problem.From(err)
 
-		result, errorResponse = ToDoItemDao.RetrieveAll("createdAt", sortOrder)
+		// Populate error response before sending to client
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
 	}
 
+	c.JSON(http.StatusCreated, &result)
+}
+
+// RetrieveAll is a handler function that returns all ToDoItems matching the query parameters.
+// See listing.Parse for the supported parameters.
+// The items are a JSON array, or NDJSON if the Accept header asks for it, streamed as they are read.
+func RetrieveAll(c *gin.Context) {
+	list, errorResponse := listing.Parse(c.Request.URL.Query())
 	if errorResponse != nil {
 		// Populate error response before sending to client
 		controller.PopulateErrorResponse(c, errorResponse)
@@ -192,18 +124,41 @@ func RetrieveAll(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	contentType, newEncoder := listFormat(c.GetHeader("Accept"))
+	c.Writer.Header().Add("Vary", "Accept")
+
+	// Cached lists are short and already in memory, so they are sent whole, with an ETag to revalidate them by
+	if items, ok := list.Cached(); ok {
+		var body bytes.Buffer
+		encoder := newEncoder(&body)
+		for i := range items {
+			if err := encoder.Encode(&items[i]); err != nil {
+				errorResponse := problem.Internal.New("Error encoding JSON: " + err.Error())
+				controller.PopulateErrorResponse(c, errorResponse)
+
+				c.JSON(errorResponse.Status, errorResponse)
+				return
+			}
+		}
+		encoder.Close()
+
+		controller.CacheableData(c, http.StatusOK, contentType, body.Bytes())
+		return
+	}
+
+	streamList(c, list, func() {
+		c.Header("Content-Type", contentType)
+		controller.SetCacheControl(c)
+	}, newEncoder)
 }
 
 func RetrieveOne(c *gin.Context) {
 	id := c.Param("id")
 
-	var result *models.ToDoItem
-	var errorResponse *models.ErrorResponse
-
-	result, errorResponse = ToDoItemDao.RetrieveOne(id)
+	result, err := ToDoItemDao.RetrieveOne(c.Request.Context(), id)
+	if err != nil {
+		errorResponse := problem.From(err)
 
-	if errorResponse != nil {
 		// Populate error response before sending to client
 		controller.PopulateErrorResponse(c, errorResponse)
 
@@ -211,15 +166,16 @@ func RetrieveOne(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, &result)
+	controller.CacheableJSON(c, http.StatusOK, &result)
 }
 
 func UpdateOne(c *gin.Context) {
 	id := c.Param("id")
 
-	item, errorResponse := ToDoItemDao.RetrieveOne(id)
+	item, err := ToDoItemDao.RetrieveOne(c.Request.Context(), id)
+	if err != nil {
+		errorResponse := problem.From(err)
 
-	if errorResponse != nil {
 		// Populate error response before sending to client
 		controller.PopulateErrorResponse(c, errorResponse)
 
@@ -228,22 +184,19 @@ func UpdateOne(c *gin.Context) {
 	}
 
 	// Bind JSON to struct
-	err := c.BindJSON(&item)
+	err = c.ShouldBindJSON(&item)
 	if err != nil {
-		errorResponse = &models.ErrorResponse{
-			Status: http.StatusInternalServerError,
-			Title:  err.Error(),
-			Detail: "Error parsing JSON",
-		}
+		errorResponse := problem.InvalidRequest.New("Error parsing JSON: " + err.Error())
 		controller.PopulateErrorResponse(c, errorResponse)
 
 		c.JSON(errorResponse.Status, errorResponse)
 		return
 	}
 
-	result, errorResponse := ToDoItemDao.UpdateOne(id, item)
+	result, err := ToDoItemDao.UpdateOne(c.Request.Context(), id, item)
+	if err != nil {
+		errorResponse := problem.From(err)
 
-	if errorResponse != nil {
 		// Populate error response before sending to client
 		controller.PopulateErrorResponse(c, errorResponse)
 
@@ -257,11 +210,10 @@ func UpdateOne(c *gin.Context) {
 func DeleteOne(c *gin.Context) {
 	id := c.Param("id")
 
-	var errorResponse *models.ErrorResponse
-
-	result, errorResponse := ToDoItemDao.DeleteOne(id)
+	result, err := ToDoItemDao.DeleteOne(c.Request.Context(), id)
+	if err != nil {
+		errorResponse := problem.From(err)
 
-	if errorResponse != nil {
 		// Populate error response before sending to client
 		controller.PopulateErrorResponse(c, errorResponse)
 
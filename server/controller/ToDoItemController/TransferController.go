@@ -0,0 +1,214 @@
+package ToDoItemController
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/controller"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/ToDoItemDao"
+	"github.com/L4TTiCe/ToDo-Go/server/interchange"
+	"github.com/L4TTiCe/ToDo-Go/server/listing"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxImportSize is the largest request body accepted by Import.
+const maxImportSize = 10 << 20
+
+// Export is a handler function that streams ToDoItems in the format given by the format query parameter
+// (json, csv, ics or todotxt, defaulting to json).
+// The items can be filtered and sorted with the same query parameters as RetrieveAll.
+func Export(c *gin.Context) {
+	format, location, errorResponse := transferParams(c)
+	if errorResponse != nil {
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	list, errorResponse := listing.Parse(c.Request.URL.Query())
+	if errorResponse != nil {
+		// Populate error response before sending to client
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	streamList(c, list, func() {
+		c.Header("Content-Type", format.ContentType())
+		c.Header("Content-Disposition", `attachment; filename="todo.`+format.Extension()+`"`)
+	}, func(w io.Writer) interchange.Encoder {
+		return interchange.NewEncoder(format, w, location)
+	})
+}
+
+// Import is a handler function that creates ToDoItems from a file in the request body.
+// The format query parameter selects the format (json, csv, ics or todotxt, defaulting to json), and map renames
+// columns or keys to ToDoItem fields, e.g. map=title:Name,deadline:Due. Rows whose external ID already exists
+// are skipped as duplicates. With dryRun=true nothing is created.
+// It returns an ImportReport with the outcome of every row.
+func Import(c *gin.Context) {
+	format, location, errorResponse := transferParams(c)
+	if errorResponse != nil {
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	mapping, err := interchange.ParseMapping(c.Query("map"))
+	if err != nil {
+		errorResponse = problem.InvalidRequest.New(err.Error())
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	dryRun := false
+	if value := c.Query("dryRun"); value != "" {
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			errorResponse = problem.InvalidRequest.New("dryRun must be a boolean")
+			controller.PopulateErrorResponse(c, errorResponse)
+
+			c.JSON(errorResponse.Status, errorResponse)
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	rows, err := interchange.Decode(format, body, mapping, location)
+	if err != nil {
+		errorResponse = problem.InvalidRequest.New(err.Error())
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	// Look up all external IDs at once to detect duplicates
+	var externalIDs []string
+	for _, row := range rows {
+		if row.Item.ExternalID != "" {
+			externalIDs = append(externalIDs, row.Item.ExternalID)
+		}
+	}
+	existing, err := ToDoItemDao.RetrieveExisting(c.Request.Context(), externalIDs)
+	if err != nil {
+		errorResponse := problem.From(err)
+
+		controller.PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	report := models.ImportReport{DryRun: dryRun, Rows: []models.ImportRowResult{}}
+	for i := range rows {
+		row := &rows[i]
+		result := checkRow(row, existing)
+		if result.Status != models.ImportValid || dryRun {
+			report.Add(result)
+			continue
+		}
+
+		inserted, err := ToDoItemDao.CreateImported(c.Request.Context(), &row.Item)
+		if err != nil {
+			result.Status = models.ImportFailed
+			result.Error = problem.Summary(problem.From(err))
+			report.Add(result)
+			continue
+		}
+
+		result.Status = models.ImportCreated
+		result.ID = objectIDHex(inserted.InsertedID)
+		report.Add(result)
+	}
+
+	status := http.StatusOK
+	if report.Created > 0 {
+		status = http.StatusCreated
+	}
+	c.JSON(status, &report)
+}
+
+// checkRow returns the outcome of importing a row without creating it: invalid, a duplicate, or valid.
+// Duplicates are detected against the external IDs that exist in the DB as well as earlier valid rows of the same
+// import, which checkRow adds to existing.
+func checkRow(row *interchange.Row, existing map[string]bool) models.ImportRowResult {
+	result := models.ImportRowResult{Row: row.Number, ExternalID: row.Item.ExternalID}
+
+	if row.Err != nil {
+		result.Status = models.ImportInvalid
+		result.Error = row.Err.Error()
+		return result
+	}
+
+	if row.Item.ExternalID != "" && existing[row.Item.ExternalID] {
+		result.Status = models.ImportDuplicate
+		return result
+	}
+
+	if err := ToDoItemDao.Validate(&row.Item); err != nil {
+		result.Status = models.ImportInvalid
+		result.Error = problem.Summary(problem.From(err))
+		return result
+	}
+
+	if row.Item.ExternalID != "" {
+		existing[row.Item.ExternalID] = true
+	}
+
+	result.Status = models.ImportValid
+	return result
+}
+
+// transferParams reads the format and tz query parameters shared by Export and Import.
+func transferParams(c *gin.Context) (interchange.Format, *time.Location, *models.ErrorResponse) {
+	format := interchange.JSON
+	if name := c.Query("format"); name != "" {
+		var ok bool
+		format, ok = interchange.ParseFormat(name)
+		if !ok {
+			return "", nil, problem.InvalidRequest.New("Format must be one of the following: json, csv, ics, todotxt")
+		}
+	}
+
+	location, errorResponse := timeZone(c)
+	if errorResponse != nil {
+		return "", nil, errorResponse
+	}
+
+	return format, location, nil
+}
+
+// timeZone reads the optional tz query parameter, an IANA time zone name used to interpret dates.
+// It defaults to the server's local time zone.
+func timeZone(c *gin.Context) (*time.Location, *models.ErrorResponse) {
+	tz := c.Query("tz")
+	if tz == "" {
+		return time.Local, nil
+	}
+
+	location, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, problem.InvalidRequest.New("tz must be an IANA time zone name, e.g. Europe/Berlin")
+	}
+
+	return location, nil
+}
+
+// objectIDHex returns the hex form of an inserted ID, or "" if it is not an ObjectID.
+func objectIDHex(id interface{}) string {
+	if objectId, ok := id.(primitive.ObjectID); ok {
+		return objectId.Hex()
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package ToDoItemController
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/L4TTiCe/ToDo-Go/server/interchange"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+func TestCheckRow(t *testing.T) {
+	// "stored" exists in the DB
+	existing := map[string]bool{"stored": true}
+
+	tests := []struct {
+		row     interchange.Row
+		status  string
+		invalid bool
+	}{
+		{row: interchange.Row{Item: models.ToDoItem{Title: "Pay rent", ExternalID: "stored"}}, status: models.ImportDuplicate},
+		{row: interchange.Row{Item: models.ToDoItem{Title: "Call mum", ExternalID: "new"}}, status: models.ImportValid},
+		// Repeated within the import
+		{row: interchange.Row{Item: models.ToDoItem{Title: "Call mum again", ExternalID: "new"}}, status: models.ImportDuplicate},
+		// Items without an external ID cannot be told apart
+		{row: interchange.Row{Item: models.ToDoItem{Title: "Buy milk"}}, status: models.ImportValid},
+		{row: interchange.Row{Item: models.ToDoItem{Title: "Buy milk"}}, status: models.ImportValid},
+		{row: interchange.Row{Err: errors.New("invalid deadline")}, status: models.ImportInvalid, invalid: true},
+		{row: interchange.Row{Item: models.ToDoItem{Title: "Fix bug", Priority: "urgent", ExternalID: "bug"}}, status: models.ImportInvalid, invalid: true},
+		// An invalid row does not claim its external ID
+		{row: interchange.Row{Item: models.ToDoItem{Title: "Fix bug", ExternalID: "bug"}}, status: models.ImportValid},
+		{row: interchange.Row{Item: models.ToDoItem{ExternalID: "untitled"}}, status: models.ImportInvalid, invalid: true},
+	}
+
+	for i, test := range tests {
+		test.row.Number = i + 1
+		result := checkRow(&test.row, existing)
+
+		if result.Row != test.row.Number || result.ExternalID != test.row.Item.ExternalID || result.Status != test.status ||
+			(result.Error != "") != test.invalid {
+			t.Errorf("row %d: checkRow = %+v, want status %s", test.row.Number, result, test.status)
+		}
+	}
+}
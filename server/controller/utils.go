@@ -1,12 +1,22 @@
 package controller
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
 	"github.com/gin-gonic/gin"
 )
 
+// PopulateErrorResponse completes an ErrorResponse before it is sent to the client: it sets the timestamp and
+// path of the request, fills in the problem type and code from the status if they are missing, and marks the
+// response as application/problem+json.
 func PopulateErrorResponse(c *gin.Context, errorResponse *models.ErrorResponse) {
 	scheme := "http"
 	if c.Request.TLS != nil {
@@ -15,4 +25,79 @@ func PopulateErrorResponse(c *gin.Context, errorResponse *models.ErrorResponse)
 
 	errorResponse.Timestamp = time.Now().UnixMilli()
 	errorResponse.Path = c.Request.Method + " " + scheme + "://" + c.Request.Host + c.Request.RequestURI
+
+	if errorResponse.Code == "" {
+		p := problem.ForStatus(errorResponse.Status)
+		errorResponse.Type = problem.TypePrefix + p.Code
+		errorResponse.Code = p.Code
+	}
+
+	// c.JSON keeps a Content-Type that has already been set
+	c.Header("Content-Type", problem.ContentType)
+}
+
+// maxAge is how long clients may use a cacheable response before revalidating it.
+var maxAge time.Duration
+
+// SetCacheMaxAge sets how long clients may use a cacheable response before revalidating it. 0 makes them revalidate it
+// every time.
+func SetCacheMaxAge(d time.Duration) {
+	maxAge = d
+}
+
+// SetCacheControl sets the Cache-Control header of a response that clients may cache for the configured max age.
+func SetCacheControl(c *gin.Context) {
+	// To-dos are private, so only the client's own cache may keep them
+	cacheControl := "private, no-cache"
+	if maxAge > 0 {
+		cacheControl = "private, max-age=" + strconv.FormatInt(int64(maxAge/time.Second), 10)
+	}
+	c.Header("Cache-Control", cacheControl)
+}
+
+// CacheableJSON sends value as JSON with a strong ETag and a Cache-Control header, or 304 Not Modified without a
+// body if the request's If-None-Match already holds the ETag.
+func CacheableJSON(c *gin.Context, status int, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		errorResponse := problem.Internal.New("Error encoding JSON: " + err.Error())
+		PopulateErrorResponse(c, errorResponse)
+
+		c.JSON(errorResponse.Status, errorResponse)
+		return
+	}
+
+	CacheableData(c, status, "application/json; charset=utf-8", body)
+}
+
+// CacheableData is CacheableJSON for a body that has already been encoded.
+func CacheableData(c *gin.Context, status int, contentType string, body []byte) {
+	tag := ETag(body)
+
+	c.Header("ETag", tag)
+	SetCacheControl(c)
+
+	if noneMatch(c.GetHeader("If-None-Match"), tag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(status, contentType, body)
+}
+
+// ETag returns the strong ETag of a representation.
+func ETag(body []byte) string {
+	sum := sha1.Sum(body)
+	return `"` + hex.EncodeToString(sum[:10]) + `"`
+}
+
+// noneMatch reports whether an If-None-Match header matches the ETag, comparing weakly as RFC 9110 requires.
+func noneMatch(header string, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
 }
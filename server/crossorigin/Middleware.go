@@ -0,0 +1,94 @@
+// Package crossorigin lets browsers call the API from pages on the origins the configuration allows (CORS), so that
+// a frontend can be hosted on a domain of its own.
+//
+// Requests from origins that are not allowed are rejected with 403. Rejected preflight requests are logged, as are
+// preflights asking for a method or header that is not allowed, which are answered but which browsers then refuse to
+// follow.
+package crossorigin
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/config"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Middleware applies the CORS policy in settings, always allowing the request headers in allowHeaders and exposing
+// the response headers in exposeHeaders, which the API itself relies on.
+func Middleware(settings *config.CORSConfig, allowHeaders []string, exposeHeaders []string) gin.HandlerFunc {
+	allowed := newOrigins(settings.AllowedOrigins)
+
+	corsConfig := cors.Config{
+		AllowMethods:     settings.AllowedMethods,
+		AllowHeaders:     append(append([]string{}, settings.AllowedHeaders...), allowHeaders...),
+		ExposeHeaders:    append(append([]string{}, settings.ExposedHeaders...), exposeHeaders...),
+		AllowCredentials: settings.AllowCredentials,
+		MaxAge:           time.Duration(settings.MaxAge),
+	}
+	if allowed.any {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOriginFunc = allowed.allows
+	}
+	handle := cors.New(corsConfig)
+
+	p := &policy{origins: allowed, methods: map[string]bool{}, headers: map[string]bool{}}
+	for _, method := range corsConfig.AllowMethods {
+		p.methods[method] = true
+	}
+	for _, header := range corsConfig.AllowHeaders {
+		p.headers[strings.ToLower(header)] = true
+	}
+
+	return func(c *gin.Context) {
+		if reason := p.rejects(c.Request); reason != "" {
+			logging.FromContext(c.Request.Context()).Warn("Rejected CORS preflight",
+				zap.String("origin", c.GetHeader("Origin")),
+				zap.String("requestedMethod", c.GetHeader("Access-Control-Request-Method")),
+				zap.String("requestedHeaders", c.GetHeader("Access-Control-Request-Headers")),
+				zap.String("reason", reason),
+			)
+		}
+
+		handle(c)
+	}
+}
+
+// policy is what the CORS policy allows, for finding out why a preflight is rejected.
+type policy struct {
+	origins *origins
+	methods map[string]bool
+	// headers are in lower case
+	headers map[string]bool
+}
+
+// rejects returns why a preflight request is rejected, or an empty string if it is allowed or not a preflight.
+func (p *policy) rejects(r *http.Request) string {
+	origin := r.Header.Get("Origin")
+	method := r.Header.Get("Access-Control-Request-Method")
+	if r.Method != http.MethodOptions || origin == "" || method == "" {
+		return ""
+	}
+	// Requests from the API's own origin are not cross-origin
+	if origin == "http://"+r.Host || origin == "https://"+r.Host {
+		return ""
+	}
+
+	if !p.origins.allows(origin) {
+		return "origin not allowed"
+	}
+	if !p.methods[method] {
+		return "method not allowed"
+	}
+	for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		if header = strings.ToLower(strings.TrimSpace(header)); header != "" && !p.headers[header] {
+			return "header " + header + " not allowed"
+		}
+	}
+	return ""
+}
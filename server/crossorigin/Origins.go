@@ -0,0 +1,80 @@
+package crossorigin
+
+import (
+	"net/url"
+	"strings"
+)
+
+// origins matches the Origin of requests against the allowed origins.
+type origins struct {
+	any   bool
+	exact map[string]bool
+	// wildcards are the allowed origins with a wildcard subdomain, e.g. https://*.example.com
+	wildcards []wildcard
+}
+
+// wildcard matches the origins with the scheme whose host, with its port, ends in suffix, e.g. .example.com:8443.
+type wildcard struct {
+	scheme string
+	suffix string
+}
+
+func newOrigins(allowed []string) *origins {
+	o := &origins{exact: map[string]bool{}}
+	for _, origin := range allowed {
+		origin = strings.TrimSuffix(strings.ToLower(origin), "/")
+		switch {
+		case origin == "*":
+			o.any = true
+		case strings.Contains(origin, "://*."):
+			scheme, host, _ := strings.Cut(origin, "://*")
+			o.wildcards = append(o.wildcards, wildcard{scheme: scheme, suffix: host})
+		default:
+			o.exact[origin] = true
+		}
+	}
+	return o
+}
+
+// allows reports whether the origin may call the API. A wildcard matches subdomains at any depth, but not the domain
+// itself: https://*.example.com allows https://app.example.com and https://eu.app.example.com, not https://example.com.
+func (o *origins) allows(origin string) bool {
+	if o.any {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if o.exact[origin] {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.User != nil || u.Path != "" || u.RawQuery != "" {
+		return false
+	}
+	for _, w := range o.wildcards {
+		subdomain := strings.TrimSuffix(u.Host, w.suffix)
+		if u.Scheme == w.scheme && subdomain != u.Host && isDomain(subdomain) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDomain reports whether s is made of DNS labels, so that a wildcard cannot match a host with a port or user in it.
+func isDomain(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
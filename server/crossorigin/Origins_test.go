@@ -0,0 +1,83 @@
+package crossorigin
+
+import "testing"
+
+func TestOriginsAllows(t *testing.T) {
+	o := newOrigins([]string{"https://App.Example.com/", "https://*.example.com", "http://*.example.org:8080"})
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{origin: "https://app.example.com", want: true},
+		{origin: "https://APP.EXAMPLE.COM", want: true},
+		{origin: "https://eu.app.example.com", want: true},
+		{origin: "https://a.b.c.example.com", want: true},
+		{origin: "https://Eu.App.Example.COM", want: true},
+		// A wildcard does not match the domain itself
+		{origin: "https://example.com", want: false},
+		{origin: "https://.example.com", want: false},
+		{origin: "https://evilexample.com", want: false},
+		{origin: "https://example.com.evil.com", want: false},
+		{origin: "http://app.example.com", want: false},
+		{origin: "wss://app.example.com", want: false},
+		// The suffix has to be the end of the host, not of the query, path or user
+		{origin: "https://evil.com?x=.example.com", want: false},
+		{origin: "https://evil.com/.example.com", want: false},
+		{origin: "https://evil.com#.example.com", want: false},
+		{origin: "https://user@app.example.com", want: false},
+		{origin: "https://evil.com:@x.example.com", want: false},
+		{origin: "https://x..example.com", want: false},
+		{origin: "https://-x.example.com", want: false},
+		// Ports are part of the origin
+		{origin: "https://app.example.com:8443", want: false},
+		{origin: "http://app.example.org:8080", want: true},
+		{origin: "http://app.example.org", want: false},
+		{origin: "http://app.example.org:9090", want: false},
+		{origin: "http://app.example.org:8080.evil.com", want: false},
+		{origin: "null", want: false},
+		{origin: "", want: false},
+	}
+
+	for _, test := range tests {
+		if got := o.allows(test.origin); got != test.want {
+			t.Errorf("allows(%q) = %t, want %t", test.origin, got, test.want)
+		}
+	}
+}
+
+func TestOriginsAllowsAny(t *testing.T) {
+	o := newOrigins([]string{"https://app.example.com", "*"})
+	for _, origin := range []string{"https://app.example.com", "https://evil.com", "null"} {
+		if !o.allows(origin) {
+			t.Errorf("allows(%q) = false, want true", origin)
+		}
+	}
+}
+
+func TestIsDomain(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{s: "app", want: true},
+		{s: "eu.app", want: true},
+		{s: "my-app.eu1", want: true},
+		{s: "", want: false},
+		{s: "app.", want: false},
+		{s: ".app", want: false},
+		{s: "eu..app", want: false},
+		{s: "-app", want: false},
+		{s: "app-", want: false},
+		{s: "app:8080", want: false},
+		{s: "user@app", want: false},
+		{s: "app_1", want: false},
+		{s: "APP", want: false},
+	}
+
+	for _, test := range tests {
+		if got := isDomain(test.s); got != test.want {
+			t.Errorf("isDomain(%q) = %t, want %t", test.s, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package dao
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is how long a DAO operation may take unless configured otherwise.
+const DefaultTimeout = 10 * time.Second
+
+var (
+	timeoutsMutex sync.RWMutex
+	// defaultTimeout applies to operations without a timeout of their own in operationTimeouts
+	defaultTimeout    = DefaultTimeout
+	operationTimeouts = map[string]time.Duration{}
+)
+
+// SetTimeouts configures how long DAO operations may take: fallback for every operation, unless operations gives the
+// operation a timeout of its own. Operations are named after their DAO and function, e.g. ToDoItemDao.RetrieveAll.
+// A fallback that is not positive leaves DefaultTimeout in place.
+func SetTimeouts(fallback time.Duration, operations map[string]time.Duration) {
+	timeoutsMutex.Lock()
+	defer timeoutsMutex.Unlock()
+
+	defaultTimeout = DefaultTimeout
+	if fallback > 0 {
+		defaultTimeout = fallback
+	}
+	operationTimeouts = map[string]time.Duration{}
+	for operation, timeout := range operations {
+		operationTimeouts[operation] = timeout
+	}
+}
+
+// Timeout returns how long an operation may take.
+func Timeout(operation string) time.Duration {
+	timeoutsMutex.RLock()
+	defer timeoutsMutex.RUnlock()
+
+	if timeout, ok := operationTimeouts[operation]; ok {
+		return timeout
+	}
+	return defaultTimeout
+}
+
+// WithTimeout limits an operation run with the caller's context to the operation's timeout. The operation ends
+// early if the caller's context is cancelled, e.g. because the client went away.
+func WithTimeout(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, Timeout(operation))
+}
+
+// Detach returns a context with the values of ctx, such as its logger and trace, that is not cancelled when ctx is
+// and has no deadline. It is for writes that must finish once started, such as the second of two writes that belong
+// together, even if the client goes away.
+func Detach(ctx context.Context) context.Context {
+	return detached{ctx}
+}
+
+type detached struct {
+	parent context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detached) Done() <-chan struct{}       { return nil }
+func (detached) Err() error                  { return nil }
+
+func (d detached) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
@@ -0,0 +1,92 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Kinds of errors returned by the DAOs. Every error a DAO returns either wraps one of them, and can be tested for
+// with errors.Is, or is an unexpected internal error.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrInvalidID   = errors.New("invalid ID")
+	ErrValidation  = errors.New("validation failed")
+	ErrConflict    = errors.New("conflict")
+	ErrUnavailable = errors.New("database unavailable")
+	ErrTimeout     = errors.New("timed out")
+	ErrCanceled    = errors.New("canceled")
+)
+
+// Error is an error returned by a DAO.
+// Detail and Violations describe the problem and are safe to show to clients; Err is the underlying cause, such as
+// a driver error, and is not.
+type Error struct {
+	Kind       error
+	Detail     string
+	Violations []models.Violation
+	Err        error
+}
+
+func (e *Error) Error() string {
+	message := e.Kind.Error()
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	return message
+}
+
+// Is reports whether the error is of the given kind.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NotFound returns an ErrNotFound error.
+func NotFound(detail string) error {
+	return &Error{Kind: ErrNotFound, Detail: detail}
+}
+
+// InvalidID returns an ErrInvalidID error for an ID that is not an ObjectID.
+func InvalidID(id string) error {
+	return &Error{Kind: ErrInvalidID, Detail: "\"" + id + "\" is not a valid ID"}
+}
+
+// Validation returns an ErrValidation error, optionally listing the offending fields.
+func Validation(detail string, violations ...models.Violation) error {
+	return &Error{Kind: ErrValidation, Detail: detail, Violations: violations}
+}
+
+// Conflict returns an ErrConflict error.
+func Conflict(detail string) error {
+	return &Error{Kind: ErrConflict, Detail: detail}
+}
+
+// Database classifies an error returned by the MongoDB driver. Duplicate keys are conflicts, operations cut short
+// by their caller are canceled, operations that ran out of time timed out, and network errors mean the database is
+// unavailable; anything else is returned unchanged as an internal error.
+// mongo.ErrNoDocuments must be handled by the caller, which knows what was not found.
+func Database(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case mongo.IsDuplicateKeyError(err):
+		return &Error{Kind: ErrConflict, Detail: "An item with the same key already exists", Err: err}
+	// Checked first, since the driver reports a cancelled operation as a network error
+	case errors.Is(err, context.Canceled):
+		return &Error{Kind: ErrCanceled, Detail: "The request was cancelled", Err: err}
+	case mongo.IsTimeout(err), errors.Is(err, context.DeadlineExceeded):
+		return &Error{Kind: ErrTimeout, Detail: "The database did not respond in time", Err: err}
+	case mongo.IsNetworkError(err), errors.Is(err, mongo.ErrClientDisconnected):
+		return &Error{Kind: ErrUnavailable, Detail: "The database cannot be reached", Err: err}
+	}
+	return err
+}
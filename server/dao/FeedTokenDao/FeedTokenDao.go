@@ -0,0 +1,154 @@
+package FeedTokenDao
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/config"
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/metrics"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// Create creates a new feed token for a user in the DB.
+// It returns the FeedToken, including the secret Token which cannot be retrieved again, or an error.
+func Create(ctx context.Context, user string) (_ *models.FeedToken, err error) {
+	defer metrics.ObserveDAO("FeedTokenDao", "Create", time.Now(), &err)
+
+	logging.FromContext(ctx).Info("FeedToken: Create", zap.String("user", user))
+
+	// Check if User is empty
+	if user == "" {
+		return nil, dao.Validation("One or more fields are invalid", models.Violation{Pointer: "/user", Message: "is required"})
+	}
+
+	// Generate a random 256-bit secret
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		logging.FromContext(ctx).Error("FeedToken: Create failed", zap.Error(err))
+		return nil, err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(secret)
+	feedToken := &models.FeedToken{
+		User:      user,
+		Hash:      hash(token),
+		CreatedAt: time.Now().UnixMilli(),
+	}
+
+	ctx, cancel := dao.WithTimeout(ctx, "FeedTokenDao.Create")
+	defer cancel()
+
+	result, err := config.FeedTokensCollection.InsertOne(ctx, feedToken)
+	if err != nil {
+		logging.FromContext(ctx).Error("FeedToken: Create failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+
+	feedToken.ID = result.InsertedID.(primitive.ObjectID)
+	feedToken.Token = token
+	return feedToken, nil
+}
+
+// RetrieveAll retrieves the feed tokens of a user, revoked ones included. Secrets are not included.
+func RetrieveAll(ctx context.Context, user string) (_ []models.FeedToken, err error) {
+	defer metrics.ObserveDAO("FeedTokenDao", "RetrieveAll", time.Now(), &err)
+
+	logging.FromContext(ctx).Info("FeedToken: RetrieveAll", zap.String("user", user))
+
+	filter := bson.M{"user": user}
+
+	ctx, cancel := dao.WithTimeout(ctx, "FeedTokenDao.RetrieveAll")
+	defer cancel()
+
+	cursor, err := config.FeedTokensCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		logging.FromContext(ctx).Error("FeedToken: RetrieveAll failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+
+	tokens := []models.FeedToken{}
+	if err = cursor.All(ctx, &tokens); err != nil {
+		logging.FromContext(ctx).Error("FeedToken: RetrieveAll failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+
+	return tokens, nil
+}
+
+// Authenticate retrieves the unrevoked feed token matching a secret.
+// It returns the FeedToken or an ErrNotFound error if the secret is missing, unknown or revoked.
+func Authenticate(ctx context.Context, token string) (_ *models.FeedToken, err error) {
+	defer metrics.ObserveDAO("FeedTokenDao", "Authenticate", time.Now(), &err)
+
+	unauthorized := dao.NotFound("The feed token is missing, unknown or has been revoked")
+	if token == "" {
+		return nil, unauthorized
+	}
+
+	ctx, cancel := dao.WithTimeout(ctx, "FeedTokenDao.Authenticate")
+	defer cancel()
+
+	feedToken := models.FeedToken{}
+	filter := bson.M{"hash": hash(token), "revokedAt": bson.M{"$exists": false}}
+	err = config.FeedTokensCollection.FindOne(ctx, filter).Decode(&feedToken)
+	if err == mongo.ErrNoDocuments {
+		return nil, unauthorized
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("FeedToken: Authenticate failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+
+	return &feedToken, nil
+}
+
+// Revoke revokes a feed token of a user, so that it can no longer be used to read the feed.
+// It returns the revoked FeedToken, or an ErrNotFound error if the user has no token with the ID.
+func Revoke(ctx context.Context, id string, user string) (_ *models.FeedToken, err error) {
+	defer metrics.ObserveDAO("FeedTokenDao", "Revoke", time.Now(), &err)
+
+	logging.FromContext(ctx).Info("FeedToken: Revoke", zap.String("id", id), zap.String("user", user))
+
+	// convert id string to ObjectId
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, dao.InvalidID(id)
+	}
+
+	ctx, cancel := dao.WithTimeout(ctx, "FeedTokenDao.Revoke")
+	defer cancel()
+
+	// Revoking an already revoked token keeps its original RevokedAt
+	update := bson.M{"$min": bson.M{"revokedAt": time.Now().UnixMilli()}}
+	after := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	feedToken := models.FeedToken{}
+	err = config.FeedTokensCollection.FindOneAndUpdate(ctx, bson.M{"_id": objectId, "user": user}, update, after).Decode(&feedToken)
+	if err == mongo.ErrNoDocuments {
+		return nil, dao.NotFound("Feed token with ID " + id + " not found")
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("FeedToken: Revoke failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+
+	return &feedToken, nil
+}
+
+// hash returns the SHA-256 hash of a token, which is what is stored in the DB.
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,86 @@
+package IdempotencyDao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/config"
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/metrics"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// Begin claims a key for a request, storing a record that is not yet completed.
+// If the key is already claimed, it returns the existing record and an ErrConflict error. Expired records that
+// MongoDB has not deleted yet are replaced.
+func Begin(ctx context.Context, record *models.IdempotencyRecord) (_ *models.IdempotencyRecord, err error) {
+	defer metrics.ObserveDAO("IdempotencyDao", "Begin", time.Now(), &err)
+
+	ctx, cancel := dao.WithTimeout(ctx, "IdempotencyDao.Begin")
+	defer cancel()
+
+	// An expired record is claimed by replacing it; any other record makes the upsert insert a duplicate _id
+	filter := bson.M{"_id": record.Key, "expiresAt": bson.M{"$lte": time.Now()}}
+	_, err = config.IdempotencyKeysCollection.ReplaceOne(ctx, filter, record, options.Replace().SetUpsert(true))
+	if err == nil {
+		return nil, nil
+	}
+
+	err = dao.Database(err)
+	if !errors.Is(err, dao.ErrConflict) {
+		logging.FromContext(ctx).Error("Idempotency: Begin failed", zap.Error(err))
+		return nil, err
+	}
+
+	existing := models.IdempotencyRecord{}
+	if err := config.IdempotencyKeysCollection.FindOne(ctx, bson.M{"_id": record.Key}).Decode(&existing); err != nil {
+		if err == mongo.ErrNoDocuments {
+			// The record expired in the meantime
+			return Begin(ctx, record)
+		}
+		logging.FromContext(ctx).Error("Idempotency: Begin failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+
+	return &existing, dao.Conflict("Idempotency key " + record.Key + " is already in use")
+}
+
+// Complete stores the response to the request that claimed a key.
+func Complete(ctx context.Context, record *models.IdempotencyRecord) (err error) {
+	defer metrics.ObserveDAO("IdempotencyDao", "Complete", time.Now(), &err)
+
+	ctx, cancel := dao.WithTimeout(ctx, "IdempotencyDao.Complete")
+	defer cancel()
+
+	record.Completed = true
+	_, err = config.IdempotencyKeysCollection.ReplaceOne(ctx, bson.M{"_id": record.Key}, record)
+	if err != nil {
+		logging.FromContext(ctx).Error("Idempotency: Complete failed", zap.Error(err))
+		return dao.Database(err)
+	}
+
+	return nil
+}
+
+// Release deletes the record of a key, so that the request can be retried.
+func Release(ctx context.Context, key string) (err error) {
+	defer metrics.ObserveDAO("IdempotencyDao", "Release", time.Now(), &err)
+
+	ctx, cancel := dao.WithTimeout(ctx, "IdempotencyDao.Release")
+	defer cancel()
+
+	_, err = config.IdempotencyKeysCollection.DeleteOne(ctx, bson.M{"_id": key})
+	if err != nil {
+		logging.FromContext(ctx).Error("Idempotency: Release failed", zap.Error(err))
+		return dao.Database(err)
+	}
+
+	return nil
+}
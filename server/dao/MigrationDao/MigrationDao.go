@@ -0,0 +1,110 @@
+// Package MigrationDao records the migrations of the DB that have been applied, and holds the lock that lets only one
+// instance of the server apply them at a time.
+package MigrationDao
+
+import (
+	"context"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/config"
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/metrics"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// lockID is the ID of the lock in the Migrations collection, whose records have numeric IDs.
+const lockID = "lock"
+
+// RetrieveApplied retrieves the records of the migrations that have been applied, by version.
+func RetrieveApplied(ctx context.Context) (_ map[int]models.Migration, err error) {
+	defer metrics.ObserveDAO("MigrationDao", "RetrieveApplied", time.Now(), &err)
+
+	ctx, cancel := dao.WithTimeout(ctx, "MigrationDao.RetrieveApplied")
+	defer cancel()
+
+	cursor, err := config.MigrationsCollection.Find(ctx, bson.M{"_id": bson.M{"$type": "number"}})
+	if err != nil {
+		logging.FromContext(ctx).Error("Migration: RetrieveApplied failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+
+	var migrations []models.Migration
+	if err = cursor.All(ctx, &migrations); err != nil {
+		logging.FromContext(ctx).Error("Migration: RetrieveApplied failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+
+	applied := make(map[int]models.Migration, len(migrations))
+	for _, migration := range migrations {
+		applied[migration.Version] = migration
+	}
+	return applied, nil
+}
+
+// Record records that a migration has been applied, setting AppliedAt to the current server time.
+func Record(ctx context.Context, migration *models.Migration) (err error) {
+	defer metrics.ObserveDAO("MigrationDao", "Record", time.Now(), &err)
+
+	migration.AppliedAt = time.Now().UnixMilli()
+
+	ctx, cancel := dao.WithTimeout(ctx, "MigrationDao.Record")
+	defer cancel()
+
+	_, err = config.MigrationsCollection.ReplaceOne(ctx, bson.M{"_id": migration.Version}, migration, options.Replace().SetUpsert(true))
+	if err != nil {
+		logging.FromContext(ctx).Error("Migration: Record failed", zap.Error(err))
+		return dao.Database(err)
+	}
+
+	return nil
+}
+
+// Lock takes the lock for owner, or extends it if owner holds it already, until lease has passed. It reports whether
+// owner holds the lock; it does not if another owner holds it and its lease has not passed yet.
+func Lock(ctx context.Context, owner string, lease time.Duration) (_ bool, err error) {
+	defer metrics.ObserveDAO("MigrationDao", "Lock", time.Now(), &err)
+
+	ctx, cancel := dao.WithTimeout(ctx, "MigrationDao.Lock")
+	defer cancel()
+
+	// If another owner holds the lock, the filter matches nothing and the upsert fails on the duplicate ID
+	now := time.Now()
+	filter := bson.M{"_id": lockID, "$or": bson.A{
+		bson.M{"owner": owner},
+		bson.M{"expiresAt": bson.M{"$lt": now.UnixMilli()}},
+	}}
+	update := bson.M{"$set": bson.M{"owner": owner, "expiresAt": now.Add(lease).UnixMilli()}}
+
+	_, err = config.MigrationsCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Migration: Lock failed", zap.Error(err))
+		return false, dao.Database(err)
+	}
+
+	return true, nil
+}
+
+// Unlock releases the lock if owner holds it.
+func Unlock(ctx context.Context, owner string) (err error) {
+	defer metrics.ObserveDAO("MigrationDao", "Unlock", time.Now(), &err)
+
+	ctx, cancel := dao.WithTimeout(ctx, "MigrationDao.Unlock")
+	defer cancel()
+
+	_, err = config.MigrationsCollection.DeleteOne(ctx, bson.M{"_id": lockID, "owner": owner})
+	if err != nil {
+		logging.FromContext(ctx).Error("Migration: Unlock failed", zap.Error(err))
+		return dao.Database(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+// Package SequenceDao numbers every write to a ToDoItem, including deletes, in a single monotonic change sequence,
+// so that clients can ask for the changes after the last one they have seen.
+package SequenceDao
+
+import (
+	"context"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/config"
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/metrics"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// counterID is the ID of the counter of the change sequence in the Counters collection.
+const counterID = "changes"
+
+// pendingLease is how long a number stays pending unless its write finishes first. It is far longer than a write may
+// take, so that only the numbers of servers that died while writing expire.
+const pendingLease = 5 * time.Minute
+
+// counter is a document of the Counters collection.
+type counter struct {
+	ID    string `bson:"_id"`
+	Value int64  `bson:"value"`
+	// Pending are the numbers allocated whose writes have not finished yet. Numbers are allocated before the write
+	// that records them, so a write can become visible after a write with a higher number; Stable does not report
+	// past a pending number, whichever server allocated it.
+	Pending []pendingNumber `bson:"pending"`
+}
+
+type pendingNumber struct {
+	Number    int64 `bson:"number"`
+	ExpiresAt int64 `bson:"expiresAt"`
+}
+
+// Next allocates the next number of the change sequence. done must be called once the write recording it has
+// finished or failed.
+func Next(ctx context.Context) (_ int64, _ func(), err error) {
+	defer metrics.ObserveDAO("SequenceDao", "Next", time.Now(), &err)
+
+	ctx, cancel := dao.WithTimeout(ctx, "SequenceDao.Next")
+	defer cancel()
+
+	// The counter is incremented and its new value marked as pending in the same update, so that Stable cannot see
+	// one without the other. Numbers whose lease has passed are dropped meanwhile
+	now := time.Now()
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: "value", Value: bson.D{{Key: "$add", Value: bson.A{bson.D{{Key: "$ifNull", Value: bson.A{"$value", 0}}}, 1}}}},
+			{Key: "pending", Value: bson.D{{Key: "$filter", Value: bson.D{
+				{Key: "input", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$pending", bson.A{}}}}},
+				{Key: "cond", Value: bson.D{{Key: "$gt", Value: bson.A{"$$this.expiresAt", now.UnixMilli()}}}},
+			}}}},
+		}}},
+		{{Key: "$set", Value: bson.D{
+			{Key: "pending", Value: bson.D{{Key: "$concatArrays", Value: bson.A{
+				"$pending",
+				bson.A{bson.D{{Key: "number", Value: "$value"}, {Key: "expiresAt", Value: now.Add(pendingLease).UnixMilli()}}},
+			}}}},
+		}}},
+	}
+
+	result := counter{}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After).SetProjection(bson.M{"value": 1})
+	err = config.CountersCollection.FindOneAndUpdate(ctx, bson.M{"_id": counterID}, update, opts).Decode(&result)
+	if err != nil {
+		logging.FromContext(ctx).Error("Sequence: Next failed", zap.Error(err))
+		return 0, nil, dao.Database(err)
+	}
+
+	// The write may have outlived the caller, and the number must not stay pending until its lease passes
+	detached := dao.Detach(ctx)
+	done := func() {
+		finish(detached, result.Value)
+	}
+
+	return result.Value, done, nil
+}
+
+// finish marks the write recording a number as finished.
+func finish(ctx context.Context, number int64) {
+	var err error
+	defer metrics.ObserveDAO("SequenceDao", "finish", time.Now(), &err)
+
+	ctx, cancel := dao.WithTimeout(ctx, "SequenceDao.finish")
+	defer cancel()
+
+	// A number left pending only holds Stable back until its lease passes
+	_, err = config.CountersCollection.UpdateOne(ctx, bson.M{"_id": counterID}, bson.M{"$pull": bson.M{"pending": bson.M{"number": number}}})
+	if err != nil {
+		logging.FromContext(ctx).Error("Sequence: finish failed", zap.Int64("number", number), zap.Error(err))
+	}
+}
+
+// Stable returns the highest number of the change sequence up to which every write has finished, or 0 if nothing
+// has been written. Writes from every server are waited for, except those pending for longer than their lease.
+func Stable(ctx context.Context) (_ int64, err error) {
+	defer metrics.ObserveDAO("SequenceDao", "Stable", time.Now(), &err)
+
+	ctx, cancel := dao.WithTimeout(ctx, "SequenceDao.Stable")
+	defer cancel()
+
+	result := counter{}
+	err = config.CountersCollection.FindOne(ctx, bson.M{"_id": counterID}).Decode(&result)
+	if err != nil && err != mongo.ErrNoDocuments {
+		logging.FromContext(ctx).Error("Sequence: Stable failed", zap.Error(err))
+		return 0, dao.Database(err)
+	}
+
+	now := time.Now().UnixMilli()
+	stable := result.Value
+	for _, pending := range result.Pending {
+		if pending.ExpiresAt > now && pending.Number <= stable {
+			stable = pending.Number - 1
+		}
+	}
+
+	return stable, nil
+}
@@ -0,0 +1,183 @@
+package ToDoItemDao
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/cache"
+	"github.com/L4TTiCe/ToDo-Go/server/metrics"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+// maxCachedListLength is the length of the longest list cached, so that streaming a long list does not keep it in
+// memory after all.
+const maxCachedListLength = 1000
+
+// The results of RetrieveOne and of the list queries, see List, are cached for a short time, unless a list is longer
+// than maxCachedListLength. Every write through this package removes the entries it may have changed: those of the
+// item it writes, and every list, since a write can change which items a list holds and their order. Writes through
+// other instances of the server are only seen once the entries expire.
+//
+// Items do not belong to users, and every user gets the same results, so entries are shared between users.
+var (
+	// items and lists are nil while caching them is disabled
+	items *cache.LRU
+	lists *cache.LRU
+
+	// cacheMutex orders storing entries after invalidating them
+	cacheMutex sync.Mutex
+	// generation counts the invalidations, so that a read that raced a write does not store what it read before it
+	generation uint64
+)
+
+// ConfigureCache caches up to itemCount items and listCount lists, each for ttl. A count that is not positive
+// disables caching them. It must be called before the DAO is used.
+func ConfigureCache(itemCount int, listCount int, ttl time.Duration) {
+	items, lists = nil, nil
+	if itemCount > 0 {
+		items = cache.NewLRU(itemCount, ttl)
+	}
+	if listCount > 0 {
+		lists = cache.NewLRU(listCount, ttl)
+	}
+}
+
+// cacheGeneration returns the current generation, to be passed to cacheItem or cacheList when the read is done.
+func cacheGeneration() uint64 {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	return generation
+}
+
+// cachedItem returns a copy of the cached item with the ID, if there is one.
+func cachedItem(id string) (*models.ToDoItem, bool) {
+	if items == nil {
+		return nil, false
+	}
+
+	value, ok := items.Get(id)
+	metrics.ObserveCacheLookup("items", ok)
+	if !ok {
+		return nil, false
+	}
+	item := cloneItem(*value.(*models.ToDoItem))
+	return &item, true
+}
+
+// cacheItem stores a copy of an item read in the generation, unless it has been written since.
+func cacheItem(id string, item *models.ToDoItem, readIn uint64) {
+	if items == nil {
+		return
+	}
+
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	if readIn != generation {
+		return
+	}
+	stored := cloneItem(*item)
+	if items.Set(id, &stored) {
+		metrics.ObserveCacheEviction("items")
+	}
+}
+
+// listKey identifies a list query by its name and parameters.
+func listKey(query string, params ...interface{}) string {
+	key := query
+	for _, param := range params {
+		key += "|" + fmt.Sprint(param)
+	}
+	return key
+}
+
+// cachedList returns a copy of the cached result of the list query, if there is one.
+func cachedList(key string) ([]models.ToDoItem, bool) {
+	if lists == nil {
+		return nil, false
+	}
+
+	value, ok := lists.Get(key)
+	metrics.ObserveCacheLookup("lists", ok)
+	if !ok {
+		return nil, false
+	}
+	return cloneItems(value.([]models.ToDoItem)), true
+}
+
+// cacheList stores a copy of the result of a list query read in the generation, unless an item has been written
+// since.
+func cacheList(key string, result []models.ToDoItem, readIn uint64) {
+	if lists == nil {
+		return
+	}
+
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	if readIn != generation {
+		return
+	}
+	if lists.Set(key, cloneItems(result)) {
+		metrics.ObserveCacheEviction("lists")
+	}
+}
+
+// invalidate removes the cached items with the IDs, in hex, and every cached list, after a write. It is meant to be
+// deferred until the write is done, whether it succeeded or not, since a write that failed may still have been applied.
+func invalidate(ids ...string) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	generation++
+	if items != nil {
+		for _, id := range ids {
+			items.Delete(id)
+		}
+	}
+	if lists != nil {
+		lists.Clear()
+	}
+}
+
+// invalidateAll removes every cached item and list, after a write to many items.
+func invalidateAll() {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	generation++
+	if items != nil {
+		items.Clear()
+	}
+	if lists != nil {
+		lists.Clear()
+	}
+}
+
+// cloneItem copies an item, including its slices and maps, so that callers cannot change the cached copy.
+func cloneItem(item models.ToDoItem) models.ToDoItem {
+	if item.Tags != nil {
+		item.Tags = append([]string{}, item.Tags...)
+	}
+	if item.Versions != nil {
+		versions := make(map[string]int64, len(item.Versions))
+		for field, version := range item.Versions {
+			versions[field] = version
+		}
+		item.Versions = versions
+	}
+	return item
+}
+
+func cloneItems(list []models.ToDoItem) []models.ToDoItem {
+	if list == nil {
+		return nil
+	}
+	clones := make([]models.ToDoItem, len(list))
+	for i := range list {
+		clones[i] = cloneItem(list[i])
+	}
+	return clones
+}
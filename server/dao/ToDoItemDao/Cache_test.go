@@ -0,0 +1,82 @@
+package ToDoItemDao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+func TestCacheGeneration(t *testing.T) {
+	ConfigureCache(10, 10, time.Hour)
+	defer ConfigureCache(0, 0, 0)
+
+	tests := []struct {
+		name string
+		// write runs between the read and storing what it read
+		write  func()
+		cached bool
+	}{
+		{name: "no write", write: func() {}, cached: true},
+		{name: "write to the item", write: func() { invalidate("a") }},
+		// Any write can change the lists, and makes the item read before it too old to store
+		{name: "write to another item", write: func() { invalidate("b") }},
+		{name: "write to many items", write: invalidateAll},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			invalidateAll()
+
+			readIn := cacheGeneration()
+			test.write()
+			cacheItem("a", &models.ToDoItem{Title: "Pay rent"}, readIn)
+			cacheList("all", []models.ToDoItem{{Title: "Pay rent"}}, readIn)
+
+			if _, ok := cachedItem("a"); ok != test.cached {
+				t.Errorf("item cached = %t, want %t", ok, test.cached)
+			}
+			if _, ok := cachedList("all"); ok != test.cached {
+				t.Errorf("list cached = %t, want %t", ok, test.cached)
+			}
+		})
+	}
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	ConfigureCache(10, 10, time.Hour)
+	defer ConfigureCache(0, 0, 0)
+
+	readIn := cacheGeneration()
+	cacheItem("a", &models.ToDoItem{Title: "Pay rent"}, readIn)
+	cacheItem("b", &models.ToDoItem{Title: "Call mum"}, readIn)
+	cacheList("all", []models.ToDoItem{{Title: "Pay rent"}, {Title: "Call mum"}}, readIn)
+
+	// A write removes its item and every list, and keeps the other items
+	invalidate("a")
+	if _, ok := cachedItem("a"); ok {
+		t.Error("written item is still cached")
+	}
+	if _, ok := cachedItem("b"); !ok {
+		t.Error("other item is no longer cached")
+	}
+	if _, ok := cachedList("all"); ok {
+		t.Error("list is still cached")
+	}
+}
+
+func TestCacheCopies(t *testing.T) {
+	ConfigureCache(10, 10, time.Hour)
+	defer ConfigureCache(0, 0, 0)
+
+	item := &models.ToDoItem{Title: "Pay rent", Tags: []string{"home"}}
+	cacheItem("a", item, cacheGeneration())
+	item.Tags[0] = "changed"
+
+	cached, _ := cachedItem("a")
+	cached.Tags[0] = "changed"
+
+	if again, _ := cachedItem("a"); again.Tags[0] != "home" {
+		t.Errorf("cached tags = %v, want [home]", again.Tags)
+	}
+}
@@ -0,0 +1,309 @@
+package ToDoItemDao
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/config"
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/metrics"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// List is a validated query for a list of ToDoItems. Its items can be retrieved at once with Retrieve, or streamed
+// from the cursor with Stream, so that large lists are never held in memory.
+type List struct {
+	// operation names the query in metrics, logs and timeouts, e.g. RetrieveAll
+	operation string
+	fields    []zap.Field
+
+	key    string
+	filter bson.D
+	sort   bson.D
+}
+
+// ListAll returns the query for every ToDoItem, sorted by sortParam in sortOrder, 1 or -1.
+func ListAll(sortParam string, sortOrder int) (*List, error) {
+	// Validate sortParam
+	if sortParam != "" && sortParam != "title" && sortParam != "completed" && sortParam != "createdAt" && sortParam != "deadline" {
+		return nil, dao.Validation("Sort parameter must be one of the following: title, completed, createdAt, deadline")
+	}
+
+	// Validate sortOrder
+	if sortOrder != 1 && sortOrder != -1 {
+		return nil, dao.Validation("Sort parameter must be one of the following: asc, desc, 1, -1")
+	}
+
+	return &List{
+		operation: "RetrieveAll",
+		fields:    []zap.Field{zap.String("sortParam", sortParam), zap.Int("sortOrder", sortOrder)},
+		key:       listKey("all", sortParam, sortOrder),
+		filter:    bson.D{},
+		// Note: bson.D{} preserves order and is ideal for specifing sort ordering
+		sort: bson.D{{Key: sortParam, Value: sortOrder}},
+	}, nil
+}
+
+// ListWithParams returns the query for the ToDoItems whose attrib, createdAt or deadline, is before (lte) or after
+// (gte) date, sorted by attrib in sortOrder, 1 or -1.
+func ListWithParams(attrib string, verb string, date int64, sortOrder int) (*List, error) {
+	// Validate attrib
+	if attrib != "createdAt" && attrib != "deadline" {
+		return nil, dao.Validation("Attribute must be one of the following: createdAt, deadline")
+	}
+
+	// Validate verb
+	if verb != "gte" && verb != "lte" {
+		return nil, dao.Validation("Verb must be one of the following: gte, lte")
+	}
+
+	// Validate date
+	if date < 0 {
+		return nil, dao.Validation("Date must be a positive integer")
+	}
+
+	// Validate sortOrder
+	if sortOrder != 1 && sortOrder != -1 {
+		return nil, dao.Validation("Sort parameter must be one of the following: asc, desc, 1, -1")
+	}
+
+	return &List{
+		operation: "RetrieveWithParams",
+		fields:    []zap.Field{zap.String("attrib", attrib), zap.String("verb", verb), zap.Int64("date", date)},
+		key:       listKey("params", attrib, verb, date, sortOrder),
+		filter:    bson.D{{Key: attrib, Value: bson.D{{Key: "$" + verb, Value: date}}}},
+		sort:      bson.D{{Key: attrib, Value: sortOrder}},
+	}, nil
+}
+
+// ListBetween returns the query for the ToDoItems whose attrib, createdAt or deadline, is between start and end,
+// inclusive, sorted by attrib in sortOrder, 1 or -1.
+func ListBetween(attrib string, start int64, end int64, sortOrder int) (*List, error) {
+	// Validate attrib
+	if attrib != "createdAt" && attrib != "deadline" {
+		return nil, dao.Validation("Attribute must be one of the following: createdAt, deadline")
+	}
+
+	// Validate dates
+	if start < 0 || end < 0 {
+		return nil, dao.Validation("Date must be a positive integer. Check that start and end dates are positive integers")
+	}
+
+	if start > end {
+		return nil, dao.Validation("Start date must be less than end date")
+	}
+
+	// Validate sortOrder
+	if sortOrder != 1 && sortOrder != -1 {
+		return nil, dao.Validation("Sort parameter must be one of the following: asc, desc, 1, -1")
+	}
+
+	return &List{
+		operation: "RetrieveBetween",
+		fields:    []zap.Field{zap.String("attrib", attrib), zap.Int64("start", start), zap.Int64("end", end)},
+		key:       listKey("between", attrib, start, end, sortOrder),
+		filter:    bson.D{{Key: attrib, Value: bson.D{{Key: "$gte", Value: start}, {Key: "$lte", Value: end}}}},
+		sort:      bson.D{{Key: attrib, Value: sortOrder}},
+	}, nil
+}
+
+// Where narrows the list to the items that are completed, or not, if completed is given, and that are in the list
+// named name and have the tag, unless they are empty.
+func (list *List) Where(completed *bool, name string, tag string) *List {
+	narrowed := *list
+	narrowed.filter = append(bson.D{}, list.filter...)
+	narrowed.fields = append([]zap.Field{}, list.fields...)
+
+	state := "any"
+	if completed != nil {
+		state = strconv.FormatBool(*completed)
+		narrowed.filter = append(narrowed.filter, bson.E{Key: "completed", Value: *completed})
+		narrowed.fields = append(narrowed.fields, zap.Bool("completed", *completed))
+	}
+	if name != "" {
+		narrowed.filter = append(narrowed.filter, bson.E{Key: "list", Value: name})
+		narrowed.fields = append(narrowed.fields, zap.String("list", name))
+	}
+	if tag != "" {
+		narrowed.filter = append(narrowed.filter, bson.E{Key: "tags", Value: tag})
+		narrowed.fields = append(narrowed.fields, zap.String("tag", tag))
+	}
+
+	narrowed.key = listKey(list.key, "where", state, name, tag)
+	return &narrowed
+}
+
+// DueWithin narrows the list to the items without a deadline and those due from start, inclusive, until end,
+// exclusive, as a CalDAV time-range does. A bound of 0 is open.
+func (list *List) DueWithin(start int64, end int64) *List {
+	narrowed := *list
+	narrowed.filter = append(bson.D{}, list.filter...)
+	narrowed.fields = append([]zap.Field{}, list.fields...)
+
+	due := bson.D{}
+	if start != 0 {
+		due = append(due, bson.E{Key: "$gte", Value: start})
+		narrowed.fields = append(narrowed.fields, zap.Int64("start", start))
+	}
+	if end != 0 {
+		due = append(due, bson.E{Key: "$lt", Value: end})
+		narrowed.fields = append(narrowed.fields, zap.Int64("end", end))
+	}
+	if len(due) > 0 {
+		// Items without a deadline have none stored, or 0
+		narrowed.filter = append(narrowed.filter, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "deadline", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$gt", Value: 0}}}}}},
+			bson.D{{Key: "deadline", Value: due}},
+		}})
+	}
+
+	narrowed.key = listKey(list.key, "due", start, end)
+	return &narrowed
+}
+
+// Cached returns the items of the list if they are cached.
+func (list *List) Cached() ([]models.ToDoItem, bool) {
+	return cachedList(list.key)
+}
+
+// Retrieve returns the items of the list, from the cache if they are there.
+func (list *List) Retrieve(ctx context.Context) (_ []models.ToDoItem, err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", list.operation, time.Now(), &err)
+
+	if items, ok := list.Cached(); ok {
+		return items, nil
+	}
+
+	var items []models.ToDoItem
+	err = list.stream(ctx, func(item *models.ToDoItem) error {
+		items = append(items, *item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Stream reads the items of the list from the database, passing each to visit as soon as it is read. Callers that
+// can use a cached list check Cached first. An error returned by visit ends the stream and is returned as it is.
+func (list *List) Stream(ctx context.Context, visit func(item *models.ToDoItem) error) (err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", list.operation, time.Now(), &err)
+
+	return list.stream(ctx, visit)
+}
+
+// stream reads the list with the cursor of a find. The operation's timeout applies to the find and to each read
+// from the cursor, not to visit, so that slow clients and large lists can be streamed for as long as they need.
+func (list *List) stream(ctx context.Context, visit func(item *models.ToDoItem) error) error {
+	logging.FromContext(ctx).Info("ToDo: "+list.operation, list.fields...)
+
+	readIn := cacheGeneration()
+
+	findCtx, cancel := dao.WithTimeout(ctx, "ToDoItemDao."+list.operation)
+	cursor, err := config.ToDoItemsCollection.Find(findCtx, list.filter, options.Find().SetSort(list.sort))
+	cancel()
+	if err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: "+list.operation+" failed", zap.Error(err))
+		return dao.Database(err)
+	}
+	// The cursor stays open on the server if the stream ends early, unless it is closed
+	defer cursor.Close(dao.Detach(ctx))
+
+	return list.read(ctx, cursor, readIn, visit)
+}
+
+// itemCursor is the part of a mongo.Cursor that lists are read with.
+type itemCursor interface {
+	Next(ctx context.Context) bool
+	Decode(value interface{}) error
+	Err() error
+}
+
+// read passes each item of the cursor to visit. Each call to Next, which fetches the next batch from the database
+// when the current one is used up, is limited to the operation's timeout.
+func (list *List) read(ctx context.Context, cursor itemCursor, readIn uint64, visit func(item *models.ToDoItem) error) error {
+	// Lists short enough to cache are kept while they are streamed, and cached once they have been read in full
+	cacheable := lists != nil
+	var cached []models.ToDoItem
+
+	for {
+		nextCtx, cancel := dao.WithTimeout(ctx, "ToDoItemDao."+list.operation)
+		next := cursor.Next(nextCtx)
+		cancel()
+		if !next {
+			break
+		}
+
+		item := models.ToDoItem{}
+		if err := cursor.Decode(&item); err != nil {
+			logging.FromContext(ctx).Error("ToDoItem: "+list.operation+" failed", zap.Error(err))
+			return dao.Database(err)
+		}
+
+		if cacheable && len(cached) == maxCachedListLength {
+			cacheable, cached = false, nil
+		}
+		if cacheable {
+			cached = append(cached, item)
+		}
+
+		if err := visit(&item); err != nil {
+			return err
+		}
+	}
+	// The loop also ends when the cursor fails, e.g. because the caller went away
+	if err := cursor.Err(); err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: "+list.operation+" failed", zap.Error(err))
+		return dao.Database(err)
+	}
+
+	if cacheable {
+		cacheList(list.key, cached, readIn)
+	}
+	return nil
+}
+
+// Page retrieves up to limit items of the list, skipping the first offset of them, and counts the items of the whole
+// list. Pages are read from the database, never from the cache.
+func (list *List) Page(ctx context.Context, offset int64, limit int64) (_ []models.ToDoItem, total int64, err error) {
+	operation := list.operation + "Page"
+	defer metrics.ObserveDAO("ToDoItemDao", operation, time.Now(), &err)
+
+	fields := append(append([]zap.Field{}, list.fields...), zap.Int64("offset", offset), zap.Int64("limit", limit))
+	logging.FromContext(ctx).Info("ToDo: "+operation, fields...)
+
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao."+operation)
+	defer cancel()
+
+	total, err = config.ToDoItemsCollection.CountDocuments(ctx, list.filter)
+	if err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: "+operation+" failed", zap.Error(err))
+		return nil, 0, dao.Database(err)
+	}
+	if limit == 0 || offset >= total {
+		return []models.ToDoItem{}, total, nil
+	}
+
+	// Ties are broken by ID, so that consecutive pages neither skip nor repeat items
+	sort := append(append(bson.D{}, list.sort...), bson.E{Key: "_id", Value: 1})
+	cursor, err := config.ToDoItemsCollection.Find(ctx, list.filter, options.Find().SetSort(sort).SetSkip(offset).SetLimit(limit))
+	if err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: "+operation+" failed", zap.Error(err))
+		return nil, 0, dao.Database(err)
+	}
+
+	items := []models.ToDoItem{}
+	if err = cursor.All(ctx, &items); err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: "+operation+" failed", zap.Error(err))
+		return nil, 0, dao.Database(err)
+	}
+
+	return items, total, nil
+}
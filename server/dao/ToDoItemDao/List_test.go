@@ -0,0 +1,145 @@
+package ToDoItemDao
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// slowCursor is a cursor over items that takes delay to read each of them, like a database answering slowly.
+type slowCursor struct {
+	items []models.ToDoItem
+	delay time.Duration
+
+	read int
+	err  error
+}
+
+func (c *slowCursor) Next(ctx context.Context) bool {
+	select {
+	case <-time.After(c.delay):
+	case <-ctx.Done():
+		c.err = ctx.Err()
+		return false
+	}
+
+	if c.read == len(c.items) {
+		return false
+	}
+	c.read++
+	return true
+}
+
+func (c *slowCursor) Decode(value interface{}) error {
+	*value.(*models.ToDoItem) = c.items[c.read-1]
+	return nil
+}
+
+func (c *slowCursor) Err() error {
+	return c.err
+}
+
+func TestListRead(t *testing.T) {
+	dao.SetTimeouts(50*time.Millisecond, nil)
+	defer dao.SetTimeouts(0, nil)
+
+	items := []models.ToDoItem{{Title: "Pay rent"}, {Title: "Call mum"}, {Title: "Buy milk"}, {Title: "Fix bug"}}
+
+	tests := []struct {
+		name    string
+		delay   time.Duration
+		visit   time.Duration
+		visited int
+		err     error
+	}{
+		{name: "fast", visited: 4},
+		// The timeout is for the database, so a client reading the list slowly is not cut off
+		{name: "slow visit", visit: 30 * time.Millisecond, visited: 4},
+		{name: "slow database", delay: 100 * time.Millisecond, err: dao.ErrTimeout},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list, err := ListAll("createdAt", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			visited := 0
+			err = list.read(context.Background(), &slowCursor{items: items, delay: test.delay}, cacheGeneration(), func(item *models.ToDoItem) error {
+				if item.Title != items[visited].Title {
+					t.Errorf("item %d = %q, want %q", visited+1, item.Title, items[visited].Title)
+				}
+				visited++
+				time.Sleep(test.visit)
+				return nil
+			})
+
+			if !errors.Is(err, test.err) || err == nil && test.err != nil {
+				t.Errorf("read = %v, want %v", err, test.err)
+			}
+			if visited != test.visited {
+				t.Errorf("visited %d items, want %d", visited, test.visited)
+			}
+		})
+	}
+}
+
+func TestListReadVisitError(t *testing.T) {
+	list, err := ListAll("createdAt", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := errors.New("client went away")
+	visited := 0
+	err = list.read(context.Background(), &slowCursor{items: []models.ToDoItem{{}, {}, {}}}, cacheGeneration(), func(item *models.ToDoItem) error {
+		visited++
+		return stop
+	})
+
+	if err != stop || visited != 1 {
+		t.Errorf("read = %v after %d items, want %v after 1", err, visited, stop)
+	}
+}
+
+func TestListDueWithin(t *testing.T) {
+	noDeadline := bson.D{{Key: "deadline", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$gt", Value: 0}}}}}}
+
+	tests := []struct {
+		start, end int64
+		want       bson.D
+	}{
+		{want: bson.D{}},
+		{start: 1000, end: 2000, want: bson.D{{Key: "$or", Value: bson.A{noDeadline, bson.D{{Key: "deadline", Value: bson.D{{Key: "$gte", Value: int64(1000)}, {Key: "$lt", Value: int64(2000)}}}}}}}},
+		{start: 1000, want: bson.D{{Key: "$or", Value: bson.A{noDeadline, bson.D{{Key: "deadline", Value: bson.D{{Key: "$gte", Value: int64(1000)}}}}}}}},
+		{end: 2000, want: bson.D{{Key: "$or", Value: bson.A{noDeadline, bson.D{{Key: "deadline", Value: bson.D{{Key: "$lt", Value: int64(2000)}}}}}}}},
+	}
+
+	all, err := ListAll("createdAt", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := map[string]bool{}
+	for _, test := range tests {
+		list := all.DueWithin(test.start, test.end)
+		if !reflect.DeepEqual(list.filter, test.want) {
+			t.Errorf("DueWithin(%d, %d) filter = %v, want %v", test.start, test.end, list.filter, test.want)
+		}
+		// Every range is cached apart
+		if keys[list.key] {
+			t.Errorf("DueWithin(%d, %d) key %q is not unique", test.start, test.end, list.key)
+		}
+		keys[list.key] = true
+	}
+
+	if len(all.filter) != 0 {
+		t.Errorf("DueWithin changed the list it narrowed: %v", all.filter)
+	}
+}
@@ -2,385 +2,649 @@ package ToDoItemDao
 
 import (
 	"context"
-	"log"
-	"net/http"
-	"strconv"
+	"sort"
 	"time"
 
 	"github.com/L4TTiCe/ToDo-Go/server/config"
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/SequenceDao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/TombstoneDao"
+	"github.com/L4TTiCe/ToDo-Go/server/events"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/metrics"
 	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/offline"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
 )
 
-// Create creates a new ToDoItem in the DB.
-// It takes a ToDoItem struct and returns a struct with the InsertedID or an ErrorResponse.
-func Create(item *models.ToDoItem) (interface{}, *models.ErrorResponse) {
+// Validate checks that a ToDoItem can be stored in the DB.
+// It returns an ErrValidation error listing every problem found, or nil if the item is valid.
+func Validate(item *models.ToDoItem) error {
 	// Check if item is nil
 	if item == nil {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusBadRequest,
-			Title:  "Empty Request",
-			Detail: "Request body is empty",
-		}
+		return dao.Validation("Request body is empty")
 	}
 
+	var violations []models.Violation
+
 	// Check if Title is empty
 	if item.Title == "" {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusBadRequest,
-			Title:  "No Title",
-			Detail: "Title is a required field",
-		}
+		violations = append(violations, models.Violation{Pointer: "/title", Message: "is required"})
+	}
+
+	// Check if Priority is valid
+	if !models.IsValidPriority(item.Priority) {
+		violations = append(violations, models.Violation{Pointer: "/priority", Message: "must be one of the following: low, medium, high"})
+	}
+
+	if len(violations) > 0 {
+		return dao.Validation("One or more fields are invalid", violations...)
+	}
+
+	return nil
+}
+
+// Create creates a new ToDoItem in the DB.
+// It takes a ToDoItem struct and returns a struct with the InsertedID or an error.
+func Create(ctx context.Context, item *models.ToDoItem) (_ *mongo.InsertOneResult, err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "Create", time.Now(), &err)
+
+	if err := Validate(item); err != nil {
+		return nil, err
 	}
 
 	// Overwrite CreatedAt field with current server time
 	item.CreatedAt = time.Now().UnixMilli()
 
-	// Create a context with a timeout of 10 seconds
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.Create")
 	defer cancel()
 
+	return insert(ctx, item)
+}
+
+// CreateImported creates a ToDoItem read from an import in the DB.
+// Unlike Create, it keeps the item's CreatedAt if it has one.
+func CreateImported(ctx context.Context, item *models.ToDoItem) (_ *mongo.InsertOneResult, err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "CreateImported", time.Now(), &err)
+
+	if err := Validate(item); err != nil {
+		return nil, err
+	}
+
+	prepareImported(item, time.Now().UnixMilli())
+
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.CreateImported")
+	defer cancel()
+
+	return insert(ctx, item)
+}
+
+// prepareImported clears the fields of an imported item that this DB owns, as the item is new to it whatever ID or
+// sync state it had before, and sets CreatedAt to now if the item has none. The ResourceName is kept: no import format
+// carries it, and CalDAV sets it on the items its clients create.
+func prepareImported(item *models.ToDoItem, now int64) {
+	item.ID = primitive.NilObjectID
+	item.ClientID = ""
+	item.Sequence = 0
+	item.Versions = nil
+
+	if item.CreatedAt == 0 {
+		item.CreatedAt = now
+	}
+}
+
+// insert inserts an already validated ToDoItem into the DB, within the timeout of the caller's operation.
+func insert(ctx context.Context, item *models.ToDoItem) (*mongo.InsertOneResult, error) {
+	// Synced items arrive with the versions their client gave them
+	if item.Versions == nil {
+		offline.Stamp(item, nil, time.Now().UnixMilli())
+	}
+
+	done, err := touch(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	// The new item can be in any cached list
+	defer invalidate()
+
 	// Insert item into DB
 	result, err := config.ToDoItemsCollection.InsertOne(ctx, &item)
 	if err != nil {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusInternalServerError,
-			Title:  err.Error(),
-		}
+		return nil, dao.Database(err)
 	}
 
+	created := *item
+	created.ID, _ = result.InsertedID.(primitive.ObjectID)
+	events.Publish(models.ChangeCreated, &created)
+
 	return result, nil
 }
 
-func RetrieveAll(sortParam string, sortOrder int) ([]models.ToDoItem, *models.ErrorResponse) {
-	log.Println("ToDo: RetrieveAll (sortParam: " + sortParam + ", sortOrder: " + strconv.Itoa(sortOrder) + ")")
+// RetrieveExisting finds which of the given external IDs are already present in the DB.
+// An ID matches an item with that ExternalID, or an item whose ObjectID it is, so that re-importing an export is detected.
+// It returns the set of IDs found or an error.
+func RetrieveExisting(ctx context.Context, externalIDs []string) (_ map[string]bool, err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "RetrieveExisting", time.Now(), &err)
 
-	// Validate sortParam
-	if sortParam != "" && sortParam != "title" && sortParam != "completed" && sortParam != "createdAt" && sortParam != "deadline" {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusBadRequest,
-			Title:  "Invalid Sort Parameter",
-			Detail: "Sort parameter must be one of the following: title, completed, createdAt, deadline",
-		}
+	existing := map[string]bool{}
+	if len(externalIDs) == 0 {
+		return existing, nil
 	}
 
-	// Validate sortOrder
-	if sortOrder != 1 && sortOrder != -1 {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusBadRequest,
-			Title:  "Invalid Sort Order",
-			Detail: "Sort parameter must be one of the following: asc, desc, 1, -1",
+	var objectIDs []primitive.ObjectID
+	for _, id := range externalIDs {
+		if objectId, err := primitive.ObjectIDFromHex(id); err == nil {
+			objectIDs = append(objectIDs, objectId)
 		}
 	}
 
-	// Create a context with a timeout of 10 seconds
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.RetrieveExisting")
 	defer cancel()
 
-	// Create options for sorting
-	// Note: bson.D{} preserves order and is ideal for specifing sort ordering
-	cursor, err := config.ToDoItemsCollection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: sortParam, Value: sortOrder}}))
+	filter := bson.M{"$or": bson.A{
+		bson.M{"externalId": bson.M{"$in": externalIDs}},
+		bson.M{"_id": bson.M{"$in": objectIDs}},
+	}}
+	projection := options.Find().SetProjection(bson.M{"_id": 1, "externalId": 1})
+
+	cursor, err := config.ToDoItemsCollection.Find(ctx, filter, projection)
 	if err != nil {
-		log.Print(err)
-		return nil, &models.ErrorResponse{
-			Status: http.StatusInternalServerError,
-			Title:  err.Error(),
-		}
+		logging.FromContext(ctx).Error("ToDoItem: RetrieveExisting failed", zap.Error(err))
+		return nil, dao.Database(err)
 	}
 
-	// Create a slice to hold all ToDoItems
-	var items []models.ToDoItem
-
-	// Append items from cursor to items
-	for cursor.Next(context.Background()) {
+	for cursor.Next(ctx) {
 		item := models.ToDoItem{}
 		err = cursor.Decode(&item)
 		if err != nil {
-			log.Print(err)
-			return nil, &models.ErrorResponse{
-				Status: http.StatusInternalServerError,
-				Title:  err.Error(),
-			}
+			logging.FromContext(ctx).Error("ToDoItem: RetrieveExisting failed", zap.Error(err))
+			return nil, dao.Database(err)
+		}
+		existing[item.ID.Hex()] = true
+		if item.ExternalID != "" {
+			existing[item.ExternalID] = true
 		}
-		items = append(items, item)
+	}
+	// The loop also ends when the cursor fails, e.g. because the caller went away
+	if err = cursor.Err(); err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: RetrieveExisting failed", zap.Error(err))
+		return nil, dao.Database(err)
 	}
 
-	return items, nil
+	return existing, nil
 }
 
-func RetrieveWithParams(attrib string, verb string, date int64, sortOrder int) ([]models.ToDoItem, *models.ErrorResponse) {
-	log.Println("ToDo: RetrieveWithParams (attrib: " + attrib + ", verb: " + verb + ", date: " + strconv.FormatInt(date, 10) + ")")
-
-	// Validate attrib
-	if attrib != "createdAt" && attrib != "deadline" {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusBadRequest,
-			Title:  "Invalid Attribute",
-			Detail: "Attribute must be one of the following: createdAt, deadline",
-		}
+// RetrieveAll retrieves every ToDoItem from the DB, sorted by sortParam in sortOrder, 1 or -1.
+func RetrieveAll(ctx context.Context, sortParam string, sortOrder int) ([]models.ToDoItem, error) {
+	list, err := ListAll(sortParam, sortOrder)
+	if err != nil {
+		return nil, err
 	}
+	return list.Retrieve(ctx)
+}
 
-	// Validate verb
-	if verb != "gte" && verb != "lte" {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusBadRequest,
-			Title:  "Invalid Verb",
-			Detail: "Verb must be one of the following: gte, lte",
-		}
+// RetrieveWithParams retrieves the ToDoItems whose attrib is before (lte) or after (gte) date from the DB, sorted by
+// attrib in sortOrder, 1 or -1.
+func RetrieveWithParams(ctx context.Context, attrib string, verb string, date int64, sortOrder int) ([]models.ToDoItem, error) {
+	list, err := ListWithParams(attrib, verb, date, sortOrder)
+	if err != nil {
+		return nil, err
 	}
+	return list.Retrieve(ctx)
+}
 
-	// Validate date
-	if date < 0 {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusBadRequest,
-			Title:  "Invalid Date",
-			Detail: "Date must be a positive integer",
-		}
+// RetrieveBetween retrieves the ToDoItems whose attrib is between start and end from the DB, sorted by attrib in
+// sortOrder, 1 or -1.
+func RetrieveBetween(ctx context.Context, attrib string, start int64, end int64, sortOrder int) ([]models.ToDoItem, error) {
+	list, err := ListBetween(attrib, start, end, sortOrder)
+	if err != nil {
+		return nil, err
 	}
+	return list.Retrieve(ctx)
+}
 
-	// Validate sortOrder
-	if sortOrder != 1 && sortOrder != -1 {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusBadRequest,
-			Title:  "Invalid Sort Order",
-			Detail: "Sort parameter must be one of the following: asc, desc, 1, -1",
-		}
-	}
+// RetrieveDue retrieves all open ToDoItems that have a deadline, sorted by deadline.
+// If list or tag are not empty, only items in that list or with that tag are retrieved.
+func RetrieveDue(ctx context.Context, list string, tag string) (_ []models.ToDoItem, err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "RetrieveDue", time.Now(), &err)
 
-	// Create a context with a timeout of 10 seconds
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	logging.FromContext(ctx).Info("ToDo: RetrieveDue", zap.String("list", list), zap.String("tag", tag))
 
-	// Create options for sorting
-	// Note: bson.D{} preserves order and is ideal for specifing sort ordering
-	sortOptions := options.Find().SetSort(bson.D{{Key: attrib, Value: sortOrder}})
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.RetrieveDue")
+	defer cancel()
 
 	// Create a filter for the query
-	filter := bson.D{{Key: attrib, Value: bson.D{{Key: "$" + verb, Value: date}}}}
+	filter := bson.M{"completed": false, "deadline": bson.M{"$gt": 0}}
+	if list != "" {
+		filter["list"] = list
+	}
+	if tag != "" {
+		filter["tags"] = tag
+	}
 
-	cursor, err := config.ToDoItemsCollection.Find(ctx, filter, sortOptions)
+	cursor, err := config.ToDoItemsCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "deadline", Value: 1}}))
 	if err != nil {
-		log.Print(err)
-		return nil, &models.ErrorResponse{
-			Status: http.StatusInternalServerError,
-			Title:  err.Error(),
-		}
+		logging.FromContext(ctx).Error("ToDoItem: RetrieveDue failed", zap.Error(err))
+		return nil, dao.Database(err)
 	}
 
-	// Create a slice to hold all ToDoItems
 	var items []models.ToDoItem
-
-	// Append items from cursor to items
-	for cursor.Next(context.Background()) {
-		item := models.ToDoItem{}
-		err = cursor.Decode(&item)
-		if err != nil {
-			log.Print(err)
-			return nil, &models.ErrorResponse{
-				Status: http.StatusInternalServerError,
-				Title:  err.Error(),
-			}
-		}
-		items = append(items, item)
+	if err = cursor.All(ctx, &items); err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: RetrieveDue failed", zap.Error(err))
+		return nil, dao.Database(err)
 	}
 
 	return items, nil
 }
 
-func RetrieveBetween(attrib string, start int64, end int64, sortOrder int) ([]models.ToDoItem, *models.ErrorResponse) {
-	log.Println("ToDo: RetrieveBetween (attrib: " + attrib + ", start: " + strconv.FormatInt(start, 10) + ", end: " + strconv.FormatInt(end, 10) + ")")
+// RetrieveDistinct retrieves the distinct non-empty values of a string field, such as list or tags, sorted.
+func RetrieveDistinct(ctx context.Context, field string) (_ []string, err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "RetrieveDistinct", time.Now(), &err)
 
-	// Validate attrib
-	if attrib != "createdAt" && attrib != "deadline" {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusBadRequest,
-			Title:  "Invalid Attribute",
-			Detail: "Attribute must be one of the following: createdAt, deadline",
-		}
-	}
+	logging.FromContext(ctx).Info("ToDo: RetrieveDistinct", zap.String("field", field))
 
-	// Validate dates
-	if start < 0 || end < 0 {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusBadRequest,
-			Title:  "Invalid Date",
-			Detail: "Date must be a positive integer. Check that start and end dates are positive integers",
-		}
+	// Validate field
+	if field != "list" && field != "tags" {
+		return nil, dao.Validation("Field must be one of the following: list, tags")
 	}
 
-	if start > end {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusBadRequest,
-			Title:  "Invalid Date",
-			Detail: "Start date must be less than end date",
-		}
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.RetrieveDistinct")
+	defer cancel()
+
+	values, err := config.ToDoItemsCollection.Distinct(ctx, field, bson.D{})
+	if err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: RetrieveDistinct failed", zap.Error(err))
+		return nil, dao.Database(err)
 	}
 
-	// Validate sortOrder
-	if sortOrder != 1 && sortOrder != -1 {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusBadRequest,
-			Title:  "Invalid Sort Order",
-			Detail: "Sort parameter must be one of the following: asc, desc, 1, -1",
+	result := []string{}
+	for _, value := range values {
+		if s, ok := value.(string); ok && s != "" {
+			result = append(result, s)
 		}
 	}
+	sort.Strings(result)
 
-	// Create a context with a timeout of 10 seconds
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	return result, nil
+}
 
-	// Create options for sorting
-	// Note: bson.D{} preserves order and is ideal for specifing sort ordering
-	sortOptions := options.Find().SetSort(bson.D{{Key: attrib, Value: sortOrder}})
+// RetrieveOne retrieves a ToDoItem from the DB.
+// It takes an ID and returns a ToDoItem or an error.
+func RetrieveOne(ctx context.Context, id string) (_ *models.ToDoItem, err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "RetrieveOne", time.Now(), &err)
 
-	// Create a filter for the query
-	filter := bson.D{{Key: attrib, Value: bson.D{{Key: "$gte", Value: start}, {Key: "$lte", Value: end}}}}
+	logging.FromContext(ctx).Info("ToDo: RetrieveOne", zap.String("id", id))
 
-	cursor, err := config.ToDoItemsCollection.Find(ctx, filter, sortOptions)
+	// convert id string to ObjectId
+	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		log.Print(err)
-		return nil, &models.ErrorResponse{
-			Status: http.StatusInternalServerError,
-			Title:  err.Error(),
-		}
+		return nil, dao.InvalidID(id)
 	}
 
-	// Create a slice to hold all ToDoItems
-	var items []models.ToDoItem
+	if item, ok := cachedItem(objectId.Hex()); ok {
+		return item, nil
+	}
+	readIn := cacheGeneration()
 
-	// Append items from cursor to items
-	for cursor.Next(context.Background()) {
-		item := models.ToDoItem{}
-		err = cursor.Decode(&item)
-		if err != nil {
-			log.Print(err)
-			return nil, &models.ErrorResponse{
-				Status: http.StatusInternalServerError,
-				Title:  err.Error(),
-			}
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.RetrieveOne")
+	defer cancel()
+
+	// Find item in DB
+	item := models.ToDoItem{}
+	err = config.ToDoItemsCollection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&item)
+	if err != nil {
+		// Check if item was not found
+		if err == mongo.ErrNoDocuments {
+			return nil, dao.NotFound("Item with ID " + id + " not found")
 		}
-		items = append(items, item)
+		logging.FromContext(ctx).Error("ToDoItem: RetrieveOne failed", zap.Error(err))
+		return nil, dao.Database(err)
 	}
 
-	return items, nil
+	cacheItem(objectId.Hex(), &item, readIn)
+	return &item, nil
 }
 
-// RetrieveOne retrieves a ToDoItem from the DB.
-// It takes an ID and returns a ToDoItem or an ErrorResponse.
-func RetrieveOne(id string) (*models.ToDoItem, *models.ErrorResponse) {
-	log.Print("ToDo: RetrieveOne (id: " + id + ")")
+// RetrieveByExternalID retrieves the ToDoItem with the given ExternalID from the DB.
+// It returns the ToDoItem or an error, ErrNotFound if there is no such item.
+func RetrieveByExternalID(ctx context.Context, externalID string) (_ *models.ToDoItem, err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "RetrieveByExternalID", time.Now(), &err)
 
-	// convert id string to ObjectId
-	objectId, err := primitive.ObjectIDFromHex(id)
+	logging.FromContext(ctx).Info("ToDo: RetrieveByExternalID", zap.String("externalId", externalID))
+
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.RetrieveByExternalID")
+	defer cancel()
+
+	item := models.ToDoItem{}
+	err = config.ToDoItemsCollection.FindOne(ctx, bson.M{"externalId": externalID}).Decode(&item)
 	if err != nil {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusBadRequest,
-			Title:  err.Error(),
-			Detail: "Invalid ID",
+		if err == mongo.ErrNoDocuments {
+			return nil, dao.NotFound("Item with external ID " + externalID + " not found")
 		}
+		logging.FromContext(ctx).Error("ToDoItem: RetrieveByExternalID failed", zap.Error(err))
+		return nil, dao.Database(err)
 	}
 
-	// Create a context with a timeout of 10 seconds
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	return &item, nil
+}
+
+// RetrieveByResourceName retrieves the ToDoItem a CalDAV client stored under the given resource name from the DB.
+// It returns the ToDoItem or an error, ErrNotFound if there is no such item.
+func RetrieveByResourceName(ctx context.Context, name string) (_ *models.ToDoItem, err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "RetrieveByResourceName", time.Now(), &err)
+
+	logging.FromContext(ctx).Info("ToDo: RetrieveByResourceName", zap.String("name", name))
+
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.RetrieveByResourceName")
 	defer cancel()
 
-	// Find item in DB
 	item := models.ToDoItem{}
-	err = config.ToDoItemsCollection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&item)
+	err = config.ToDoItemsCollection.FindOne(ctx, bson.M{"resourceName": name}).Decode(&item)
 	if err != nil {
-		// Check if item was not found
-		if err.Error() == "mongo: no documents in result" {
-			return nil, &models.ErrorResponse{
-				Status: http.StatusNotFound,
-				Title:  "Item Not Found",
-				Detail: "Item with ID " + id + " not found",
-			}
-		} else {
-			log.Print(err)
-			return nil, &models.ErrorResponse{
-				Status: http.StatusInternalServerError,
-				Title:  err.Error(),
-			}
+		if err == mongo.ErrNoDocuments {
+			return nil, dao.NotFound("Item with resource name " + name + " not found")
 		}
+		logging.FromContext(ctx).Error("ToDoItem: RetrieveByResourceName failed", zap.Error(err))
+		return nil, dao.Database(err)
 	}
 
 	return &item, nil
 }
 
+// CountOpen counts the ToDoItems that are not completed, and those of them whose deadline has passed.
+func CountOpen(ctx context.Context) (open int64, overdue int64, err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "CountOpen", time.Now(), &err)
+
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.CountOpen")
+	defer cancel()
+
+	open, err = config.ToDoItemsCollection.CountDocuments(ctx, bson.M{"completed": false})
+	if err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: CountOpen failed", zap.Error(err))
+		return 0, 0, dao.Database(err)
+	}
+
+	filter := bson.M{"completed": false, "deadline": bson.M{"$gt": 0, "$lt": time.Now().UnixMilli()}}
+	overdue, err = config.ToDoItemsCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: CountOpen failed", zap.Error(err))
+		return 0, 0, dao.Database(err)
+	}
+
+	return open, overdue, nil
+}
+
 // Update updates a ToDoItem in the DB.
-// It takes a ToDoItem struct and returns the update status or an ErrorResponse.
-func UpdateOne(id string, updatedItem *models.ToDoItem) (interface{}, *models.ErrorResponse) {
-	log.Print("ToDo: UpdateOne (id: " + id + ")")
+// It takes a ToDoItem struct and returns the update status or an error.
+func UpdateOne(ctx context.Context, id string, updatedItem *models.ToDoItem) (_ interface{}, err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "UpdateOne", time.Now(), &err)
+
+	logging.FromContext(ctx).Info("ToDo: UpdateOne", zap.String("id", id))
 
 	// convert id string to ObjectId
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusBadRequest,
-			Title:  err.Error(),
-			Detail: "Invalid ID",
-		}
+		return nil, dao.InvalidID(id)
 	}
 
-	// Create a context with a timeout of 10 seconds
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.UpdateOne")
 	defer cancel()
 
 	// Item validation already performed when FindOne is called from contrller before this function is called
 
+	// The stored item tells which fields are written, to version them for syncing clients
+	previous := models.ToDoItem{}
+	err = config.ToDoItemsCollection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&previous)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, dao.NotFound("Item with ID " + id + " not found")
+		}
+		logging.FromContext(ctx).Error("ToDoItem: UpdateOne failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+
+	updatedItem.ClientID = previous.ClientID
+	updatedItem.ResourceName = previous.ResourceName
+	offline.Stamp(updatedItem, &previous, time.Now().UnixMilli())
+
+	done, err := touch(ctx, updatedItem)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	defer invalidate(objectId.Hex())
+
 	// Update item in DB
 	result, err := config.ToDoItemsCollection.ReplaceOne(ctx, bson.M{"_id": objectId}, &updatedItem)
 	if err != nil {
-		log.Print(err)
-		return nil, &models.ErrorResponse{
-			Status: http.StatusInternalServerError,
-			Title:  err.Error(),
-		}
+		logging.FromContext(ctx).Error("ToDoItem: UpdateOne failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, dao.NotFound("Item with ID " + id + " not found")
 	}
 
+	updated := *updatedItem
+	updated.ID = objectId
+	events.Publish(models.ChangeUpdated, &updated)
+
 	return result, nil
 }
 
+// CreateSynced creates a ToDoItem that an offline client created in the DB.
+// The item must have a ClientID, and the Versions of the fields the client set. Client IDs are unique, so an ErrConflict
+// error is returned if an item with the same ClientID exists already.
+func CreateSynced(ctx context.Context, item *models.ToDoItem) (_ *mongo.InsertOneResult, err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "CreateSynced", time.Now(), &err)
+
+	if err := Validate(item); err != nil {
+		return nil, err
+	}
+
+	item.ID = primitive.NilObjectID
+	item.CreatedAt = time.Now().UnixMilli()
+
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.CreateSynced")
+	defer cancel()
+
+	return insert(ctx, item)
+}
+
+// ReplaceSynced replaces a ToDoItem with the result of merging an offline client's change into it.
+// sequence is the Sequence of the item the change was merged into; if the item has been written since, it is not
+// replaced and an ErrConflict error is returned, so that the change can be merged again.
+func ReplaceSynced(ctx context.Context, item *models.ToDoItem, sequence int64) (err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "ReplaceSynced", time.Now(), &err)
+
+	logging.FromContext(ctx).Info("ToDo: ReplaceSynced", zap.String("id", item.ID.Hex()))
+
+	if err := Validate(item); err != nil {
+		return err
+	}
+
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.ReplaceSynced")
+	defer cancel()
+
+	filter := bson.M{"_id": item.ID, "sequence": sequence}
+	if sequence == 0 {
+		filter["sequence"] = bson.M{"$exists": false}
+	}
+
+	done, err := touch(ctx, item)
+	if err != nil {
+		return err
+	}
+	defer done()
+	defer invalidate(item.ID.Hex())
+
+	result, err := config.ToDoItemsCollection.ReplaceOne(ctx, filter, item)
+	if err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: ReplaceSynced failed", zap.Error(err))
+		return dao.Database(err)
+	}
+	if result.MatchedCount == 0 {
+		return dao.Conflict("Item with ID " + item.ID.Hex() + " was changed concurrently")
+	}
+
+	updated := *item
+	events.Publish(models.ChangeUpdated, &updated)
+
+	return nil
+}
+
+// RetrieveByClientID retrieves the ToDoItem an offline client created with the given client ID from the DB.
+// It returns the ToDoItem or an error, ErrNotFound if there is no such item.
+func RetrieveByClientID(ctx context.Context, clientID string) (_ *models.ToDoItem, err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "RetrieveByClientID", time.Now(), &err)
+
+	logging.FromContext(ctx).Info("ToDo: RetrieveByClientID", zap.String("clientId", clientID))
+
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.RetrieveByClientID")
+	defer cancel()
+
+	item := models.ToDoItem{}
+	err = config.ToDoItemsCollection.FindOne(ctx, bson.M{"clientId": clientID}).Decode(&item)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, dao.NotFound("Item with client ID " + clientID + " not found")
+		}
+		logging.FromContext(ctx).Error("ToDoItem: RetrieveByClientID failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+
+	return &item, nil
+}
+
+// RetrieveChangedAfter retrieves up to limit ToDoItems whose sequence is after the given one and at most upTo, in order.
+func RetrieveChangedAfter(ctx context.Context, after int64, upTo int64, limit int64) (_ []models.ToDoItem, err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "RetrieveChangedAfter", time.Now(), &err)
+
+	logging.FromContext(ctx).Info("ToDo: RetrieveChangedAfter", zap.Int64("after", after), zap.Int64("upTo", upTo))
+
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.RetrieveChangedAfter")
+	defer cancel()
+
+	filter := bson.M{"sequence": bson.M{"$gt": after, "$lte": upTo}}
+	opts := options.Find().SetSort(bson.D{{Key: "sequence", Value: 1}}).SetLimit(limit)
+	cursor, err := config.ToDoItemsCollection.Find(ctx, filter, opts)
+	if err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: RetrieveChangedAfter failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+
+	var items []models.ToDoItem
+	if err = cursor.All(ctx, &items); err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: RetrieveChangedAfter failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+
+	return items, nil
+}
+
+// AssignSequences numbers the ToDoItems written before the change sequence existed, so that clients syncing for
+// the first time receive them. It runs once, as a migration: every later write numbers its item.
+func AssignSequences(ctx context.Context) (err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "AssignSequences", time.Now(), &err)
+
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.AssignSequences")
+	defer cancel()
+
+	// Numbering changes the items, and the order of lists sorted by sequence
+	defer invalidateAll()
+
+	unnumbered := bson.M{"sequence": bson.M{"$exists": false}}
+	cursor, err := config.ToDoItemsCollection.Find(ctx, unnumbered, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: AssignSequences failed", zap.Error(err))
+		return dao.Database(err)
+	}
+
+	var items []models.ToDoItem
+	if err = cursor.All(ctx, &items); err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: AssignSequences failed", zap.Error(err))
+		return dao.Database(err)
+	}
+	if len(items) > 0 {
+		logging.FromContext(ctx).Info("ToDo: AssignSequences", zap.Int("items", len(items)))
+	}
+
+	for _, item := range items {
+		sequence, done, err := SequenceDao.Next(ctx)
+		if err != nil {
+			return err
+		}
+
+		filter := bson.M{"_id": item.ID, "sequence": bson.M{"$exists": false}}
+		_, err = config.ToDoItemsCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"sequence": sequence}})
+		done()
+		if err != nil {
+			logging.FromContext(ctx).Error("ToDoItem: AssignSequences failed", zap.Error(err))
+			return dao.Database(err)
+		}
+	}
+
+	return nil
+}
+
 // DeleteOne deletes a ToDoItem from the DB.
-// It takes an ID and returns the status of the delete Operation or an ErrorResponse.
-func DeleteOne(id string) (interface{}, *models.ErrorResponse) {
-	log.Print("ToDo: DeleteOne (id: " + id + ")")
+// It takes an ID and returns the status of the delete Operation or an error.
+func DeleteOne(ctx context.Context, id string) (_ interface{}, err error) {
+	defer metrics.ObserveDAO("ToDoItemDao", "DeleteOne", time.Now(), &err)
+
+	logging.FromContext(ctx).Info("ToDo: DeleteOne", zap.String("id", id))
 
 	// convert id string to ObjectId
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusBadRequest,
-			Title:  err.Error(),
-			Detail: "Invalid ID",
-		}
+		return nil, dao.InvalidID(id)
 	}
 
-	// Create a context with a timeout of 10 seconds
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := dao.WithTimeout(ctx, "ToDoItemDao.DeleteOne")
 	defer cancel()
+	defer invalidate(objectId.Hex())
 
-	// Delete item in DB
-	result, err := config.ToDoItemsCollection.DeleteOne(ctx, bson.M{"_id": objectId})
+	// Delete item in DB, keeping the deleted item to record a tombstone
+	item := models.ToDoItem{}
+	err = config.ToDoItemsCollection.FindOneAndDelete(ctx, bson.M{"_id": objectId}).Decode(&item)
 	if err != nil {
-		log.Print(err)
-		return nil, &models.ErrorResponse{
-			Status: http.StatusInternalServerError,
-			Title:  err.Error(),
+		// Check if item was not found
+		if err == mongo.ErrNoDocuments {
+			return nil, dao.NotFound("Item with ID " + id + " not found")
 		}
+		logging.FromContext(ctx).Error("ToDoItem: DeleteOne failed", zap.Error(err))
+		return nil, dao.Database(err)
 	}
 
-	// Check if item was deleted
-	if result.DeletedCount == 0 {
-		return nil, &models.ErrorResponse{
-			Status: http.StatusNotFound,
-			Title:  "Item Not Found",
-			Detail: "Item with ID " + id + " not found",
-		}
+	events.Publish(models.ChangeDeleted, &item)
+
+	// A missing tombstone only affects clients syncing changes, so the delete still succeeds. The item is gone, so
+	// the tombstone is recorded even if the caller goes away meanwhile
+	if err := TombstoneDao.Create(dao.Detach(ctx), &models.Tombstone{ItemID: item.ID, ExternalID: item.ExternalID, ClientID: item.ClientID, ResourceName: item.ResourceName}); err != nil {
+		logging.FromContext(ctx).Error("ToDoItem: DeleteOne failed", zap.Error(err))
 	}
 
-	return result, nil
+	return &mongo.DeleteResult{DeletedCount: 1}, nil
+}
+
+// touch sets UpdatedAt to the current server time, sets or clears CompletedAt to match Completed, and numbers the
+// write in the change sequence. done must be called once the write has finished or failed.
+func touch(ctx context.Context, item *models.ToDoItem) (done func(), err error) {
+	now := time.Now().UnixMilli()
+	item.UpdatedAt = now
+
+	if !item.Completed {
+		item.CompletedAt = 0
+	} else if item.CompletedAt == 0 {
+		item.CompletedAt = now
+	}
+
+	item.Sequence, done, err = SequenceDao.Next(ctx)
+	return done, err
 }
@@ -0,0 +1,47 @@
+package ToDoItemDao
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPrepareImported(t *testing.T) {
+	tests := []struct {
+		name string
+		item models.ToDoItem
+		want models.ToDoItem
+	}{
+		{
+			name: "new item",
+			item: models.ToDoItem{Title: "Pay rent", ExternalID: "rent"},
+			want: models.ToDoItem{Title: "Pay rent", ExternalID: "rent", CreatedAt: 5000},
+		},
+		{
+			// An export of a synced item carries the state of the DB it came from, which is meaningless here
+			name: "exported item",
+			item: models.ToDoItem{
+				ID: primitive.NewObjectID(), Title: "Pay rent", ExternalID: "rent", CreatedAt: 1000, Tags: []string{"home"},
+				ClientID: "client-1", Sequence: 42, Versions: map[string]int64{"title": 1000},
+			},
+			want: models.ToDoItem{Title: "Pay rent", ExternalID: "rent", CreatedAt: 1000, Tags: []string{"home"}},
+		},
+		{
+			// Items created through CalDAV are found again by the name their client chose
+			name: "CalDAV resource",
+			item: models.ToDoItem{Title: "Pay rent", ResourceName: "rent"},
+			want: models.ToDoItem{Title: "Pay rent", ResourceName: "rent", CreatedAt: 5000},
+		},
+	}
+
+	for _, test := range tests {
+		item := test.item
+		prepareImported(&item, 5000)
+
+		if !reflect.DeepEqual(item, test.want) {
+			t.Errorf("%s: prepareImported = %+v, want %+v", test.name, item, test.want)
+		}
+	}
+}
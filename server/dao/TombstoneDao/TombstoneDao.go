@@ -0,0 +1,99 @@
+package TombstoneDao
+
+import (
+	"context"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/config"
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/SequenceDao"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/metrics"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// Create records the deletion of a ToDoItem in the DB.
+// It sets DeletedAt to the current server time, numbers the delete in the change sequence, and returns an error if
+// the tombstone could not be stored.
+func Create(ctx context.Context, tombstone *models.Tombstone) (err error) {
+	defer metrics.ObserveDAO("TombstoneDao", "Create", time.Now(), &err)
+
+	tombstone.DeletedAt = time.Now().UnixMilli()
+
+	sequence, done, err := SequenceDao.Next(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	tombstone.Sequence = sequence
+
+	ctx, cancel := dao.WithTimeout(ctx, "TombstoneDao.Create")
+	defer cancel()
+
+	_, err = config.TombstonesCollection.InsertOne(ctx, tombstone)
+	if err != nil {
+		logging.FromContext(ctx).Error("Tombstone: Create failed", zap.Error(err))
+		return dao.Database(err)
+	}
+
+	return nil
+}
+
+// RetrieveAfter retrieves up to limit tombstones whose sequence is after the given one and at most upTo, in order.
+func RetrieveAfter(ctx context.Context, after int64, upTo int64, limit int64) (_ []models.Tombstone, err error) {
+	defer metrics.ObserveDAO("TombstoneDao", "RetrieveAfter", time.Now(), &err)
+
+	logging.FromContext(ctx).Info("Tombstone: RetrieveAfter", zap.Int64("after", after), zap.Int64("upTo", upTo))
+
+	ctx, cancel := dao.WithTimeout(ctx, "TombstoneDao.RetrieveAfter")
+	defer cancel()
+
+	filter := bson.M{"sequence": bson.M{"$gt": after, "$lte": upTo}}
+	opts := options.Find().SetSort(bson.D{{Key: "sequence", Value: 1}}).SetLimit(limit)
+	cursor, err := config.TombstonesCollection.Find(ctx, filter, opts)
+	if err != nil {
+		logging.FromContext(ctx).Error("Tombstone: RetrieveAfter failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+
+	var tombstones []models.Tombstone
+	if err = cursor.All(ctx, &tombstones); err != nil {
+		logging.FromContext(ctx).Error("Tombstone: RetrieveAfter failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+
+	return tombstones, nil
+}
+
+// RetrieveForItem retrieves the tombstone of the item with the given ID or, if id is empty, of the item an offline
+// client created with the given client ID.
+// It returns the Tombstone or an error, ErrNotFound if the item has not been deleted.
+func RetrieveForItem(ctx context.Context, id primitive.ObjectID, clientID string) (_ *models.Tombstone, err error) {
+	defer metrics.ObserveDAO("TombstoneDao", "RetrieveForItem", time.Now(), &err)
+
+	ctx, cancel := dao.WithTimeout(ctx, "TombstoneDao.RetrieveForItem")
+	defer cancel()
+
+	filter := bson.M{"itemId": id}
+	if id.IsZero() {
+		filter = bson.M{"clientId": clientID}
+	}
+
+	tombstone := models.Tombstone{}
+	err = config.TombstonesCollection.FindOne(ctx, filter).Decode(&tombstone)
+	if err == mongo.ErrNoDocuments {
+		return nil, dao.NotFound("Item has not been deleted")
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Tombstone: RetrieveForItem failed", zap.Error(err))
+		return nil, dao.Database(err)
+	}
+
+	return &tombstone, nil
+}
@@ -0,0 +1,89 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+// subscriberBuffer is the number of changes a subscriber may fall behind before it is dropped.
+const subscriberBuffer = 64
+
+var (
+	mutex       sync.Mutex
+	subscribers = map[chan models.Change]struct{}{}
+	closed      bool
+)
+
+// Publish sends a change to every subscriber. It never blocks: a subscriber that has fallen too far behind
+// is dropped, and its channel closed, so that it can resubscribe and reload instead of silently missing changes.
+func Publish(changeType string, item *models.ToDoItem) {
+	change := models.Change{
+		Type:      changeType,
+		ID:        item.ID,
+		Item:      item,
+		Timestamp: time.Now().UnixMilli(),
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for subscriber := range subscribers {
+		select {
+		case subscriber <- change:
+		default:
+			logging.L().Warn("Dropping slow change subscriber")
+			delete(subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+// Subscribe returns a channel receiving every change published from now on, and a function to unsubscribe.
+// The channel is closed when unsubscribing, if the subscriber falls too far behind, or when the broker is closed.
+func Subscribe() (<-chan models.Change, func()) {
+	subscriber := make(chan models.Change, subscriberBuffer)
+
+	mutex.Lock()
+	if closed {
+		close(subscriber)
+	} else {
+		subscribers[subscriber] = struct{}{}
+	}
+	mutex.Unlock()
+
+	unsubscribe := func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if _, ok := subscribers[subscriber]; ok {
+			delete(subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+
+	return subscriber, unsubscribe
+}
+
+// Close closes every subscriber's channel, and those of later subscribers straight away, so that long-lived
+// streams of changes end when the server shuts down.
+func Close() {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	closed = true
+	for subscriber := range subscribers {
+		delete(subscribers, subscriber)
+		close(subscriber)
+	}
+}
+
+// Closed reports whether Close has been called.
+func Closed() bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return closed
+}
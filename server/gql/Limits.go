@@ -0,0 +1,96 @@
+package gql
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits on the shape of an operation, checked before it is executed so that a single request cannot make the
+// server resolve an unbounded number of fields.
+const (
+	MaxDepth      = 8
+	MaxComplexity = 200
+)
+
+// listCost is the cost of each field selected below a field that returns many items.
+const listCost = 10
+
+// listFields are the fields, by parent and field name, whose selections are resolved once per item.
+var listFields = map[string]bool{
+	"items.items":     true,
+	"history.changes": true,
+}
+
+// CheckLimits parses a request and rejects it if the selected operation is nested deeper than MaxDepth or costs
+// more than MaxComplexity. Every field costs 1, multiplied by listCost for each enclosing list field. Parse errors are
+// left to the executor to report.
+func CheckLimits(query string, operationName string) error {
+	document, err := parse(query)
+	if err != nil {
+		return nil
+	}
+
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, operation := range operations(document, operationName) {
+		m := measurer{fragments: fragments, visiting: map[string]bool{}}
+		m.measure(operation.SelectionSet, "", 1, 1)
+
+		if m.depth > MaxDepth {
+			return fmt.Errorf("query depth %d exceeds the maximum of %d", m.depth, MaxDepth)
+		}
+		if m.complexity > MaxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the maximum of %d", m.complexity, MaxComplexity)
+		}
+	}
+
+	return nil
+}
+
+// measurer walks the selections of an operation, expanding fragments, and records its depth and complexity.
+type measurer struct {
+	fragments  map[string]*ast.FragmentDefinition
+	visiting   map[string]bool
+	depth      int
+	complexity int
+}
+
+func (m *measurer) measure(selectionSet *ast.SelectionSet, parent string, depth int, multiplier int) {
+	if selectionSet == nil || m.complexity > MaxComplexity {
+		return
+	}
+
+	for _, selection := range selectionSet.Selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if depth > m.depth {
+				m.depth = depth
+			}
+			m.complexity += multiplier
+
+			childMultiplier := multiplier
+			if listFields[parent+"."+s.Name.Value] {
+				childMultiplier *= listCost
+			}
+			m.measure(s.SelectionSet, s.Name.Value, depth+1, childMultiplier)
+		case *ast.InlineFragment:
+			m.measure(s.SelectionSet, parent, depth, multiplier)
+		case *ast.FragmentSpread:
+			// Fragment cycles are rejected by validation, but must not loop here
+			name := s.Name.Value
+			fragment, ok := m.fragments[name]
+			if !ok || m.visiting[name] {
+				continue
+			}
+			m.visiting[name] = true
+			m.measure(fragment.SelectionSet, parent, depth, multiplier)
+			m.visiting[name] = false
+		}
+	}
+}
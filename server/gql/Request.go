@@ -0,0 +1,51 @@
+package gql
+
+import (
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Request is a GraphQL request, as sent in a POST body or the query string of a GET.
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// Operation returns the type of the operation a request would execute: "query", "mutation" or "subscription".
+// It returns "" if the request cannot be parsed or names no operation, leaving the executor to report why.
+func Operation(query string, operationName string) string {
+	document, err := parse(query)
+	if err != nil {
+		return ""
+	}
+
+	selected := operations(document, operationName)
+	if len(selected) != 1 {
+		return ""
+	}
+	return selected[0].Operation
+}
+
+func parse(query string) (*ast.Document, error) {
+	return parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(query), Name: "GraphQL request"}),
+	})
+}
+
+// operations returns the operations of a document with the given name, or all of them if the name is empty.
+func operations(document *ast.Document, operationName string) []*ast.OperationDefinition {
+	var selected []*ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName != "" && (operation.Name == nil || operation.Name.Value != operationName) {
+			continue
+		}
+		selected = append(selected, operation)
+	}
+	return selected
+}
@@ -0,0 +1,543 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/url"
+	"strconv"
+
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/SequenceDao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/ToDoItemDao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/TombstoneDao"
+	"github.com/L4TTiCe/ToDo-Go/server/events"
+	"github.com/L4TTiCe/ToDo-Go/server/listing"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Default and maximum page sizes of the items query.
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+// Schema is the GraphQL schema over ToDoItems. Every resolver goes through the same DAO as the REST API.
+var Schema graphql.Schema
+
+// timestamp is a Unix millisecond timestamp. GraphQL's Int is only 32 bits wide, so timestamps get their own scalar.
+var timestamp = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Timestamp",
+	Description: "A Unix millisecond timestamp.",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+	ParseValue: func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case int:
+			return int64(v)
+		case int64:
+			return v
+		case float64:
+			if v == math.Trunc(v) {
+				return int64(v)
+			}
+		}
+		return nil
+	},
+	ParseLiteral: func(value ast.Value) interface{} {
+		if v, ok := value.(*ast.IntValue); ok {
+			if parsed, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+				return parsed
+			}
+		}
+		return nil
+	},
+})
+
+var itemType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ToDoItem",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.ID),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*models.ToDoItem).ID.Hex(), nil
+			},
+		},
+		"title":       field(graphql.NewNonNull(graphql.String), func(item *models.ToDoItem) interface{} { return item.Title }),
+		"completed":   field(graphql.NewNonNull(graphql.Boolean), func(item *models.ToDoItem) interface{} { return item.Completed }),
+		"createdAt":   field(timestamp, func(item *models.ToDoItem) interface{} { return optional(item.CreatedAt) }),
+		"deadline":    field(timestamp, func(item *models.ToDoItem) interface{} { return optional(item.Deadline) }),
+		"updatedAt":   field(timestamp, func(item *models.ToDoItem) interface{} { return optional(item.UpdatedAt) }),
+		"completedAt": field(timestamp, func(item *models.ToDoItem) interface{} { return optional(item.CompletedAt) }),
+		"list":        field(graphql.String, func(item *models.ToDoItem) interface{} { return item.List }),
+		"tags": field(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), func(item *models.ToDoItem) interface{} {
+			if item.Tags == nil {
+				return []string{}
+			}
+			return item.Tags
+		}),
+		"priority":   field(graphql.String, func(item *models.ToDoItem) interface{} { return item.Priority }),
+		"recurrence": field(graphql.String, func(item *models.ToDoItem) interface{} { return item.Recurrence }),
+		"externalId": field(graphql.String, func(item *models.ToDoItem) interface{} { return item.ExternalID }),
+	},
+})
+
+var pageType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "ToDoItemPage",
+	Description: "A page of items. totalCount is the number of items matching the filter, across all pages.",
+	Fields: graphql.Fields{
+		"items":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(itemType)))},
+		"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"offset":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"limit":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"hasMore":    &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+	},
+})
+
+var changeTypeEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "ChangeType",
+	Values: graphql.EnumValueConfigMap{
+		"CREATED": &graphql.EnumValueConfig{Value: models.ChangeCreated},
+		"UPDATED": &graphql.EnumValueConfig{Value: models.ChangeUpdated},
+		"DELETED": &graphql.EnumValueConfig{Value: models.ChangeDeleted},
+	},
+})
+
+var changeType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Change",
+	Fields: graphql.Fields{
+		"type": &graphql.Field{
+			Type: graphql.NewNonNull(changeTypeEnum),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(models.Change).Type, nil
+			},
+		},
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.ID),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(models.Change).ID.Hex(), nil
+			},
+		},
+		"item": &graphql.Field{
+			Type: itemType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(models.Change).Item, nil
+			},
+		},
+		"timestamp": &graphql.Field{
+			Type: graphql.NewNonNull(timestamp),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(models.Change).Timestamp, nil
+			},
+		},
+	},
+})
+
+var historyType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "History",
+	Description: "A page of history. cursor is passed as after to read the changes made after this page; it stays " +
+		"valid once hasMore is false, to read the changes made since.",
+	Fields: graphql.Fields{
+		"changes": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(changeType)))},
+		"cursor":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"hasMore": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+	},
+})
+
+var itemInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name:        "ToDoItemInput",
+	Description: "Fields of an item. On update, only the fields that are given are changed; null clears a field.",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"completed":  &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+		"deadline":   &graphql.InputObjectFieldConfig{Type: timestamp},
+		"list":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"tags":       &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+		"priority":   &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"recurrence": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"externalId": &graphql.InputObjectFieldConfig{Type: graphql.String},
+	},
+})
+
+var queryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.Fields{
+		"item": &graphql.Field{
+			Type: itemType,
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				item, err := ToDoItemDao.RetrieveOne(p.Context, p.Args["id"].(string))
+				if err != nil {
+					if errors.Is(err, dao.ErrNotFound) {
+						return nil, nil
+					}
+					return nil, Error(problem.From(err))
+				}
+				return item, nil
+			},
+		},
+		"items": &graphql.Field{
+			Type:        graphql.NewNonNull(pageType),
+			Description: "Items sorted and filtered like GET /todo/, further filtered by completed, list and tag, and paginated.",
+			Args: graphql.FieldConfigArgument{
+				"attrib":    &graphql.ArgumentConfig{Type: graphql.String, Description: "title, completed, createdAt or deadline"},
+				"sort":      &graphql.ArgumentConfig{Type: graphql.String, Description: "asc, desc, 1 or -1"},
+				"before":    &graphql.ArgumentConfig{Type: timestamp},
+				"after":     &graphql.ArgumentConfig{Type: timestamp},
+				"start":     &graphql.ArgumentConfig{Type: timestamp},
+				"end":       &graphql.ArgumentConfig{Type: timestamp},
+				"completed": &graphql.ArgumentConfig{Type: graphql.Boolean},
+				"list":      &graphql.ArgumentConfig{Type: graphql.String},
+				"tag":       &graphql.ArgumentConfig{Type: graphql.String},
+				"offset":    &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				"limit":     &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultLimit},
+			},
+			Resolve: resolveItems,
+		},
+		"lists": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+			Description: "The names of all lists in use.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return distinct(p.Context, "list")
+			},
+		},
+		"tags": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+			Description: "All tags in use.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return distinct(p.Context, "tags")
+			},
+		},
+		"history": &graphql.Field{
+			Type: graphql.NewNonNull(historyType),
+			Description: "The latest change to every item, in the order they were made, from the change sequence " +
+				"that offline clients sync from: an UPDATED change with the item as last written, or a DELETED change. " +
+				"Earlier writes to an item are not kept.",
+			Args: graphql.FieldConfigArgument{
+				"after": &graphql.ArgumentConfig{Type: graphql.String, Description: "The cursor of the previous page; from the start if omitted"},
+				"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultLimit},
+			},
+			Resolve: resolveHistory,
+		},
+	},
+})
+
+var mutationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Mutation",
+	Fields: graphql.Fields{
+		"createItem": &graphql.Field{
+			Type: graphql.NewNonNull(itemType),
+			Args: graphql.FieldConfigArgument{
+				"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(itemInput)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				item := &models.ToDoItem{}
+				apply(item, p.Args["input"].(map[string]interface{}))
+
+				result, err := ToDoItemDao.Create(p.Context, item)
+				if err != nil {
+					return nil, Error(problem.From(err))
+				}
+				return retrieve(p.Context, result.InsertedID)
+			},
+		},
+		"updateItem": &graphql.Field{
+			Type: graphql.NewNonNull(itemType),
+			Args: graphql.FieldConfigArgument{
+				"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(itemInput)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return update(p.Context, p.Args["id"].(string), func(item *models.ToDoItem) {
+					apply(item, p.Args["input"].(map[string]interface{}))
+				})
+			},
+		},
+		"completeItem": &graphql.Field{
+			Type: graphql.NewNonNull(itemType),
+			Args: graphql.FieldConfigArgument{
+				"id":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"completed": &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: true},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return update(p.Context, p.Args["id"].(string), func(item *models.ToDoItem) {
+					item.Completed = p.Args["completed"].(bool)
+				})
+			},
+		},
+		"deleteItem": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Boolean),
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if _, err := ToDoItemDao.DeleteOne(p.Context, p.Args["id"].(string)); err != nil {
+					return nil, Error(problem.From(err))
+				}
+				return true, nil
+			},
+		},
+	},
+})
+
+var subscriptionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Subscription",
+	Fields: graphql.Fields{
+		"itemChanged": &graphql.Field{
+			Type:        graphql.NewNonNull(changeType),
+			Description: "Every change to an item from the time of subscribing.",
+			Subscribe: func(p graphql.ResolveParams) (interface{}, error) {
+				changes, unsubscribe := events.Subscribe()
+				payloads := make(chan interface{})
+
+				go func() {
+					defer close(payloads)
+					defer unsubscribe()
+
+					for {
+						select {
+						case <-p.Context.Done():
+							return
+						case change, ok := <-changes:
+							if !ok {
+								return
+							}
+							select {
+							case payloads <- change:
+							case <-p.Context.Done():
+								return
+							}
+						}
+					}
+				}()
+
+				return payloads, nil
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source, nil
+			},
+		},
+	},
+})
+
+func init() {
+	var err error
+	Schema, err = graphql.NewSchema(graphql.SchemaConfig{
+		Query:        queryType,
+		Mutation:     mutationType,
+		Subscription: subscriptionType,
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// resolveItems resolves the items query.
+func resolveItems(p graphql.ResolveParams) (interface{}, error) {
+	params := url.Values{}
+	for _, name := range []string{"attrib", "sort"} {
+		if value, ok := p.Args[name].(string); ok && value != "" {
+			params.Set(name, value)
+		}
+	}
+	for _, name := range []string{"before", "after", "start", "end"} {
+		if value, ok := p.Args[name].(int64); ok {
+			params.Set(name, strconv.FormatInt(value, 10))
+		}
+	}
+
+	list, errorResponse := listing.Parse(params)
+	if errorResponse != nil {
+		return nil, Error(errorResponse)
+	}
+
+	var completed *bool
+	if value, ok := p.Args["completed"].(bool); ok {
+		completed = &value
+	}
+	name, _ := p.Args["list"].(string)
+	tag, _ := p.Args["tag"].(string)
+
+	offset, _ := p.Args["offset"].(int)
+	limit, _ := p.Args["limit"].(int)
+	if offset < 0 || limit < 0 || limit > maxLimit {
+		return nil, errors.New("offset must not be negative, and limit must be between 0 and " + strconv.Itoa(maxLimit))
+	}
+
+	items, total, err := list.Where(completed, name, tag).Page(p.Context, int64(offset), int64(limit))
+	if err != nil {
+		return nil, Error(problem.From(err))
+	}
+
+	pageItems := make([]*models.ToDoItem, len(items))
+	for i := range items {
+		pageItems[i] = &items[i]
+	}
+
+	return map[string]interface{}{
+		"items":      pageItems,
+		"totalCount": int(total),
+		"offset":     offset,
+		"limit":      limit,
+		"hasMore":    int64(offset+limit) < total,
+	}, nil
+}
+
+// resolveHistory resolves the history query.
+func resolveHistory(p graphql.ResolveParams) (interface{}, error) {
+	var after int64
+	if cursor, ok := p.Args["after"].(string); ok && cursor != "" {
+		var err error
+		if after, err = strconv.ParseInt(cursor, 10, 64); err != nil || after < 0 {
+			return nil, errors.New("after must be the cursor of a page of history")
+		}
+	}
+	limit, _ := p.Args["limit"].(int)
+	if limit < 0 || limit > maxLimit {
+		return nil, errors.New("limit must be between 0 and " + strconv.Itoa(maxLimit))
+	}
+
+	// Changes are only read up to the point every write before has finished, so that none is skipped
+	stable, err := SequenceDao.Stable(p.Context)
+	if err != nil {
+		return nil, Error(problem.From(err))
+	}
+	if after > stable {
+		return nil, errors.New("after is not a cursor of this history, which must be read again from the start")
+	}
+
+	items, err := ToDoItemDao.RetrieveChangedAfter(p.Context, after, stable, int64(limit)+1)
+	if err != nil {
+		return nil, Error(problem.From(err))
+	}
+	tombstones, err := TombstoneDao.RetrieveAfter(p.Context, after, stable, int64(limit)+1)
+	if err != nil {
+		return nil, Error(problem.From(err))
+	}
+
+	// Both are in sequence order, and are merged until the page is full
+	changes := []models.Change{}
+	last := after
+	i, j := 0, 0
+	for i+j < limit && (i < len(items) || j < len(tombstones)) {
+		if j == len(tombstones) || (i < len(items) && items[i].Sequence < tombstones[j].Sequence) {
+			changes = append(changes, models.Change{Type: models.ChangeUpdated, ID: items[i].ID, Item: &items[i], Timestamp: items[i].UpdatedAt})
+			last = items[i].Sequence
+			i++
+		} else {
+			changes = append(changes, models.Change{Type: models.ChangeDeleted, ID: tombstones[j].ItemID, Timestamp: tombstones[j].DeletedAt})
+			last = tombstones[j].Sequence
+			j++
+		}
+	}
+
+	hasMore := i < len(items) || j < len(tombstones)
+	if !hasMore {
+		last = stable
+	}
+	return map[string]interface{}{
+		"changes": changes,
+		"cursor":  strconv.FormatInt(last, 10),
+		"hasMore": hasMore,
+	}, nil
+}
+
+// update applies a change to the stored item with the given ID, validates and saves it, and returns the result.
+func update(ctx context.Context, id string, change func(item *models.ToDoItem)) (interface{}, error) {
+	item, err := ToDoItemDao.RetrieveOne(ctx, id)
+	if err != nil {
+		return nil, Error(problem.From(err))
+	}
+
+	change(item)
+
+	if err = ToDoItemDao.Validate(item); err != nil {
+		return nil, Error(problem.From(err))
+	}
+	if _, err = ToDoItemDao.UpdateOne(ctx, id, item); err != nil {
+		return nil, Error(problem.From(err))
+	}
+
+	return item, nil
+}
+
+// retrieve returns the stored item with the given inserted ID.
+func retrieve(ctx context.Context, insertedID interface{}) (interface{}, error) {
+	id, ok := insertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, errors.New("unexpected inserted ID")
+	}
+
+	item, err := ToDoItemDao.RetrieveOne(ctx, id.Hex())
+	if err != nil {
+		return nil, Error(problem.From(err))
+	}
+	return item, nil
+}
+
+// apply copies the fields present in a ToDoItemInput onto an item. Fields given as null are cleared.
+func apply(item *models.ToDoItem, input map[string]interface{}) {
+	for name, value := range input {
+		switch name {
+		case "title":
+			item.Title, _ = value.(string)
+		case "completed":
+			item.Completed, _ = value.(bool)
+		case "deadline":
+			item.Deadline, _ = value.(int64)
+		case "list":
+			item.List, _ = value.(string)
+		case "tags":
+			item.Tags = nil
+			values, _ := value.([]interface{})
+			for _, tag := range values {
+				item.Tags = append(item.Tags, tag.(string))
+			}
+		case "priority":
+			item.Priority, _ = value.(string)
+		case "recurrence":
+			item.Recurrence, _ = value.(string)
+		case "externalId":
+			item.ExternalID, _ = value.(string)
+		}
+	}
+}
+
+func distinct(ctx context.Context, field string) (interface{}, error) {
+	values, err := ToDoItemDao.RetrieveDistinct(ctx, field)
+	if err != nil {
+		return nil, Error(problem.From(err))
+	}
+	return values, nil
+}
+
+// field returns a field resolved from a ToDoItem source.
+func field(fieldType graphql.Output, get func(item *models.ToDoItem) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(*models.ToDoItem)), nil
+		},
+	}
+}
+
+// optional returns nil for unset timestamps, so that they are null rather than 0.
+func optional(millis int64) interface{} {
+	if millis == 0 {
+		return nil
+	}
+	return millis
+}
+
+// Error converts an ErrorResponse into a GraphQL error.
+func Error(errorResponse *models.ErrorResponse) error {
+	return errors.New(problem.Summary(errorResponse))
+}
@@ -0,0 +1,124 @@
+// Package health checks whether the server and the dependencies it needs are working, for liveness and readiness
+// probes.
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/buildinfo"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"go.uber.org/zap"
+)
+
+// Timeout is how long each dependency has to answer a check.
+const Timeout = 2 * time.Second
+
+// Check checks a dependency, returning an error if it is not usable.
+type Check func(ctx context.Context) error
+
+var (
+	mutex  sync.Mutex
+	checks = map[string]Check{}
+
+	started = time.Now()
+	// shuttingDown is 1 once the server is shutting down
+	shuttingDown int32
+)
+
+// Register adds a dependency to check, replacing any check with the same name.
+func Register(name string, check Check) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	checks[name] = check
+}
+
+// SetShuttingDown marks the server as shutting down, so that it is no longer ready.
+func SetShuttingDown() {
+	atomic.StoreInt32(&shuttingDown, 1)
+}
+
+// Live describes the server without checking any dependency, for liveness probes: a failing dependency is no reason
+// to restart the server. The status is HealthShuttingDown once SetShuttingDown has been called, and HealthOK otherwise.
+func Live() models.HealthReport {
+	report := models.HealthReport{
+		Status: models.HealthOK,
+		Build:  buildinfo.Get(),
+		Uptime: time.Since(started).Round(time.Second).String(),
+	}
+	if atomic.LoadInt32(&shuttingDown) == 1 {
+		report.Status = models.HealthShuttingDown
+	}
+
+	return report
+}
+
+// Report checks every dependency concurrently, each with Timeout, and describes the server's health.
+// The status is HealthShuttingDown once SetShuttingDown has been called, HealthFailing if a dependency failed, and
+// HealthOK otherwise. Why a dependency failed is logged rather than reported, as it may reveal details of the
+// dependency.
+func Report(ctx context.Context) models.HealthReport {
+	mutex.Lock()
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	registered := make(map[string]Check, len(checks))
+	for name, check := range checks {
+		registered[name] = check
+	}
+	mutex.Unlock()
+	sort.Strings(names)
+
+	results := make([]models.DependencyHealth, len(names))
+	var wait sync.WaitGroup
+	for i, name := range names {
+		wait.Add(1)
+		go func(i int, name string) {
+			defer wait.Done()
+			results[i] = run(ctx, name, registered[name])
+		}(i, name)
+	}
+	wait.Wait()
+
+	report := models.HealthReport{
+		Status:       models.HealthOK,
+		Build:        buildinfo.Get(),
+		Uptime:       time.Since(started).Round(time.Second).String(),
+		Dependencies: map[string]models.DependencyHealth{},
+	}
+	for i, name := range names {
+		report.Dependencies[name] = results[i]
+		if results[i].Status != models.HealthOK {
+			report.Status = models.HealthFailing
+		}
+	}
+	if atomic.LoadInt32(&shuttingDown) == 1 {
+		report.Status = models.HealthShuttingDown
+	}
+
+	return report
+}
+
+// run runs the check of the named dependency with Timeout and times it.
+func run(ctx context.Context, name string, check Check) models.DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := models.DependencyHealth{
+		Status:    models.HealthOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = models.HealthFailing
+		logging.FromContext(ctx).Warn("Health: Check failed", zap.String("dependency", name), zap.Error(err))
+	}
+	return result
+}
@@ -0,0 +1,327 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Property is a single iCalendar content line, e.g. "DUE;TZID=Europe/Berlin:20261020T170000".
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Component is an iCalendar component such as VCALENDAR or VTODO, with its properties and sub-components.
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []*Component
+}
+
+// Get returns the first property with the given name, or nil if there is none.
+func (c *Component) Get(name string) *Property {
+	for i := range c.Properties {
+		if c.Properties[i].Name == name {
+			return &c.Properties[i]
+		}
+	}
+	return nil
+}
+
+// Value returns the value of the first property with the given name, or "" if there is none.
+func (c *Component) Value(name string) string {
+	if p := c.Get(name); p != nil {
+		return p.Value
+	}
+	return ""
+}
+
+// Set appends a property with the given name and value. Empty values are ignored.
+func (c *Component) Set(name string, value string) {
+	if value == "" {
+		return
+	}
+	c.Properties = append(c.Properties, Property{Name: name, Value: value})
+}
+
+// Children returns the direct sub-components with the given name.
+func (c *Component) Children(name string) []*Component {
+	var children []*Component
+	for _, child := range c.Components {
+		if child.Name == name {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// Encoder writes iCalendar content lines, folding them at 75 octets as required by RFC 5545.
+type Encoder struct {
+	w   io.Writer
+	err error
+}
+
+// NewEncoder returns an Encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Begin writes the BEGIN line of a component.
+func (e *Encoder) Begin(name string) error {
+	return e.line("BEGIN:" + name)
+}
+
+// End writes the END line of a component.
+func (e *Encoder) End(name string) error {
+	return e.line("END:" + name)
+}
+
+// Property writes a single property.
+func (e *Encoder) Property(p Property) error {
+	var b strings.Builder
+	b.WriteString(p.Name)
+
+	// Parameters are written in a stable order so that identical components encode identically
+	keys := make([]string, 0, len(p.Params))
+	for key := range p.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := p.Params[key]
+		b.WriteString(";" + key + "=")
+		if strings.ContainsAny(value, ":;,") {
+			b.WriteString(`"` + value + `"`)
+		} else {
+			b.WriteString(value)
+		}
+	}
+	b.WriteString(":" + p.Value)
+	return e.line(b.String())
+}
+
+// Component writes a component and all of its sub-components.
+func (e *Encoder) Component(c *Component) error {
+	e.Begin(c.Name)
+	for _, p := range c.Properties {
+		e.Property(p)
+	}
+	for _, child := range c.Components {
+		e.Component(child)
+	}
+	return e.End(c.Name)
+}
+
+// line writes a content line, folding it if it is longer than 75 octets.
+// Once a write has failed, all further writes are skipped and the error is returned.
+func (e *Encoder) line(s string) error {
+	if e.err != nil {
+		return e.err
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+
+	_, e.err = io.WriteString(e.w, b.String())
+	return e.err
+}
+
+// Decode reads iCalendar data and returns the first top-level component, usually a VCALENDAR.
+func Decode(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var stack []*Component
+	for number, line := range lines {
+		property, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number+1, err)
+		}
+
+		switch property.Name {
+		case "BEGIN":
+			component := &Component{Name: strings.ToUpper(property.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, component)
+			}
+			stack = append(stack, component)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(property.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", number+1, property.Value)
+			}
+			if len(stack) == 1 {
+				return stack[0], nil
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property outside of a component", number+1)
+			}
+			current := stack[len(stack)-1]
+			current.Properties = append(current.Properties, property)
+		}
+	}
+
+	return nil, errors.New("unexpected end of iCalendar data")
+}
+
+// unfold reads all content lines, joining folded continuation lines.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseLine splits a content line into its name, parameters and value.
+func parseLine(line string) (Property, error) {
+	property := Property{}
+
+	// Find the colon separating the value, skipping colons inside quoted parameter values
+	quoted := false
+	separator := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			separator = i
+			break
+		}
+	}
+	if separator < 0 {
+		return property, errors.New("missing ':' in content line")
+	}
+	property.Value = line[separator+1:]
+
+	parts := splitUnquoted(line[:separator], ';')
+	property.Name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		key, value, found := strings.Cut(param, "=")
+		if !found {
+			continue
+		}
+		if property.Params == nil {
+			property.Params = map[string]string{}
+		}
+		property.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+
+	return property, nil
+}
+
+// splitUnquoted splits s on sep, ignoring separators inside double quotes.
+func splitUnquoted(s string, sep rune) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i, r := range s {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == sep && !quoted {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// EscapeText escapes a TEXT value.
+func EscapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// UnescapeText reverses EscapeText.
+func UnescapeText(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
+
+// SplitText splits a multi-valued TEXT value such as CATEGORIES on unescaped commas and unescapes each value.
+func SplitText(s string) []string {
+	var values []string
+	var current strings.Builder
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune('\\')
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ',':
+			values = append(values, UnescapeText(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(values, UnescapeText(current.String()))
+}
+
+// FormatDateTime formats a Unix millisecond timestamp as a UTC DATE-TIME value.
+func FormatDateTime(millis int64) string {
+	return time.UnixMilli(millis).UTC().Format("20060102T150405Z")
+}
+
+// FormatDate formats a Unix millisecond timestamp as a DATE value in the given location.
+func FormatDate(millis int64, location *time.Location) string {
+	return time.UnixMilli(millis).In(location).Format("20060102")
+}
+
+// ParseTime parses a DATE or DATE-TIME property into a Unix millisecond timestamp.
+// Floating times and dates are interpreted in the given location, unless the property has a TZID parameter.
+func ParseTime(p *Property, location *time.Location) (int64, error) {
+	if tzid := p.Params["TZID"]; tzid != "" {
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			location = loc
+		}
+	}
+
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		var t time.Time
+		var err error
+		if strings.HasSuffix(layout, "Z") {
+			t, err = time.Parse(layout, p.Value)
+		} else {
+			t, err = time.ParseInLocation(layout, p.Value, location)
+		}
+		if err == nil {
+			return t.UnixMilli(), nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid date or date-time %q in %s", p.Value, p.Name)
+}
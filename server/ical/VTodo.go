@@ -0,0 +1,179 @@
+package ical
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProductID is the PRODID written to every calendar produced by this server.
+const ProductID = "-//L4TTiCe//ToDo-Go//EN"
+
+// uidDomain is appended to ObjectIDs to form globally unique UIDs.
+const uidDomain = "@todo-go"
+
+// ObjectUID returns a stable UID derived from a ToDoItem's ObjectID.
+func ObjectUID(id primitive.ObjectID) string {
+	return id.Hex() + uidDomain
+}
+
+// ParseObjectUID returns the ObjectID a UID was derived from by ObjectUID, or false if it was not.
+func ParseObjectUID(uid string) (primitive.ObjectID, bool) {
+	if !strings.HasSuffix(uid, uidDomain) {
+		return primitive.NilObjectID, false
+	}
+	id, err := primitive.ObjectIDFromHex(strings.TrimSuffix(uid, uidDomain))
+	return id, err == nil
+}
+
+// UID returns the UID to use for a ToDoItem: its ExternalID if it was imported from another tool,
+// or a UID derived from its ObjectID otherwise.
+func UID(item *models.ToDoItem) string {
+	if item.ExternalID != "" {
+		return item.ExternalID
+	}
+	return ObjectUID(item.ID)
+}
+
+// NewCalendar returns an empty VCALENDAR component.
+func NewCalendar() *Component {
+	calendar := &Component{Name: "VCALENDAR"}
+	calendar.Set("VERSION", "2.0")
+	calendar.Set("PRODID", ProductID)
+	return calendar
+}
+
+// FromItem converts a ToDoItem into a VTODO component with the given UID.
+func FromItem(item *models.ToDoItem, uid string) *Component {
+	todo := &Component{Name: "VTODO"}
+	todo.Set("UID", uid)
+	todo.Set("DTSTAMP", FormatDateTime(item.CreatedAt))
+	todo.Set("CREATED", FormatDateTime(item.CreatedAt))
+	todo.Set("SUMMARY", EscapeText(item.Title))
+
+	if item.UpdatedAt != 0 {
+		todo.Set("LAST-MODIFIED", FormatDateTime(item.UpdatedAt))
+	}
+
+	if item.Completed {
+		todo.Set("STATUS", "COMPLETED")
+		if item.CompletedAt != 0 {
+			todo.Set("COMPLETED", FormatDateTime(item.CompletedAt))
+		}
+	} else {
+		todo.Set("STATUS", "NEEDS-ACTION")
+	}
+
+	if item.Deadline != 0 {
+		todo.Set("DUE", FormatDateTime(item.Deadline))
+	}
+
+	todo.Set("CATEGORIES", categories(item.Tags))
+
+	switch item.Priority {
+	case models.PriorityHigh:
+		todo.Set("PRIORITY", "1")
+	case models.PriorityMedium:
+		todo.Set("PRIORITY", "5")
+	case models.PriorityLow:
+		todo.Set("PRIORITY", "9")
+	}
+
+	todo.Set("RRULE", item.Recurrence)
+
+	return todo
+}
+
+// ToItem converts a VTODO (or VEVENT) component into a ToDoItem. The component's UID is kept as the ExternalID.
+// Floating dates and times are interpreted in the given location.
+func ToItem(component *Component, location *time.Location) (models.ToDoItem, error) {
+	item := models.ToDoItem{
+		ExternalID: component.Value("UID"),
+		Title:      UnescapeText(component.Value("SUMMARY")),
+		Completed:  strings.EqualFold(component.Value("STATUS"), "COMPLETED") || component.Get("COMPLETED") != nil,
+		Recurrence: component.Value("RRULE"),
+	}
+
+	due := component.Get("DUE")
+	if due == nil && component.Name == "VEVENT" {
+		due = component.Get("DTSTART")
+	}
+	if due != nil {
+		deadline, err := ParseTime(due, location)
+		if err != nil {
+			return item, err
+		}
+		item.Deadline = deadline
+	}
+
+	if completed := component.Get("COMPLETED"); completed != nil {
+		completedAt, err := ParseTime(completed, location)
+		if err != nil {
+			return item, err
+		}
+		item.CompletedAt = completedAt
+	}
+
+	if created := component.Get("CREATED"); created != nil {
+		createdAt, err := ParseTime(created, location)
+		if err != nil {
+			return item, err
+		}
+		item.CreatedAt = createdAt
+	}
+
+	for _, categories := range component.Properties {
+		if categories.Name != "CATEGORIES" {
+			continue
+		}
+		for _, tag := range SplitText(categories.Value) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				item.Tags = append(item.Tags, tag)
+			}
+		}
+	}
+
+	if priority, err := strconv.Atoi(component.Value("PRIORITY")); err == nil {
+		switch {
+		case priority >= 1 && priority <= 4:
+			item.Priority = models.PriorityHigh
+		case priority == 5:
+			item.Priority = models.PriorityMedium
+		case priority >= 6 && priority <= 9:
+			item.Priority = models.PriorityLow
+		}
+	}
+
+	return item, nil
+}
+
+// DueEvent converts a ToDoItem with a deadline into an all-day VEVENT on the day it is due, in the given location.
+func DueEvent(item *models.ToDoItem, uid string, location *time.Location) *Component {
+	due := time.UnixMilli(item.Deadline).In(location)
+
+	event := &Component{Name: "VEVENT"}
+	event.Set("UID", uid)
+	event.Set("DTSTAMP", FormatDateTime(item.CreatedAt))
+	event.Set("SUMMARY", EscapeText(item.Title))
+	event.Properties = append(event.Properties,
+		Property{Name: "DTSTART", Params: map[string]string{"VALUE": "DATE"}, Value: FormatDate(item.Deadline, location)},
+		Property{Name: "DTEND", Params: map[string]string{"VALUE": "DATE"}, Value: due.AddDate(0, 0, 1).Format("20060102")},
+	)
+	event.Set("TRANSP", "TRANSPARENT")
+
+	event.Set("CATEGORIES", categories(item.Tags))
+
+	return event
+}
+
+// categories returns the CATEGORIES value for a list of tags, or "" if there are none.
+func categories(tags []string) string {
+	escaped := make([]string, len(tags))
+	for i, tag := range tags {
+		escaped[i] = EscapeText(tag)
+	}
+	return strings.Join(escaped, ",")
+}
@@ -0,0 +1,196 @@
+// Package idempotency lets clients retry requests that create things without creating them twice.
+//
+// A client sends a unique Idempotency-Key header with the request. The first request with a key is handled as usual
+// and its response is stored; retries with the same key receive the stored response, marked with an
+// Idempotent-Replayed header, until the key expires. A key reused for a different request is rejected with 422, and
+// a retry sent while the first request is still being handled is rejected with 409.
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/controller"
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/IdempotencyDao"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Header is the request header holding the key, and ReplayedHeader the response header marking a replay.
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// DefaultTTL is how long keys are remembered unless configured otherwise.
+const DefaultTTL = 24 * time.Hour
+
+// maxKeyLength is the length of the longest key accepted.
+const maxKeyLength = 255
+
+// maxStoredBody is the largest response body that is stored. Requests with larger responses can be retried, and
+// are then handled again.
+const maxStoredBody = 1 << 20
+
+// maxRequestBody is the largest request body that is fingerprinted, which covers the largest import.
+const maxRequestBody = 10 << 20
+
+// ttl is how long keys are remembered.
+var ttl = DefaultTTL
+
+// SetTTL configures how long keys are remembered. A ttl that is not positive leaves DefaultTTL in place.
+func SetTTL(configured time.Duration) {
+	ttl = DefaultTTL
+	if configured > 0 {
+		ttl = configured
+	}
+}
+
+// TTL returns how long keys are remembered.
+func TTL() time.Duration {
+	return ttl
+}
+
+// Middleware makes the routes it is added to honour the Idempotency-Key header, remembering keys for ttl.
+// Requests without the header are handled as usual.
+func Middleware(ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(Header)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			abort(c, problem.InvalidRequest.New("The Idempotency-Key header must be at most 255 characters long"))
+			return
+		}
+
+		fingerprint, err := fingerprint(c)
+		if err != nil {
+			abort(c, problem.InvalidRequest.New("Error reading the request body: "+err.Error()))
+			return
+		}
+
+		record := &models.IdempotencyRecord{
+			Key:         key,
+			Fingerprint: fingerprint,
+			CreatedAt:   time.Now().UnixMilli(),
+			ExpiresAt:   time.Now().Add(ttl),
+		}
+
+		existing, err := IdempotencyDao.Begin(c.Request.Context(), record)
+		if err != nil && !errors.Is(err, dao.ErrConflict) {
+			abort(c, problem.From(err))
+			return
+		}
+		if existing != nil {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				abort(c, problem.IdempotencyKeyReused.New("The key was first used for a different request"))
+			case !existing.Completed:
+				abort(c, problem.Conflict.New("A request with the same Idempotency-Key is still being handled"))
+			default:
+				replay(c, existing)
+			}
+			return
+		}
+
+		// The outcome is stored even if the client has gone away, so that its retry finds it
+		store := dao.Detach(c.Request.Context())
+
+		recorder := &recorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors and abandoned requests are not remembered, so that the request can be retried
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError || status == problem.StatusClientClosedRequest || recorder.overflow {
+			if recorder.overflow {
+				logging.FromContext(c.Request.Context()).Warn("Not storing response to idempotent request: body too large")
+			}
+			if err := IdempotencyDao.Release(store, key); err != nil {
+				logging.FromContext(c.Request.Context()).Error("Releasing idempotency key failed", zap.Error(err))
+			}
+			return
+		}
+
+		record.Status = status
+		record.ContentType = recorder.Header().Get("Content-Type")
+		record.Location = recorder.Header().Get("Location")
+		record.Body = recorder.body.Bytes()
+		if err := IdempotencyDao.Complete(store, record); err != nil {
+			// The client has its response; a retry will find the key unusable until it expires
+			logging.FromContext(c.Request.Context()).Error("Storing idempotent response failed", zap.Error(err))
+		}
+	}
+}
+
+// fingerprint hashes the method, URL and body of a request, and puts the body back for the handler to read.
+func fingerprint(c *gin.Context) (string, error) {
+	hash := sha256.New()
+	io.WriteString(hash, c.Request.Method+" "+c.Request.URL.RequestURI()+"\n")
+
+	if c.Request.Body != nil {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxRequestBody+1))
+		if err != nil {
+			return "", err
+		}
+		hash.Write(body)
+		c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// replay sends a stored response.
+func replay(c *gin.Context, record *models.IdempotencyRecord) {
+	c.Header(ReplayedHeader, "true")
+	if record.Location != "" {
+		c.Header("Location", record.Location)
+	}
+	c.Data(record.Status, record.ContentType, record.Body)
+	c.Abort()
+}
+
+func abort(c *gin.Context, errorResponse *models.ErrorResponse) {
+	controller.PopulateErrorResponse(c, errorResponse)
+	c.AbortWithStatusJSON(errorResponse.Status, errorResponse)
+}
+
+// recorder keeps a copy of the response body written through it, up to maxStoredBody.
+type recorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (r *recorder) Write(data []byte) (int, error) {
+	r.record(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *recorder) WriteString(s string) (int, error) {
+	r.record([]byte(s))
+	return r.ResponseWriter.WriteString(s)
+}
+
+func (r *recorder) record(data []byte) {
+	if r.overflow {
+		return
+	}
+	if r.body.Len()+len(data) > maxStoredBody {
+		r.overflow = true
+		r.body.Reset()
+		return
+	}
+	r.body.Write(data)
+}
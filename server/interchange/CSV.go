@@ -0,0 +1,203 @@
+package interchange
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+// csvColumns are the columns written by the CSV encoder, and the field names understood by the decoder.
+var csvColumns = []string{"id", "title", "completed", "createdAt", "deadline", "list", "tags", "priority", "recurrence"}
+
+// csvTagSeparator separates tags within the tags column.
+const csvTagSeparator = ";"
+
+// csvEncoder writes items as CSV with a header row. Timestamps are written as RFC 3339.
+type csvEncoder struct {
+	w      *csv.Writer
+	header bool
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) Encode(item *models.ToDoItem) error {
+	if !e.header {
+		e.header = true
+		if err := e.w.Write(csvColumns); err != nil {
+			return err
+		}
+	}
+
+	err := e.w.Write([]string{
+		externalID(item),
+		item.Title,
+		strconv.FormatBool(item.Completed),
+		formatTimestamp(item.CreatedAt),
+		formatTimestamp(item.Deadline),
+		item.List,
+		strings.Join(item.Tags, csvTagSeparator),
+		item.Priority,
+		item.Recurrence,
+	})
+	if err != nil {
+		return err
+	}
+
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) Close() error {
+	if !e.header {
+		e.header = true
+		e.w.Write(csvColumns)
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// decodeCSV reads CSV with a header row. Columns are matched to fields by name, case-insensitively, after mapping.
+func decodeCSV(r io.Reader, mapping Mapping, location *time.Location) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read CSV header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	// column returns the index of the column holding the given field, or -1
+	column := func(field string) int {
+		if index, ok := columns[strings.ToLower(mapping.source(field))]; ok {
+			return index
+		}
+		return -1
+	}
+
+	indices := map[string]int{}
+	for _, field := range append(csvColumns, "externalId") {
+		indices[field] = column(field)
+	}
+	if indices["title"] < 0 {
+		return nil, errors.New("CSV header must contain a title column")
+	}
+
+	var rows []Row
+	for number := 1; ; number++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		row := Row{Number: number}
+		if err != nil {
+			// Malformed quoting is reported for the record, and reading continues with the next one
+			var parseError *csv.ParseError
+			if !errors.As(err, &parseError) {
+				return nil, err
+			}
+			row.Err = err
+			rows = append(rows, row)
+			continue
+		}
+
+		value := func(field string) string {
+			index := indices[field]
+			if index < 0 || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		row.Item, row.Err = csvItem(value, location)
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// csvItem converts the values of a CSV record into a ToDoItem.
+func csvItem(value func(field string) string, location *time.Location) (models.ToDoItem, error) {
+	item := models.ToDoItem{
+		ExternalID: value("externalId"),
+		Title:      value("title"),
+		List:       value("list"),
+		Priority:   strings.ToLower(value("priority")),
+		Recurrence: value("recurrence"),
+	}
+
+	if item.ExternalID == "" {
+		item.ExternalID = value("id")
+	}
+
+	if completed := value("completed"); completed != "" {
+		switch strings.ToLower(completed) {
+		case "x", "yes", "y", "done":
+			item.Completed = true
+		case "no", "n":
+			item.Completed = false
+		default:
+			parsed, err := strconv.ParseBool(completed)
+			if err != nil {
+				return item, fmt.Errorf("invalid completed value %q", completed)
+			}
+			item.Completed = parsed
+		}
+	}
+
+	var err error
+	if item.CreatedAt, err = parseTimestamp(value("createdAt"), location); err != nil {
+		return item, fmt.Errorf("invalid createdAt: %w", err)
+	}
+	if item.Deadline, err = parseTimestamp(value("deadline"), location); err != nil {
+		return item, fmt.Errorf("invalid deadline: %w", err)
+	}
+
+	for _, tag := range strings.Split(value("tags"), csvTagSeparator) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			item.Tags = append(item.Tags, tag)
+		}
+	}
+
+	return item, nil
+}
+
+// formatTimestamp formats a Unix millisecond timestamp as RFC 3339, or "" if it is unset.
+func formatTimestamp(millis int64) string {
+	if millis == 0 {
+		return ""
+	}
+	return time.UnixMilli(millis).UTC().Format(time.RFC3339)
+}
+
+// parseTimestamp parses a Unix millisecond timestamp, an RFC 3339 timestamp or a YYYY-MM-DD date.
+// Dates without a time are due at the end of the day (23:59) in the given location.
+func parseTimestamp(s string, location *time.Location) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if millis, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return millis, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UnixMilli(), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, location); err == nil {
+		return endOfDay(t).UnixMilli(), nil
+	}
+	return 0, fmt.Errorf("%q is not a timestamp or date", s)
+}
@@ -0,0 +1,92 @@
+package interchange
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+func TestDecodeCSV(t *testing.T) {
+	document := strings.Join([]string{
+		`Name,Done,Due Date,Tags,Priority,externalId,id`,
+		`Pay rent,no,2022-07-01,home; bills,HIGH,,1`,
+		`Call mum,x,1656662400000,,,,`,
+		`Renew passport,maybe,,,,,`,
+		`Book flights,,next week,,,,`,
+		`Say "hello,,,,,,`,
+		`"Buy milk","yes",2022-07-01T09:00:00+02:00,,low,milk-1,2`,
+		`Short row`,
+	}, "\n")
+	mapping := Mapping{"title": "Name", "completed": "Done", "deadline": "Due Date"}
+
+	rows, err := Decode(CSV, strings.NewReader(document), mapping, berlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkRows(t, rows, []Row{
+		{Number: 1, Item: models.ToDoItem{
+			ExternalID: "1", Title: "Pay rent", Deadline: millis("2022-07-01T23:59:00+02:00"),
+			Tags: []string{"home", "bills"}, Priority: models.PriorityHigh,
+		}},
+		{Number: 2, Item: models.ToDoItem{Title: "Call mum", Completed: true, Deadline: 1656662400000}},
+		// Invalid values fail their row only
+		{Number: 3, Err: errInvalid},
+		{Number: 4, Err: errInvalid},
+		// So does malformed quoting
+		{Number: 5, Err: errInvalid},
+		// The externalId column takes precedence over id
+		{Number: 6, Item: models.ToDoItem{
+			ExternalID: "milk-1", Title: "Buy milk", Completed: true, Deadline: millis("2022-07-01T07:00:00Z"),
+			Priority: models.PriorityLow,
+		}},
+		{Number: 7, Item: models.ToDoItem{Title: "Short row"}},
+	})
+}
+
+func TestDecodeCSVDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		mapping  Mapping
+	}{
+		{name: "empty", document: ""},
+		{name: "no title column", document: "name,deadline\nPay rent,2022-07-01\n"},
+		{name: "title mapped away", document: "title,name\nPay rent,Rent\n", mapping: Mapping{"title": "summary"}},
+	}
+
+	for _, test := range tests {
+		if rows, err := Decode(CSV, strings.NewReader(test.document), test.mapping, berlin); err == nil {
+			t.Errorf("%s: Decode = %+v, want an error", test.name, rows)
+		}
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    int64
+		invalid bool
+	}{
+		{s: "", want: 0},
+		{s: "1656662400000", want: 1656662400000},
+		{s: "2022-07-01T09:00:00Z", want: millis("2022-07-01T09:00:00Z")},
+		{s: "2022-07-01T09:00:00+02:00", want: millis("2022-07-01T07:00:00Z")},
+		// Dates are due at the end of the day in the given location
+		{s: "2022-07-01", want: millis("2022-07-01T23:59:00+02:00")},
+		// Days on which daylight saving time starts or ends are 23 and 25 hours long
+		{s: "2022-03-27", want: millis("2022-03-27T23:59:00+02:00")},
+		{s: "2022-10-30", want: millis("2022-10-30T23:59:00+01:00")},
+		{s: "2022-02-30", invalid: true},
+		{s: "01/07/2022", invalid: true},
+		{s: "tomorrow", invalid: true},
+	}
+
+	for _, test := range tests {
+		got, err := parseTimestamp(test.s, berlin)
+		if (err != nil) != test.invalid || got != test.want {
+			t.Errorf("parseTimestamp(%q) = %d, %v, want %d", test.s, got, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package interchange
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+// Format is a file format that ToDoItems can be exported to and imported from.
+type Format string
+
+const (
+	JSON    Format = "json"
+	CSV     Format = "csv"
+	ICS     Format = "ics"
+	TodoTxt Format = "todotxt"
+)
+
+// ParseFormat returns the Format with the given name, or false if it is not supported.
+func ParseFormat(name string) (Format, bool) {
+	switch format := Format(strings.ToLower(name)); format {
+	case JSON, CSV, ICS, TodoTxt:
+		return format, true
+	}
+	return "", false
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case ICS:
+		return "text/calendar; charset=utf-8"
+	case TodoTxt:
+		return "text/plain; charset=utf-8"
+	}
+	return "application/json; charset=utf-8"
+}
+
+// Extension returns the file extension conventionally used for the format.
+func (f Format) Extension() string {
+	if f == TodoTxt {
+		return "txt"
+	}
+	return string(f)
+}
+
+// Encoder writes ToDoItems one at a time, so that large collections can be streamed.
+// Close must be called after the last item to terminate the document.
+type Encoder interface {
+	Encode(item *models.ToDoItem) error
+	Close() error
+}
+
+// NewEncoder returns an Encoder writing the given format to w.
+// Dates in formats that only carry a calendar date (todo.txt) are written in the given location.
+func NewEncoder(format Format, w io.Writer, location *time.Location) Encoder {
+	switch format {
+	case CSV:
+		return newCSVEncoder(w)
+	case ICS:
+		return newICSEncoder(w)
+	case TodoTxt:
+		return newTodoTxtEncoder(w, location)
+	}
+	return newJSONEncoder(w)
+}
+
+// Row is a single record read from an import, numbered from 1 in the order it appeared.
+// Err is set if the record could not be converted into a ToDoItem.
+type Row struct {
+	Number int
+	Item   models.ToDoItem
+	Err    error
+}
+
+// Mapping maps ToDoItem field names (as used in JSON) to the column or key names used in an imported file.
+type Mapping map[string]string
+
+// ParseMapping parses a mapping of the form "title:Name,deadline:Due Date".
+func ParseMapping(s string) (Mapping, error) {
+	mapping := Mapping{}
+	if s == "" {
+		return mapping, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		field, source, found := strings.Cut(pair, ":")
+		field, source = strings.TrimSpace(field), strings.TrimSpace(source)
+		if !found || field == "" || source == "" {
+			return nil, errors.New("mapping entries must be of the form field:source")
+		}
+		mapping[field] = source
+	}
+
+	return mapping, nil
+}
+
+// source returns the name under which a field is found in the imported file.
+func (m Mapping) source(field string) string {
+	if source, ok := m[field]; ok {
+		return source
+	}
+	return field
+}
+
+// Decode reads every record from r. Field mapping only applies to formats with named fields (JSON and CSV).
+// Floating dates are interpreted in the given location.
+// An error is returned only if the document as a whole cannot be read; per-record problems are reported in Row.Err.
+func Decode(format Format, r io.Reader, mapping Mapping, location *time.Location) ([]Row, error) {
+	switch format {
+	case CSV:
+		return decodeCSV(r, mapping, location)
+	case ICS:
+		return decodeICS(r, location)
+	case TodoTxt:
+		return decodeTodoTxt(r, location)
+	}
+	return decodeJSON(r, mapping)
+}
+
+// endOfDay returns 23:59 on the day of t, in t's location. Unlike adding 23h59m to midnight, it is right on days
+// that daylight saving time makes shorter or longer.
+func endOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 23, 59, 0, 0, t.Location())
+}
+
+// externalID returns the identifier written to exports: the ExternalID if the item was imported, or its ObjectID.
+func externalID(item *models.ToDoItem) string {
+	if item.ExternalID != "" {
+		return item.ExternalID
+	}
+	return item.ID.Hex()
+}
@@ -0,0 +1,281 @@
+package interchange
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var berlin = mustLoadLocation("Europe/Berlin")
+
+func mustLoadLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return location
+}
+
+// millis parses an RFC 3339 timestamp into Unix milliseconds.
+func millis(s string) int64 {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t.UnixMilli()
+}
+
+func mustObjectID(hex string) primitive.ObjectID {
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// exported are the items written by the round trip tests: one created on this server, one imported from elsewhere.
+var exported = []models.ToDoItem{
+	{
+		ID:         mustObjectID("62a9a5c0e4b0a1b2c3d4e5f6"),
+		Title:      "Submit expense report, Q2",
+		CreatedAt:  millis("2022-06-15T08:00:00Z"),
+		Deadline:   millis("2022-06-16T15:00:00Z"),
+		List:       "work",
+		Tags:       []string{"finance", "q2 review"},
+		Priority:   models.PriorityHigh,
+		Recurrence: "FREQ=MONTHLY",
+	},
+	{
+		ID:          mustObjectID("62a9a5c0e4b0a1b2c3d4e5f7"),
+		ExternalID:  "imported-1",
+		Title:       "Water plants",
+		Completed:   true,
+		CreatedAt:   millis("2022-06-01T09:30:00Z"),
+		CompletedAt: millis("2022-06-14T18:00:00Z"),
+		Priority:    models.PriorityLow,
+		Recurrence:  "FREQ=WEEKLY;INTERVAL=2",
+	},
+}
+
+// TestRoundTrip exports items and imports them again. Whatever a format cannot carry is lost, but the external ID
+// always refers back to the exported item, so that re-importing an export is detected as a duplicate.
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   []models.ToDoItem
+	}{
+		{
+			format: JSON,
+			want: []models.ToDoItem{
+				{
+					ExternalID: "62a9a5c0e4b0a1b2c3d4e5f6",
+					Title:      "Submit expense report, Q2",
+					CreatedAt:  millis("2022-06-15T08:00:00Z"),
+					Deadline:   millis("2022-06-16T15:00:00Z"),
+					List:       "work",
+					Tags:       []string{"finance", "q2 review"},
+					Priority:   models.PriorityHigh,
+					Recurrence: "FREQ=MONTHLY",
+				},
+				{
+					ExternalID:  "imported-1",
+					Title:       "Water plants",
+					Completed:   true,
+					CreatedAt:   millis("2022-06-01T09:30:00Z"),
+					CompletedAt: millis("2022-06-14T18:00:00Z"),
+					Priority:    models.PriorityLow,
+					Recurrence:  "FREQ=WEEKLY;INTERVAL=2",
+				},
+			},
+		},
+		{
+			format: CSV,
+			want: []models.ToDoItem{
+				{
+					ExternalID: "62a9a5c0e4b0a1b2c3d4e5f6",
+					Title:      "Submit expense report, Q2",
+					CreatedAt:  millis("2022-06-15T08:00:00Z"),
+					Deadline:   millis("2022-06-16T15:00:00Z"),
+					List:       "work",
+					Tags:       []string{"finance", "q2 review"},
+					Priority:   models.PriorityHigh,
+					Recurrence: "FREQ=MONTHLY",
+				},
+				{
+					ExternalID: "imported-1",
+					Title:      "Water plants",
+					Completed:  true,
+					CreatedAt:  millis("2022-06-01T09:30:00Z"),
+					Priority:   models.PriorityLow,
+					Recurrence: "FREQ=WEEKLY;INTERVAL=2",
+				},
+			},
+		},
+		{
+			format: ICS,
+			want: []models.ToDoItem{
+				{
+					ExternalID: "62a9a5c0e4b0a1b2c3d4e5f6",
+					Title:      "Submit expense report, Q2",
+					CreatedAt:  millis("2022-06-15T08:00:00Z"),
+					Deadline:   millis("2022-06-16T15:00:00Z"),
+					Tags:       []string{"finance", "q2 review"},
+					Priority:   models.PriorityHigh,
+					Recurrence: "FREQ=MONTHLY",
+				},
+				{
+					ExternalID:  "imported-1",
+					Title:       "Water plants",
+					Completed:   true,
+					CreatedAt:   millis("2022-06-01T09:30:00Z"),
+					CompletedAt: millis("2022-06-14T18:00:00Z"),
+					Priority:    models.PriorityLow,
+					Recurrence:  "FREQ=WEEKLY;INTERVAL=2",
+				},
+			},
+		},
+		{
+			// todo.txt only carries dates, and no creation date on completed tasks
+			format: TodoTxt,
+			want: []models.ToDoItem{
+				{
+					ExternalID: "62a9a5c0e4b0a1b2c3d4e5f6",
+					Title:      "Submit expense report, Q2",
+					CreatedAt:  millis("2022-06-15T00:00:00+02:00"),
+					Deadline:   millis("2022-06-16T23:59:00+02:00"),
+					Tags:       []string{"finance", "q2_review"},
+					Priority:   models.PriorityHigh,
+					Recurrence: "FREQ=MONTHLY",
+				},
+				{
+					ExternalID: "imported-1",
+					Title:      "Water plants",
+					Completed:  true,
+					Priority:   models.PriorityLow,
+					Recurrence: "FREQ=WEEKLY;INTERVAL=2",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.format), func(t *testing.T) {
+			var buffer bytes.Buffer
+			encoder := NewEncoder(test.format, &buffer, berlin)
+			for i := range exported {
+				if err := encoder.Encode(&exported[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := encoder.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := Decode(test.format, &buffer, Mapping{}, berlin)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := make([]Row, len(test.want))
+			for i, item := range test.want {
+				want[i] = Row{Number: i + 1, Item: item}
+			}
+			checkRows(t, rows, want)
+		})
+	}
+}
+
+// TestRoundTripEmpty exports nothing, which must still import as a valid, empty document.
+func TestRoundTripEmpty(t *testing.T) {
+	for _, format := range []Format{JSON, CSV, ICS, TodoTxt} {
+		var buffer bytes.Buffer
+		if err := NewEncoder(format, &buffer, berlin).Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		rows, err := Decode(format, &buffer, Mapping{}, berlin)
+		if err != nil || len(rows) != 0 {
+			t.Errorf("%s: Decode = %+v, %v, want no rows", format, rows, err)
+		}
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Mapping
+		invalid bool
+	}{
+		{s: "", want: Mapping{}},
+		{s: "title:Name", want: Mapping{"title": "Name"}},
+		{s: "title: Name , deadline:Due Date", want: Mapping{"title": "Name", "deadline": "Due Date"}},
+		{s: "title", invalid: true},
+		{s: "title:", invalid: true},
+		{s: ":Name", invalid: true},
+		{s: "title:Name,", invalid: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMapping(test.s)
+		if (err != nil) != test.invalid || !test.invalid && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseMapping(%q) = %v, %v, want %v", test.s, got, err, test.want)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		want Format
+		ok   bool
+	}{
+		{"json", JSON, true},
+		{"CSV", CSV, true},
+		{"ics", ICS, true},
+		{"todotxt", TodoTxt, true},
+		{"txt", "", false},
+		{"", "", false},
+	}
+
+	for _, test := range tests {
+		if got, ok := ParseFormat(test.name); got != test.want || ok != test.ok {
+			t.Errorf("ParseFormat(%q) = %q, %t, want %q, %t", test.name, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+// errInvalid marks a Row expected to be invalid; only the presence of Row.Err is compared, not the message.
+var errInvalid = errors.New("invalid")
+
+// checkRows compares decoded rows to the rows expected. The items of invalid rows are not compared.
+func checkRows(t *testing.T, got []Row, want []Row) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d rows, want %d: %+v", len(got), len(want), got)
+	}
+
+	for i := range want {
+		if got[i].Number != want[i].Number {
+			t.Errorf("row %d: Number = %d, want %d", i+1, got[i].Number, want[i].Number)
+		}
+		if want[i].Err != nil {
+			if got[i].Err == nil {
+				t.Errorf("row %d: decoded %+v, want an error", want[i].Number, got[i].Item)
+			}
+			continue
+		}
+		if got[i].Err != nil {
+			t.Errorf("row %d: %v", want[i].Number, got[i].Err)
+			continue
+		}
+		if !reflect.DeepEqual(got[i].Item, want[i].Item) {
+			t.Errorf("row %d = %+v, want %+v", want[i].Number, got[i].Item, want[i].Item)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package interchange
+
+import (
+	"io"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/ical"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+// icsEncoder writes items as VTODO components of a single VCALENDAR.
+type icsEncoder struct {
+	encoder *ical.Encoder
+	started bool
+}
+
+func newICSEncoder(w io.Writer) *icsEncoder {
+	return &icsEncoder{encoder: ical.NewEncoder(w)}
+}
+
+// start writes the calendar header, before the first item.
+func (e *icsEncoder) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+
+	calendar := ical.NewCalendar()
+	e.encoder.Begin(calendar.Name)
+	for _, property := range calendar.Properties {
+		e.encoder.Property(property)
+	}
+	return nil
+}
+
+func (e *icsEncoder) Encode(item *models.ToDoItem) error {
+	e.start()
+	return e.encoder.Component(ical.FromItem(item, ical.UID(item)))
+}
+
+func (e *icsEncoder) Close() error {
+	e.start()
+	return e.encoder.End("VCALENDAR")
+}
+
+// decodeICS reads every VTODO of a VCALENDAR.
+func decodeICS(r io.Reader, location *time.Location) ([]Row, error) {
+	calendar, err := ical.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []Row
+	for i, todo := range calendar.Children("VTODO") {
+		item, err := ical.ToItem(todo, location)
+
+		// UIDs of items exported by this server refer back to their ObjectID
+		if id, ok := ical.ParseObjectUID(item.ExternalID); ok {
+			item.ExternalID = id.Hex()
+		}
+		rows = append(rows, Row{Number: i + 1, Item: item, Err: err})
+	}
+
+	return rows, nil
+}
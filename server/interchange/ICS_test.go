@@ -0,0 +1,74 @@
+package interchange
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+func TestDecodeICS(t *testing.T) {
+	document := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Example//Other Tool//EN",
+		"BEGIN:VTODO",
+		"UID:task-1@example.com",
+		"SUMMARY:Call mum\\, then dad",
+		"DUE;TZID=America/New_York:20220620T090000",
+		"CATEGORIES:family,phone",
+		"PRIORITY:3",
+		"END:VTODO",
+		"BEGIN:VEVENT",
+		"UID:event-1@example.com",
+		"SUMMARY:Not a task",
+		"END:VEVENT",
+		"BEGIN:VTODO",
+		"UID:task-2@example.com",
+		"SUMMARY:Pay rent",
+		"DUE:someday",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:62a9a5c0e4b0a1b2c3d4e5f6@todo-go",
+		"SUMMARY:Water plants",
+		"DUE;VALUE=DATE:20220620",
+		"STATUS:COMPLETED",
+		"RRULE:FREQ=WEEKLY",
+		"END:VTODO",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	rows, err := Decode(ICS, strings.NewReader(document), Mapping{}, berlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkRows(t, rows, []Row{
+		{Number: 1, Item: models.ToDoItem{
+			ExternalID: "task-1@example.com", Title: "Call mum, then dad", Deadline: millis("2022-06-20T09:00:00-04:00"),
+			Tags: []string{"family", "phone"}, Priority: models.PriorityHigh,
+		}},
+		{Number: 2, Err: errInvalid},
+		// UIDs of exported items refer back to their ObjectID, and dates float in the given location
+		{Number: 3, Item: models.ToDoItem{
+			ExternalID: "62a9a5c0e4b0a1b2c3d4e5f6", Title: "Water plants", Completed: true,
+			Deadline: millis("2022-06-20T00:00:00+02:00"), Recurrence: "FREQ=WEEKLY",
+		}},
+	})
+}
+
+func TestDecodeICSDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+	}{
+		{name: "not a calendar", document: "title,deadline\r\nPay rent,2022-07-01\r\n"},
+		{name: "unterminated", document: "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:Pay rent\r\n"},
+	}
+
+	for _, test := range tests {
+		if rows, err := Decode(ICS, strings.NewReader(test.document), Mapping{}, berlin); err == nil {
+			t.Errorf("%s: Decode = %+v, want an error", test.name, rows)
+		}
+	}
+}
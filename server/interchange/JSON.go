@@ -0,0 +1,87 @@
+package interchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+// jsonEncoder writes items as a JSON array.
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func newJSONEncoder(w io.Writer) *jsonEncoder {
+	return &jsonEncoder{w: w}
+}
+
+func (e *jsonEncoder) Encode(item *models.ToDoItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n"
+	if e.count == 0 {
+		separator = "[\n"
+	}
+	e.count++
+
+	if _, err = io.WriteString(e.w, separator); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	if e.count == 0 {
+		_, err := io.WriteString(e.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(e.w, "\n]\n")
+	return err
+}
+
+// decodeJSON reads a JSON array of objects, renaming keys according to the mapping.
+// An object's "_id" is kept as its ExternalID if it has none, so that re-importing an export is detected as a duplicate.
+func decodeJSON(r io.Reader, mapping Mapping) ([]Row, error) {
+	var objects []map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&objects); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of objects: %w", err)
+	}
+
+	rows := make([]Row, len(objects))
+	for i, object := range objects {
+		rows[i].Number = i + 1
+
+		// Rename mapped keys to the ToDoItem field names
+		for field, source := range mapping {
+			if value, ok := object[source]; ok {
+				delete(object, source)
+				object[field] = value
+			}
+		}
+
+		var id string
+		if raw, ok := object["_id"]; ok {
+			json.Unmarshal(raw, &id)
+			delete(object, "_id")
+		}
+
+		data, _ := json.Marshal(object)
+		if err := json.Unmarshal(data, &rows[i].Item); err != nil {
+			rows[i].Err = err
+			continue
+		}
+
+		if rows[i].Item.ExternalID == "" {
+			rows[i].Item.ExternalID = id
+		}
+	}
+
+	return rows, nil
+}
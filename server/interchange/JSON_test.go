@@ -0,0 +1,41 @@
+package interchange
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	document := `[
+		{"name": "Pay rent", "due": 1656662400000, "tags": ["home"], "_id": "62a9a5c0e4b0a1b2c3d4e5f6"},
+		{"name": "Call mum", "completed": "yes"},
+		{"name": "Buy milk", "externalId": "milk-1", "_id": "62a9a5c0e4b0a1b2c3d4e5f7", "unknown": true},
+		{"title": "Not mapped"}
+	]`
+	mapping := Mapping{"title": "name", "deadline": "due"}
+
+	rows, err := Decode(JSON, strings.NewReader(document), mapping, berlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkRows(t, rows, []Row{
+		// The _id of an export becomes the external ID, so that importing it again finds a duplicate
+		{Number: 1, Item: models.ToDoItem{
+			ExternalID: "62a9a5c0e4b0a1b2c3d4e5f6", Title: "Pay rent", Deadline: 1656662400000, Tags: []string{"home"},
+		}},
+		{Number: 2, Err: errInvalid},
+		{Number: 3, Item: models.ToDoItem{ExternalID: "milk-1", Title: "Buy milk"}},
+		{Number: 4, Item: models.ToDoItem{Title: "Not mapped"}},
+	})
+}
+
+func TestDecodeJSONDocument(t *testing.T) {
+	for _, document := range []string{"", `{"title": "Pay rent"}`, `["Pay rent"]`, `[{"title": "Pay rent"}`} {
+		if rows, err := Decode(JSON, strings.NewReader(document), Mapping{}, berlin); err == nil {
+			t.Errorf("Decode(%q) = %+v, want an error", document, rows)
+		}
+	}
+}
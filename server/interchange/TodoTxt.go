@@ -0,0 +1,216 @@
+package interchange
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+// todo.txt (https://github.com/todotxt/todo.txt) has no notion of tags or recurrence, so the common conventions are used:
+// tags are written as +project and read from both +project and @context, the priority maps (A)/(B)/(C) to
+// high/medium/low, and the due:, rec: and id: keys hold the deadline, recurrence and external ID.
+
+const todoTxtDate = "2006-01-02"
+
+var (
+	todoTxtPriority   = regexp.MustCompile(`^\(([A-Z])\)$`)
+	todoTxtRecurrence = regexp.MustCompile(`^\+?(\d+)([dwmy])$`)
+)
+
+var todoTxtPriorities = map[string]string{
+	models.PriorityHigh:   "A",
+	models.PriorityMedium: "B",
+	models.PriorityLow:    "C",
+}
+
+var todoTxtUnits = map[string]string{
+	"DAILY":   "d",
+	"WEEKLY":  "w",
+	"MONTHLY": "m",
+	"YEARLY":  "y",
+}
+
+// todoTxtEncoder writes one item per line.
+type todoTxtEncoder struct {
+	w        io.Writer
+	location *time.Location
+}
+
+func newTodoTxtEncoder(w io.Writer, location *time.Location) *todoTxtEncoder {
+	return &todoTxtEncoder{w: w, location: location}
+}
+
+func (e *todoTxtEncoder) Encode(item *models.ToDoItem) error {
+	var parts []string
+
+	priority := todoTxtPriorities[item.Priority]
+
+	// A creation date is only allowed on completed tasks together with a completion date, which is not tracked
+	if item.Completed {
+		parts = append(parts, "x")
+	} else {
+		if priority != "" {
+			parts = append(parts, "("+priority+")")
+		}
+		if item.CreatedAt != 0 {
+			parts = append(parts, time.UnixMilli(item.CreatedAt).In(e.location).Format(todoTxtDate))
+		}
+	}
+
+	parts = append(parts, strings.Join(strings.Fields(item.Title), " "))
+
+	for _, tag := range item.Tags {
+		parts = append(parts, "+"+strings.Join(strings.Fields(tag), "_"))
+	}
+
+	if item.Completed && priority != "" {
+		parts = append(parts, "pri:"+priority)
+	}
+	if item.Deadline != 0 {
+		parts = append(parts, "due:"+time.UnixMilli(item.Deadline).In(e.location).Format(todoTxtDate))
+	}
+	if rec := todoTxtRec(item.Recurrence); rec != "" {
+		parts = append(parts, "rec:"+rec)
+	}
+	parts = append(parts, "id:"+externalID(item))
+
+	_, err := io.WriteString(e.w, strings.Join(parts, " ")+"\n")
+	return err
+}
+
+func (e *todoTxtEncoder) Close() error {
+	return nil
+}
+
+// todoTxtRec converts an RRULE into a rec: value. Rules that cannot be expressed (e.g. with BYDAY) yield "".
+func todoTxtRec(rule string) string {
+	if rule == "" {
+		return ""
+	}
+
+	unit := ""
+	interval := "1"
+	for _, part := range strings.Split(rule, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "FREQ":
+			unit = todoTxtUnits[value]
+		case "INTERVAL":
+			interval = value
+		default:
+			return ""
+		}
+	}
+
+	if unit == "" {
+		return ""
+	}
+	return interval + unit
+}
+
+// decodeTodoTxt reads one item per non-empty line.
+func decodeTodoTxt(r io.Reader, location *time.Location) ([]Row, error) {
+	var rows []Row
+
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		item, err := todoTxtItem(line, location)
+		rows = append(rows, Row{Number: number, Item: item, Err: err})
+	}
+
+	return rows, scanner.Err()
+}
+
+// todoTxtItem parses a single todo.txt line.
+func todoTxtItem(line string, location *time.Location) (models.ToDoItem, error) {
+	item := models.ToDoItem{}
+	words := strings.Fields(line)
+
+	if len(words) > 0 && words[0] == "x" {
+		item.Completed = true
+		words = words[1:]
+	}
+
+	if len(words) > 0 {
+		if match := todoTxtPriority.FindStringSubmatch(words[0]); match != nil {
+			item.Priority = todoTxtPriorityName(match[1])
+			words = words[1:]
+		}
+	}
+
+	// Completed tasks may carry a completion date followed by a creation date; the creation date is the last one
+	for i := 0; i < 2 && len(words) > 0; i++ {
+		date, err := time.ParseInLocation(todoTxtDate, words[0], location)
+		if err != nil {
+			break
+		}
+		item.CreatedAt = date.UnixMilli()
+		words = words[1:]
+	}
+
+	var title []string
+	for _, word := range words {
+		key, value, found := strings.Cut(word, ":")
+
+		switch {
+		case (strings.HasPrefix(word, "+") || strings.HasPrefix(word, "@")) && len(word) > 1:
+			item.Tags = append(item.Tags, word[1:])
+		case found && key == "due":
+			date, err := time.ParseInLocation(todoTxtDate, value, location)
+			if err != nil {
+				return item, fmt.Errorf("invalid due date %q", value)
+			}
+			item.Deadline = endOfDay(date).UnixMilli()
+		case found && key == "rec":
+			match := todoTxtRecurrence.FindStringSubmatch(value)
+			if match == nil {
+				return item, fmt.Errorf("invalid recurrence %q", value)
+			}
+			item.Recurrence = todoTxtRule(match[1], match[2])
+		case found && key == "id":
+			item.ExternalID = value
+		case found && key == "pri":
+			item.Priority = todoTxtPriorityName(value)
+		default:
+			title = append(title, word)
+		}
+	}
+
+	item.Title = strings.Join(title, " ")
+	return item, nil
+}
+
+// todoTxtPriorityName maps a todo.txt priority letter to a priority; letters after C are low.
+func todoTxtPriorityName(letter string) string {
+	switch letter {
+	case "A":
+		return models.PriorityHigh
+	case "B":
+		return models.PriorityMedium
+	}
+	return models.PriorityLow
+}
+
+// todoTxtRule converts a rec: interval and unit into an RRULE.
+func todoTxtRule(interval string, unit string) string {
+	for freq, u := range todoTxtUnits {
+		if u != unit {
+			continue
+		}
+		if n, _ := strconv.Atoi(interval); n > 1 {
+			return "FREQ=" + freq + ";INTERVAL=" + interval
+		}
+		return "FREQ=" + freq
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package interchange
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+func TestDecodeTodoTxt(t *testing.T) {
+	document := strings.Join([]string{
+		`(A) 2022-06-15 Call mum +family @phone due:2022-06-20`,
+		``,
+		`x 2022-06-18 2022-06-10 Water plants rec:+2w pri:C`,
+		`Pay rent due:July`,
+		`Stretch rec:often`,
+		`(D) Email Bob about the 5:30 meeting id:mail-1 rec:1y`,
+		`   `,
+		`x`,
+		`Change the clocks due:2022-10-30`,
+	}, "\n")
+
+	rows, err := Decode(TodoTxt, strings.NewReader(document), Mapping{}, berlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rows are numbered by line, blank lines included
+	checkRows(t, rows, []Row{
+		{Number: 1, Item: models.ToDoItem{
+			Title: "Call mum", CreatedAt: millis("2022-06-15T00:00:00+02:00"), Deadline: millis("2022-06-20T23:59:00+02:00"),
+			Tags: []string{"family", "phone"}, Priority: models.PriorityHigh,
+		}},
+		// The creation date follows the completion date
+		{Number: 3, Item: models.ToDoItem{
+			Title: "Water plants", Completed: true, CreatedAt: millis("2022-06-10T00:00:00+02:00"),
+			Priority: models.PriorityLow, Recurrence: "FREQ=WEEKLY;INTERVAL=2",
+		}},
+		{Number: 4, Err: errInvalid},
+		{Number: 5, Err: errInvalid},
+		{Number: 6, Item: models.ToDoItem{
+			ExternalID: "mail-1", Title: "Email Bob about the 5:30 meeting", Priority: models.PriorityLow, Recurrence: "FREQ=YEARLY",
+		}},
+		{Number: 8, Item: models.ToDoItem{Completed: true}},
+		{Number: 9, Item: models.ToDoItem{Title: "Change the clocks", Deadline: millis("2022-10-30T23:59:00+01:00")}},
+	})
+}
+
+func TestTodoTxtRec(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"", ""},
+		{"FREQ=DAILY", "1d"},
+		{"FREQ=WEEKLY;INTERVAL=2", "2w"},
+		{"FREQ=MONTHLY", "1m"},
+		{"FREQ=YEARLY", "1y"},
+		// Rules todo.txt cannot express are dropped
+		{"FREQ=WEEKLY;BYDAY=MO", ""},
+		{"FREQ=HOURLY", ""},
+	}
+
+	for _, test := range tests {
+		if got := todoTxtRec(test.rule); got != test.want {
+			t.Errorf("todoTxtRec(%q) = %q, want %q", test.rule, got, test.want)
+		}
+	}
+}
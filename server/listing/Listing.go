@@ -0,0 +1,117 @@
+// Package listing reads the query parameters that select a list of ToDoItems. It is shared by every API that lists
+// items, so that they all interpret the parameters the same way.
+package listing
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/L4TTiCe/ToDo-Go/server/dao/ToDoItemDao"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+)
+
+// Parse reads the list query selected by the attrib, sort, before, after, start and end query parameters.
+// It returns the query or an ErrorResponse, which is not yet populated.
+func Parse(params url.Values) (*ToDoItemDao.List, *models.ErrorResponse) {
+	attrib := params.Get("attrib")
+
+	sort := params.Get("sort")
+	before := params.Get("before")
+	after := params.Get("after")
+	start := params.Get("start")
+	end := params.Get("end")
+
+	var sortOrder int
+
+	switch sort {
+	case "asc", "1":
+		sortOrder = 1
+	case "desc", "-1":
+		sortOrder = -1
+	case "":
+		sortOrder = 1
+	default:
+		sortOrder = 0
+	}
+
+	var list *ToDoItemDao.List
+	var errorResponse *models.ErrorResponse
+	var err error
+
+	if attrib != "" {
+		var verb string
+		var date int64
+
+		var startDate int64
+		var endDate int64
+
+		// Either before or after must be specified, together with a verb
+		if before != "" && after == "" && start == "" && end == "" { // before
+			verb = "lte"
+			intVal, err := strconv.Atoi(before)
+			if err != nil {
+				errorResponse = problem.InvalidRequest.New("Date must be a positive integer")
+				return nil, errorResponse
+			}
+
+			date = int64(intVal)
+		} else if after != "" && before == "" && start == "" && end == "" { // after
+			verb = "gte"
+			intVal, err := strconv.Atoi(after)
+			if err != nil {
+				errorResponse = problem.InvalidRequest.New("Date must be a positive integer")
+				return nil, errorResponse
+			}
+
+			date = int64(intVal)
+		} else if after != "" && before != "" && start == "" && end == "" { // after and before
+			errorResponse = problem.InvalidRequest.New("Must specify either before or after")
+			return nil, errorResponse
+		} else if (after != "" || before != "") && start != "" && end != "" { // start and end and after or before
+			errorResponse = problem.InvalidRequest.New("Must specify either before / after or start and end")
+			return nil, errorResponse
+		} else if after == "" && before == "" && start != "" && end != "" { // start and end
+			// validate start and end
+			intVal, err := strconv.Atoi(start)
+			if err != nil {
+				errorResponse = problem.InvalidRequest.New("Date must be a positive integer. Check start.")
+				return nil, errorResponse
+			}
+
+			startDate = int64(intVal)
+
+			intVal, err = strconv.Atoi(end)
+			if err != nil {
+				errorResponse = problem.InvalidRequest.New("Date must be a positive integer. Check end.")
+				return nil, errorResponse
+			}
+
+			endDate = int64(intVal)
+		}
+
+		if before == "" && after == "" && start == "" && end == "" { // no params (before, after, start, end)
+			list, err = ToDoItemDao.ListAll(attrib, sortOrder)
+		} else if (before != "" || after != "") && start == "" && end == "" { // before or after
+			list, err = ToDoItemDao.ListWithParams(attrib, verb, date, sortOrder)
+		} else if start != "" && end != "" { // start and end
+			list, err = ToDoItemDao.ListBetween(attrib, startDate, endDate, sortOrder)
+		} else { // start or end alone
+			errorResponse = problem.InvalidRequest.New("Must specify both start and end")
+			return nil, errorResponse
+		}
+
+	} else {
+		if before != "" || after != "" || start != "" || end != "" {
+			errorResponse = problem.InvalidRequest.New("Must specify an attribute to use with the before, after, start, or end parameters")
+			return nil, errorResponse
+		}
+
+		list, err = ToDoItemDao.ListAll("createdAt", sortOrder)
+	}
+
+	if err != nil {
+		return nil, problem.From(err)
+	}
+	return list, nil
+}
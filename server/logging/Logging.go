@@ -0,0 +1,59 @@
+// Package logging writes structured, levelled logs.
+//
+// The format is json, for log collectors, or console, for people; the level is the lowest level written: debug, info,
+// warn or error. Lines logged while handling a request carry the request's
+// ID, method, route, user, trace and the time elapsed since it arrived; see FromContext.
+package logging
+
+import (
+	"errors"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Setup replaces the global logger with one writing in the format from the level on, and sends the output of the
+// standard library's log package to it. The returned function flushes buffered lines, and must be called before
+// exiting.
+func Setup(format string, levelName string) (func(), error) {
+	level := zap.NewAtomicLevel()
+	if err := level.UnmarshalText([]byte(levelName)); err != nil {
+		return nil, errors.New("unknown log level " + levelName + ", must be one of the following: debug, info, warn, error")
+	}
+
+	var config zap.Config
+	switch format {
+	case "", "console":
+		config = zap.NewDevelopmentConfig()
+		config.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		config.Development = false
+	case "json":
+		config = zap.NewProductionConfig()
+		config.EncoderConfig.TimeKey = "time"
+		config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	default:
+		return nil, errors.New("unknown log format " + format + ", must be one of the following: json, console")
+	}
+	config.Level = level
+	config.OutputPaths = []string{"stdout"}
+	// Logging every request is the point; sampling would drop some of them under load
+	config.Sampling = nil
+
+	logger, err := config.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	zap.ReplaceGlobals(logger)
+	restore := zap.RedirectStdLog(logger)
+
+	return func() {
+		restore()
+		_ = logger.Sync()
+	}, nil
+}
+
+// L returns the global logger, for lines that do not belong to a request.
+func L() *zap.Logger {
+	return zap.L()
+}
@@ -0,0 +1,148 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RequestIDHeader is the header that carries the ID of a request, both in the request and in its response.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the length of the longest request ID accepted from a client.
+const maxRequestIDLength = 128
+
+// request describes the request being handled, for the lines logged while handling it.
+type request struct {
+	id     string
+	method string
+	route  string
+	start  time.Time
+
+	mutex sync.Mutex
+	user  string
+}
+
+type requestKey struct{}
+
+// Middleware gives every request an ID, taken from its X-Request-ID header or generated, and returns it in the
+// response's X-Request-ID header. It puts the request's details in its context for FromContext, and logs a line
+// for every request once it has been handled.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+
+		r := &request{id: id, method: c.Request.Method, route: c.FullPath(), start: time.Now()}
+		if r.route == "" {
+			r.route = "unmatched"
+		}
+		if user, _, ok := c.Request.BasicAuth(); ok {
+			r.user = user
+		}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestKey{}, r))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+		level := zapcore.InfoLevel
+		if status >= http.StatusInternalServerError {
+			level = zapcore.ErrorLevel
+		}
+		if line := FromContext(c.Request.Context()).Check(level, "Handled request"); line != nil {
+			fields := []zap.Field{
+				zap.String("path", c.Request.URL.Path),
+				zap.Int("status", status),
+				zap.Int("size", size),
+				zap.String("clientIp", c.ClientIP()),
+			}
+			if errs := c.Errors.ByType(gin.ErrorTypePrivate).String(); errs != "" {
+				fields = append(fields, zap.String("errors", errs))
+			}
+			line.Write(fields...)
+		}
+	}
+}
+
+// FromContext returns a logger for the request whose context ctx is, or the global logger if ctx belongs to no
+// request. Its lines carry the request's ID, method, route and user, the ID of the trace it is part of, and the
+// time elapsed since it arrived.
+func FromContext(ctx context.Context) *zap.Logger {
+	logger := zap.L()
+
+	if r, ok := ctx.Value(requestKey{}).(*request); ok {
+		fields := []zap.Field{
+			zap.String("requestId", r.id),
+			zap.String("method", r.method),
+			zap.String("route", r.route),
+		}
+		r.mutex.Lock()
+		if r.user != "" {
+			fields = append(fields, zap.String("user", r.user))
+		}
+		r.mutex.Unlock()
+		fields = append(fields, zap.Duration("latency", time.Since(r.start)))
+		logger = logger.With(fields...)
+	}
+
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		logger = logger.With(zap.String("traceId", span.TraceID().String()))
+	}
+
+	return logger
+}
+
+// RequestID returns the ID of the request whose context ctx is, or an empty string.
+func RequestID(ctx context.Context) string {
+	if r, ok := ctx.Value(requestKey{}).(*request); ok {
+		return r.id
+	}
+	return ""
+}
+
+// SetUser records who made the request whose context ctx is, once they are known, for the lines logged afterwards.
+func SetUser(ctx context.Context, user string) {
+	if r, ok := ctx.Value(requestKey{}).(*request); ok {
+		r.mutex.Lock()
+		r.user = user
+		r.mutex.Unlock()
+	}
+}
+
+// validRequestID reports whether a client's request ID can be used: it must be short and printable, so that it
+// cannot forge log lines or headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		// Only used to tell requests apart, so a clock is good enough when there is no randomness
+		return time.Now().UTC().Format("20060102T150405.000000000")
+	}
+	return hex.EncodeToString(id)
+}
@@ -1,63 +1,332 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
+	"github.com/L4TTiCe/ToDo-Go/server/buildinfo"
+	"github.com/L4TTiCe/ToDo-Go/server/compression"
 	"github.com/L4TTiCe/ToDo-Go/server/config"
+	"github.com/L4TTiCe/ToDo-Go/server/controller"
+	"github.com/L4TTiCe/ToDo-Go/server/controller/FeedTokenController"
+	"github.com/L4TTiCe/ToDo-Go/server/crossorigin"
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/ToDoItemDao"
+	"github.com/L4TTiCe/ToDo-Go/server/events"
+	"github.com/L4TTiCe/ToDo-Go/server/health"
+	"github.com/L4TTiCe/ToDo-Go/server/idempotency"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/metrics"
+	"github.com/L4TTiCe/ToDo-Go/server/migrations"
+	"github.com/L4TTiCe/ToDo-Go/server/openapi"
+	"github.com/L4TTiCe/ToDo-Go/server/ratelimit"
 	"github.com/L4TTiCe/ToDo-Go/server/routes"
-	"github.com/gin-contrib/cors"
+	"github.com/L4TTiCe/ToDo-Go/server/rpc"
+	"github.com/L4TTiCe/ToDo-Go/server/tracing"
+	"github.com/L4TTiCe/ToDo-Go/server/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
-// loadEnv loads the .env file if it exists
-func loadEnv() {
-	log.Println("Loading .env file...")
-	err := godotenv.Load()
+// loadEnv loads the .env file if it exists, and reports whether it does
+func loadEnv() bool {
+	return godotenv.Load() == nil
+}
+
+// splitCommand separates the command, the leading arguments that are not flags, from the flags. Without a command,
+// the server is run; migrate applies the pending migrations of the DB and lists them, and migrate status only lists
+// them.
+func splitCommand(args []string) (command string, flags []string) {
+	var words []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		words, args = append(words, args[0]), args[1:]
+	}
+	return strings.Join(words, " "), args
+}
+
+// loadConfig reads the configuration from the config file, the environment and the flags, printing it and exiting
+// for --print-config. The logger is not set up yet, so problems are written to stderr.
+func loadConfig(args []string) *config.Config {
+	cfg, printConfig, err := config.Load(os.Args[0], args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+
+	if printConfig && cfg != nil {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
 	if err != nil {
-		log.Println("No .env file found")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if printConfig {
+		os.Exit(0)
 	}
+
+	return cfg
 }
 
-// configureLogger sets optional flags for the logger
-func configureLogger() {
-	log.Print("Configuring logger...")
-	log.SetOutput(os.Stdout)
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
+// configureLogger sets up structured logging, returning a function that flushes it
+func configureLogger(log *config.LogConfig) func() {
+	flush, err := logging.Setup(log.Format, log.Level)
+	if err != nil {
+		panic(err)
+	}
+	return flush
 }
 
-func initializeRouter() *gin.Engine {
-	log.Println("Initializing router...")
-	router := gin.Default()
+// migrate applies the pending migrations unless status is set, and lists every migration, for the migrate command. It
+// returns the exit code.
+func migrate(database *config.DatabaseConfig, status bool) int {
+	config.ConnectMongoDB(database)
+	defer config.CloseClientDB()
+
+	if !status {
+		if err := migrations.Migrate(context.Background(), time.Duration(database.MigrationTimeout)); err != nil {
+			logging.L().Error("Migrating the DB failed", zap.Error(err))
+			return 1
+		}
+	}
+
+	statuses, err := migrations.Statuses(context.Background())
+	if err != nil {
+		logging.L().Error("Reading the migrations failed", zap.Error(err))
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tAPPLIED\tDESCRIPTION")
+	for _, migration := range statuses {
+		applied := "pending"
+		if migration.AppliedAt != 0 {
+			applied = time.UnixMilli(migration.AppliedAt).UTC().Format(time.RFC3339)
+		}
+		description := migration.Description
+		if migration.Unknown {
+			description += " (applied by a newer version of the server)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", migration.Version, applied, description)
+	}
+	if err := w.Flush(); err != nil {
+		return 1
+	}
+	return 0
+}
 
-	// Enable CORS for all requests
-	router.Use(cors.Default())
+func initializeRouter(cfg *config.Config, limits ratelimit.Store) *gin.Engine {
+	logging.L().Info("Initializing router...")
+	router := gin.New()
 
-	healthCheck := func(c *gin.Context) {
-		c.String(http.StatusOK, "Server is Up!")
+	// Only believe the client IPs forwarded by the trusted proxies, since clients are rate limited by IP
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		panic(err)
 	}
 
-	router.GET("/up", healthCheck)
+	// Trace every request, continuing the caller's trace, then give it an ID and log it
+	router.Use(tracing.Middleware())
+	router.Use(logging.Middleware())
+	router.Use(gin.Recovery())
+
+	// Let browsers call the API from the allowed origins, sending and seeing the idempotency headers, sending API keys
+	// and trace context, revalidating cached responses, and seeing the rate limits
+	router.Use(crossorigin.Middleware(&cfg.CORS,
+		[]string{idempotency.Header, logging.RequestIDHeader, ratelimit.APIKeyHeader, "traceparent", "tracestate", "If-None-Match"},
+		append([]string{idempotency.ReplayedHeader, logging.RequestIDHeader, "ETag"}, ratelimit.Headers...),
+	))
+
+	// Compress responses, including those rejected by rate limiting and validation
+	router.Use(compression.Middleware(&cfg.Compression))
+
+	// Count and time every request, including those rejected by rate limiting and validation
+	router.Use(metrics.Middleware())
 
-	routes.ToDoRoutes(router)
+	router.Use(ratelimit.Middleware(&cfg.RateLimit, limits))
+
+	// Validate requests against the OpenAPI document before they reach the handlers
+	router.Use(validation.Middleware(openapi.Spec()))
+
+	routes.Register(router)
+
+	ratelimit.CheckRoutes(&cfg.RateLimit, router.Routes())
 
 	return router
 }
 
+// startGRPCServer serves the gRPC API on the address in the background.
+func startGRPCServer(address string) *grpc.Server {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		panic(err)
+	}
+
+	grpcServer := rpc.NewServer()
+	go func() {
+		logging.L().Info("Serving gRPC", zap.String("address", listener.Addr().String()))
+		if err := grpcServer.Serve(listener); err != nil {
+			logging.L().Error("Serving gRPC failed", zap.Error(err))
+		}
+	}()
+
+	return grpcServer
+}
+
+// newHTTPServer returns a server for the router on the configured address.
+func newHTTPServer(router *gin.Engine, settings *config.ServerConfig) *http.Server {
+	return &http.Server{
+		Addr:              settings.Address,
+		Handler:           router,
+		ReadHeaderTimeout: time.Duration(settings.ReadTimeout),
+		ReadTimeout:       time.Duration(settings.ReadTimeout),
+		WriteTimeout:      time.Duration(settings.WriteTimeout),
+		IdleTimeout:       time.Duration(settings.IdleTimeout),
+	}
+}
+
+// shutdown fails readiness and keeps serving for the delay, then stops accepting connections, waits up to the
+// timeout for in-flight requests and RPCs to finish, cutting off those that do not, and then flushes the traces and
+// disconnects from the DB.
+func shutdown(server *http.Server, grpcServer *grpc.Server, flushTraces func(ctx context.Context) error, settings *config.ServerConfig) {
+	health.SetShuttingDown()
+	if delay := time.Duration(settings.ShutdownDelay); delay > 0 {
+		logging.L().Info("Failing readiness before draining...", zap.Duration("delay", delay))
+		time.Sleep(delay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(settings.ShutdownTimeout))
+	defer cancel()
+
+	// Subscriptions never finish by themselves; closing the broker ends them, so that they do not hold up draining
+	events.Close()
+
+	logging.L().Info("Draining HTTP requests...")
+	if err := server.Shutdown(ctx); err != nil {
+		logging.L().Warn("HTTP requests did not finish in time", zap.Error(err))
+		_ = server.Close()
+	}
+
+	logging.L().Info("Draining gRPC calls...")
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		logging.L().Warn("gRPC calls did not finish in time")
+		grpcServer.Stop()
+	}
+
+	if err := flushTraces(ctx); err != nil {
+		logging.L().Warn("Traces were not exported", zap.Error(err))
+	}
+
+	config.CloseClientDB()
+	logging.L().Info("Shut down")
+}
+
 func main() {
-	configureLogger()
-	loadEnv()
+	// The .env file may configure the server, so it is read first and reported once the logger is ready
+	foundEnv := loadEnv()
+	command, args := splitCommand(os.Args[1:])
+	if command != "" && command != "migrate" && command != "migrate status" {
+		fmt.Fprintf(os.Stderr, "unknown command %q, expected migrate or migrate status\n", command)
+		os.Exit(2)
+	}
+	cfg := loadConfig(args)
+	flushLogs := configureLogger(&cfg.Log)
+	if !foundEnv {
+		logging.L().Info("No .env file found")
+	}
 
-	config.ConnectMongoDB()
-	defer config.CloseClientDB()
+	build := buildinfo.Get()
+	logging.L().Info("Starting ToDo-Go",
+		zap.String("version", build.Version),
+		zap.String("commit", build.Commit),
+		zap.String("goVersion", build.GoVersion),
+	)
+
+	flushTraces, err := tracing.Setup(cfg.Tracing.Exporter)
+	if err != nil {
+		panic(err)
+	}
+
+	dao.SetTimeouts(time.Duration(cfg.Database.Timeout), cfg.Database.Timeouts())
+	idempotency.SetTTL(time.Duration(cfg.Idempotency.TTL))
+	ToDoItemDao.ConfigureCache(cfg.Cache.Items, cfg.Cache.Lists, time.Duration(cfg.Cache.TTL))
+	controller.SetCacheMaxAge(time.Duration(cfg.Cache.MaxAge))
+	FeedTokenController.SetAdminToken(cfg.Feed.AdminToken)
+
+	if command != "" {
+		exitCode := migrate(&cfg.Database, command == "migrate status")
+		flushLogs()
+		os.Exit(exitCode)
+	}
+
+	config.ConnectMongoDB(&cfg.Database)
+	health.Register("mongodb", config.Ping)
+	metrics.RegisterItemCounter(ToDoItemDao.CountOpen)
 
-	router := initializeRouter()
+	// Instances starting together take turns migrating, and none serves before the DB is up to date
+	if cfg.Database.MigrateOnStartup {
+		if err := migrations.Migrate(context.Background(), time.Duration(cfg.Database.MigrationTimeout)); err != nil {
+			panic(err)
+		}
+	} else if pending, err := migrations.Pending(context.Background()); err != nil || len(pending) > 0 {
+		logging.L().Warn("The DB may not be up to date; apply the pending migrations with the migrate command", zap.Int("pending", len(pending)), zap.Error(err))
+	}
 
-	err := router.Run()
+	limits, err := ratelimit.NewStore(&cfg.RateLimit)
 	if err != nil {
 		panic(err)
 	}
+
+	grpcServer := startGRPCServer(cfg.Server.GRPCAddress)
+	server := newHTTPServer(initializeRouter(cfg, limits), &cfg.Server)
+
+	failed := make(chan error, 1)
+	go func() {
+		logging.L().Info("Serving HTTP", zap.String("address", server.Addr))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			failed <- err
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	exitCode := 0
+	select {
+	case sig := <-signals:
+		logging.L().Info("Shutting down", zap.String("signal", sig.String()))
+	case err := <-failed:
+		logging.L().Error("Serving HTTP failed", zap.Error(err))
+		exitCode = 1
+	}
+
+	// A second signal kills the process without waiting for the shutdown
+	signal.Stop(signals)
+
+	shutdown(server, grpcServer, flushTraces, &cfg.Server)
+	if err := limits.Close(); err != nil {
+		logging.L().Warn("Closing the rate limit store failed", zap.Error(err))
+	}
+	flushLogs()
+	os.Exit(exitCode)
 }
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "lookups_total",
+		Help:      "Lookups in the caches, by cache and result: hit or miss.",
+	}, []string{"cache", "result"})
+
+	cacheEvictions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "evictions_total",
+		Help:      "Values evicted from the caches to make room for others, by cache.",
+	}, []string{"cache"})
+)
+
+// ObserveCacheLookup records a lookup in a cache, and whether it found a value.
+func ObserveCacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// ObserveCacheEviction records that a cache evicted a value to make room for another.
+func ObserveCacheEviction(cache string) {
+	cacheEvictions.WithLabelValues(cache).Inc()
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	daoDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "dao",
+		Name:      "operation_duration_seconds",
+		Help:      "Time taken by DAO operations, by DAO and operation.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"dao", "operation"})
+
+	daoErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "dao",
+		Name:      "errors_total",
+		Help:      "Errors returned by DAO operations, by DAO, operation and kind of error.",
+	}, []string{"dao", "operation", "kind"})
+)
+
+// ObserveDAO records that a DAO operation started at start has finished, returning the error err points to.
+// It is meant to be deferred at the top of the operation, with err pointing to its named error result:
+//
+//	defer metrics.ObserveDAO("ToDoItemDao", "Create", time.Now(), &err)
+func ObserveDAO(daoName string, operation string, start time.Time, err *error) {
+	daoDuration.WithLabelValues(daoName, operation).Observe(time.Since(start).Seconds())
+	if err != nil && *err != nil {
+		daoErrors.WithLabelValues(daoName, operation, errorKind(*err)).Inc()
+	}
+}
+
+// errorKind names the kind of a DAO error, for labels.
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, dao.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, dao.ErrInvalidID):
+		return "invalid_id"
+	case errors.Is(err, dao.ErrValidation):
+		return "validation"
+	case errors.Is(err, dao.ErrConflict):
+		return "conflict"
+	case errors.Is(err, dao.ErrUnavailable):
+		return "unavailable"
+	case errors.Is(err, dao.ErrTimeout):
+		return "timeout"
+	case errors.Is(err, dao.ErrCanceled):
+		return "canceled"
+	default:
+		return "internal"
+	}
+}
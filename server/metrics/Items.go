@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// ItemCounter counts the items that are not completed, and those of them whose deadline has passed.
+type ItemCounter func(ctx context.Context) (open int64, overdue int64, err error)
+
+var (
+	openItems = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "items", "open"),
+		"Items that are not completed.",
+		nil, nil,
+	)
+	overdueItems = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "items", "overdue"),
+		"Items that are not completed and whose deadline has passed.",
+		nil, nil,
+	)
+)
+
+// RegisterItemCounter exposes the item counts, which are counted afresh on every scrape.
+func RegisterItemCounter(count ItemCounter) {
+	prometheus.MustRegister(itemCollector{count: count})
+}
+
+// itemCollector is a prometheus.Collector for the item counts.
+type itemCollector struct {
+	count ItemCounter
+}
+
+func (c itemCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- openItems
+	ch <- overdueItems
+}
+
+func (c itemCollector) Collect(ch chan<- prometheus.Metric) {
+	open, overdue, err := c.count(context.Background())
+	if err != nil {
+		// Leaving the gauges out of the scrape marks them as missing, rather than reporting stale values
+		logging.L().Error("Counting items failed", zap.Error(err))
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(openItems, prometheus.GaugeValue, float64(open))
+	ch <- prometheus.MustNewConstMetric(overdueItems, prometheus.GaugeValue, float64(overdue))
+}
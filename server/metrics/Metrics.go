@@ -0,0 +1,17 @@
+// Package metrics exposes Prometheus metrics about the server: HTTP requests, DAO operations, caches, the MongoDB
+// connection pool and the items stored.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the names of all metrics.
+const namespace = "todo"
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+var (
+	poolConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mongodb_pool",
+		Name:      "connections",
+		Help:      "Connections open in the MongoDB connection pool, by server address.",
+	}, []string{"address"})
+
+	poolInUse = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mongodb_pool",
+		Name:      "connections_in_use",
+		Help:      "Connections checked out of the MongoDB connection pool, by server address.",
+	}, []string{"address"})
+
+	poolWaiting = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mongodb_pool",
+		Name:      "checkouts_waiting",
+		Help:      "Operations waiting to check a connection out of the MongoDB connection pool, by server address.",
+	}, []string{"address"})
+
+	poolCheckoutFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mongodb_pool",
+		Name:      "checkout_failures_total",
+		Help:      "Failed checkouts from the MongoDB connection pool, by server address and reason.",
+	}, []string{"address", "reason"})
+
+	poolClears = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mongodb_pool",
+		Name:      "clears_total",
+		Help:      "Times the MongoDB connection pool was cleared after an error, by server address.",
+	}, []string{"address"})
+)
+
+// PoolMonitor returns a monitor for the MongoDB driver that records the state of its connection pools.
+func PoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				poolConnections.WithLabelValues(e.Address).Inc()
+			case event.ConnectionClosed:
+				poolConnections.WithLabelValues(e.Address).Dec()
+			case event.GetStarted:
+				poolWaiting.WithLabelValues(e.Address).Inc()
+			case event.GetSucceeded:
+				poolWaiting.WithLabelValues(e.Address).Dec()
+				poolInUse.WithLabelValues(e.Address).Inc()
+			case event.GetFailed:
+				poolWaiting.WithLabelValues(e.Address).Dec()
+				poolCheckoutFailures.WithLabelValues(e.Address, e.Reason).Inc()
+			case event.ConnectionReturned:
+				poolInUse.WithLabelValues(e.Address).Dec()
+			case event.PoolCleared:
+				poolClears.WithLabelValues(e.Address).Inc()
+			}
+		},
+	}
+}
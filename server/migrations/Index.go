@@ -0,0 +1,121 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// The codes of the errors MongoDB returns for an index that exists with other options, or under another name, and for
+// one whose name is taken by an index on other keys.
+const (
+	indexOptionsConflict  = 85
+	indexKeySpecsConflict = 86
+)
+
+// index describes an index on the keys, named as MongoDB would name it, e.g. createdAt_1, with the options, which
+// may be nil.
+func index(keys bson.D, opts *options.IndexOptions) mongo.IndexModel {
+	if opts == nil {
+		opts = options.Index()
+	}
+
+	var name []string
+	for _, key := range keys {
+		name = append(name, key.Key, fmt.Sprint(key.Value))
+	}
+	return mongo.IndexModel{Keys: keys, Options: opts.SetName(strings.Join(name, "_"))}
+}
+
+// ensureIndexes creates the indexes on the collection. An index that exists already as described is kept; one that
+// exists with other options, under another name or on other keys under the same name is replaced, so that a migration
+// can change an index by describing it anew.
+func ensureIndexes(ctx context.Context, collection *mongo.Collection, indexes ...mongo.IndexModel) error {
+	for _, model := range indexes {
+		_, err := collection.Indexes().CreateOne(ctx, model)
+		var commandErr mongo.CommandError
+		if errors.As(err, &commandErr) && (commandErr.Code == indexOptionsConflict || commandErr.Code == indexKeySpecsConflict) {
+			err = replaceIndex(ctx, collection, model)
+		}
+		if err != nil {
+			return fmt.Errorf("creating index %s on %s: %w", *model.Options.Name, collection.Name(), err)
+		}
+	}
+	return nil
+}
+
+// replaceIndex drops the indexes that conflict with the model, those with its name or on its keys, and creates it.
+func replaceIndex(ctx context.Context, collection *mongo.Collection, model mongo.IndexModel) error {
+	keys, err := bson.Marshal(model.Keys)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+	var existing []struct {
+		Name string   `bson:"name"`
+		Key  bson.Raw `bson:"key"`
+	}
+	if err := cursor.All(ctx, &existing); err != nil {
+		return err
+	}
+
+	for _, e := range existing {
+		if e.Name != *model.Options.Name && !sameKeys(e.Key, keys) {
+			continue
+		}
+		logging.FromContext(ctx).Info("Replacing index", zap.String("collection", collection.Name()), zap.String("index", e.Name))
+		if _, err := collection.Indexes().DropOne(ctx, e.Name); err != nil {
+			return err
+		}
+	}
+
+	_, err = collection.Indexes().CreateOne(ctx, model)
+	return err
+}
+
+// sameKeys reports whether two index key documents index the same fields in the same order and directions, however
+// their numbers are typed.
+func sameKeys(a bson.Raw, b bson.Raw) bool {
+	aKeys, err := a.Elements()
+	if err != nil {
+		return false
+	}
+	bKeys, err := b.Elements()
+	if err != nil || len(aKeys) != len(bKeys) {
+		return false
+	}
+
+	for i := range aKeys {
+		if aKeys[i].Key() != bKeys[i].Key() || direction(aKeys[i].Value()) != direction(bKeys[i].Value()) {
+			return false
+		}
+	}
+	return true
+}
+
+// direction formats the value of an index key, a number such as 1 or -1, or a type such as text.
+func direction(value bson.RawValue) string {
+	switch value.Type {
+	case bsontype.Int32:
+		return strconv.FormatInt(int64(value.Int32()), 10)
+	case bsontype.Int64:
+		return strconv.FormatInt(value.Int64(), 10)
+	case bsontype.Double:
+		return strconv.FormatFloat(value.Double(), 'f', -1, 64)
+	}
+	return value.String()
+}
@@ -0,0 +1,169 @@
+package migrations
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/MigrationDao"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"go.uber.org/zap"
+)
+
+// lease is how long the lock is held unless it is extended, so that another instance takes over soon after the one
+// holding it dies.
+const lease = time.Minute
+
+// pollInterval is how often an instance waiting for another to apply the migrations checks whether it is done.
+const pollInterval = time.Second
+
+// Status is the state of a migration: whether, and when, it has been applied. Unknown is set for a migration that has
+// been applied by a newer version of the server, which this one does not know.
+type Status struct {
+	Version     int
+	Description string
+	AppliedAt   int64
+	Unknown     bool
+}
+
+// Migrate applies the migrations that have not been applied yet, in order, giving up after timeout. If another
+// instance is applying them already, it waits for it to finish, and takes over if it dies.
+func Migrate(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	owner := newOwner()
+	for waiting := false; ; waiting = true {
+		pending, err := Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		locked, err := MigrationDao.Lock(ctx, owner, lease)
+		if err != nil {
+			return err
+		}
+		if locked {
+			break
+		}
+
+		if !waiting {
+			logging.FromContext(ctx).Info("Waiting for another instance to apply the migrations")
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for another instance to apply the migrations: %w", ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+	// The lock is released even if the migrations ran out of time
+	defer MigrationDao.Unlock(dao.Detach(ctx), owner)
+
+	// Extend the lease while the migrations are applied, stopping them if the lock is lost
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	var lost int32
+	go func() {
+		ticker := time.NewTicker(lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if locked, err := MigrationDao.Lock(ctx, owner, lease); err == nil && !locked {
+				atomic.StoreInt32(&lost, 1)
+				stop()
+				return
+			}
+		}
+	}()
+
+	// Migrations applied by another instance before the lock was taken are not applied again
+	pending, err := Pending(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range pending {
+		logging.FromContext(ctx).Info("Applying migration", zap.Int("version", migration.Version), zap.String("description", migration.Description))
+		start := time.Now()
+
+		err := migration.Up(ctx)
+		if err == nil {
+			err = MigrationDao.Record(ctx, &models.Migration{Version: migration.Version, Description: migration.Description})
+		}
+		if atomic.LoadInt32(&lost) == 1 {
+			return errors.New("the lock on the migrations was taken over by another instance")
+		}
+		if err != nil {
+			return fmt.Errorf("migration %d, %s, failed: %w", migration.Version, migration.Description, err)
+		}
+
+		logging.FromContext(ctx).Info("Applied migration", zap.Int("version", migration.Version), zap.Duration("duration", time.Since(start)))
+	}
+
+	return nil
+}
+
+// Pending returns the migrations that have not been applied yet, in order.
+func Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := MigrationDao.RetrieveApplied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range All {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Statuses returns the state of every migration, in order, including those applied by newer versions of the server.
+func Statuses(ctx context.Context) ([]Status, error) {
+	applied, err := MigrationDao.RetrieveApplied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, migration := range All {
+		statuses = append(statuses, Status{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   applied[migration.Version].AppliedAt,
+		})
+		delete(applied, migration.Version)
+	}
+	for _, record := range applied {
+		statuses = append(statuses, Status{Version: record.Version, Description: record.Description, AppliedAt: record.AppliedAt, Unknown: true})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// newOwner identifies this instance as the holder of the lock, in a way that tells operators which one it is.
+func newOwner() string {
+	host, _ := os.Hostname()
+	random := make([]byte, 4)
+	rand.Read(random)
+	return fmt.Sprintf("%s:%d:%s", host, os.Getpid(), hex.EncodeToString(random))
+}
@@ -0,0 +1,137 @@
+// Package migrations brings the DB up to date with the server: it creates and updates the indexes the DAOs' queries
+// rely on, and backfills documents written before the fields they now carry existed.
+//
+// Migrations are numbered, and applied in order, each once; the Migrations collection records those that have been
+// applied. Instances of the server starting at the same time take turns through a lock in the same collection,
+// leased for a short time and extended while they migrate, so that another instance takes over if one dies while
+// migrating. A migration may then be applied again after it was interrupted, so every migration must be safe to
+// repeat.
+//
+// Migrations are never changed once released: a change to the DB is a new migration, at the end of All.
+package migrations
+
+import (
+	"context"
+
+	"github.com/L4TTiCe/ToDo-Go/server/config"
+	"github.com/L4TTiCe/ToDo-Go/server/dao/ToDoItemDao"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migration is a change to the DB, applied by Up.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context) error
+}
+
+// All are the migrations, in the order they are applied.
+var All = []Migration{
+	{
+		Version:     1,
+		Description: "Index items by createdAt and deadline, for the range queries and sorts of lists",
+		Up: func(ctx context.Context) error {
+			return ensureIndexes(ctx, config.ToDoItemsCollection,
+				index(bson.D{{Key: "createdAt", Value: 1}}, nil),
+				index(bson.D{{Key: "deadline", Value: 1}}, nil),
+			)
+		},
+	},
+	{
+		Version:     2,
+		Description: "Index items and tombstones by their position in the change sequence, for syncing",
+		Up: func(ctx context.Context) error {
+			if err := ensureIndexes(ctx, config.ToDoItemsCollection, index(bson.D{{Key: "sequence", Value: 1}}, nil)); err != nil {
+				return err
+			}
+			return ensureIndexes(ctx, config.TombstonesCollection, index(bson.D{{Key: "sequence", Value: 1}}, nil))
+		},
+	},
+	{
+		Version:     3,
+		Description: "Index items, tombstones and feed tokens by the IDs they are looked up by",
+		Up: func(ctx context.Context) error {
+			// Most items and tombstones have neither a client ID nor an external ID
+			sparse := options.Index().SetSparse(true)
+			err := ensureIndexes(ctx, config.ToDoItemsCollection,
+				index(bson.D{{Key: "clientId", Value: 1}}, sparse),
+				index(bson.D{{Key: "externalId", Value: 1}}, sparse),
+			)
+			if err != nil {
+				return err
+			}
+			err = ensureIndexes(ctx, config.TombstonesCollection,
+				index(bson.D{{Key: "itemId", Value: 1}}, nil),
+				index(bson.D{{Key: "clientId", Value: 1}}, sparse),
+			)
+			if err != nil {
+				return err
+			}
+			return ensureIndexes(ctx, config.FeedTokensCollection, index(bson.D{{Key: "hash", Value: 1}}, nil))
+		},
+	},
+	{
+		Version:     4,
+		Description: "Index items and tombstones by when they were written, for feeds",
+		Up: func(ctx context.Context) error {
+			if err := ensureIndexes(ctx, config.ToDoItemsCollection, index(bson.D{{Key: "updatedAt", Value: 1}}, nil)); err != nil {
+				return err
+			}
+			return ensureIndexes(ctx, config.TombstonesCollection, index(bson.D{{Key: "deletedAt", Value: 1}}, nil))
+		},
+	},
+	{
+		Version:     5,
+		Description: "Let MongoDB delete expired idempotency records",
+		Up: func(ctx context.Context) error {
+			return ensureIndexes(ctx, config.IdempotencyKeysCollection,
+				index(bson.D{{Key: "expiresAt", Value: 1}}, options.Index().SetExpireAfterSeconds(0)),
+			)
+		},
+	},
+	{
+		Version:     6,
+		Description: "Backfill updatedAt and completedAt of items written before they were recorded",
+		Up: func(ctx context.Context) error {
+			_, err := config.ToDoItemsCollection.UpdateMany(ctx,
+				bson.M{"updatedAt": bson.M{"$exists": false}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.D{{Key: "updatedAt", Value: "$createdAt"}}}}},
+			)
+			if err != nil {
+				return err
+			}
+			_, err = config.ToDoItemsCollection.UpdateMany(ctx,
+				bson.M{"completed": true, "completedAt": bson.M{"$exists": false}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.D{{Key: "completedAt", Value: "$updatedAt"}}}}},
+			)
+			return err
+		},
+	},
+	{
+		Version:     7,
+		Description: "Number the items written before the change sequence existed",
+		Up:          ToDoItemDao.AssignSequences,
+	},
+	{
+		Version:     8,
+		Description: "Make the client IDs of items unique, so that concurrent replays of a sync cannot create an item twice",
+		Up: func(ctx context.Context) error {
+			return ensureIndexes(ctx, config.ToDoItemsCollection,
+				index(bson.D{{Key: "clientId", Value: 1}}, options.Index().SetSparse(true).SetUnique(true)),
+			)
+		},
+	},
+	{
+		Version:     9,
+		Description: "Index items by the names CalDAV clients stored them under",
+		Up: func(ctx context.Context) error {
+			// Only items created through CalDAV have a resource name
+			return ensureIndexes(ctx, config.ToDoItemsCollection,
+				index(bson.D{{Key: "resourceName", Value: 1}}, options.Index().SetSparse(true)),
+			)
+		},
+	},
+}
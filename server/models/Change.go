@@ -0,0 +1,19 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Types of Change.
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// Change is a struct that describes a write to a ToDoItem, as published to watchers.
+// Item is the item after the write, or the deleted item for ChangeDeleted. Timestamp is a Unix millisecond timestamp.
+type Change struct {
+	Type      string             `json:"type"`
+	ID        primitive.ObjectID `json:"id"`
+	Item      *ToDoItem          `json:"item,omitempty"`
+	Timestamp int64              `json:"timestamp"`
+}
@@ -1,11 +1,25 @@
 package models
 
-// ErrorResponse is a struct that contains the error response to be sent to the client.
+// ErrorResponse is a struct that contains the error response to be sent to the client, as an RFC 7807 problem.
 // It contains the status code, title, and detail, as well as the timestamp and path of the request.
+// Type is a URI identifying the kind of problem and Code a stable, machine-readable name for it; clients should
+// branch on these rather than on the title or detail, which are meant for people.
+// Violations lists the individual problems with an invalid request, if it was rejected by validation.
 type ErrorResponse struct {
-	Status    int    `json:"status"`
-	Title     string `json:"title"`
-	Detail    string `json:"detail"`
-	Path      string `json:"path"`
-	Timestamp int64  `json:"timestamp"`
+	Type       string      `json:"type"`
+	Code       string      `json:"code"`
+	Status     int         `json:"status"`
+	Title      string      `json:"title"`
+	Detail     string      `json:"detail"`
+	Path       string      `json:"path"`
+	Timestamp  int64       `json:"timestamp"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Violation is a single problem with a request. Pointer is a JSON pointer (RFC 6901) to the offending value in the
+// request body, e.g. /tags/0, and Parameter names the offending path or query parameter; only one of them is set.
+type Violation struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	Message   string `json:"message"`
 }
@@ -0,0 +1,17 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// FeedToken is a struct that contains a secret token granting read access to the iCalendar feed. Items are not owned
+// by users, so every token reads the same feed; User is who the token was issued to, and a token of theirs is needed
+// to create, list or revoke their tokens, unless the server's admin token creates them.
+// Only a hash of the token is stored; Token holds the secret itself and is only set in the response to its creation.
+// CreatedAt and RevokedAt are represented as Unix millisecond timestamps.
+type FeedToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	User      string             `bson:"user" json:"user"`
+	Hash      string             `bson:"hash" json:"-"`
+	Token     string             `bson:"-" json:"token,omitempty"`
+	CreatedAt int64              `bson:"createdAt" json:"createdAt"`
+	RevokedAt int64              `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+}
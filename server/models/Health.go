@@ -0,0 +1,34 @@
+package models
+
+// Statuses of a HealthReport and of its dependencies.
+const (
+	HealthOK           = "ok"
+	HealthFailing      = "failing"
+	HealthShuttingDown = "shutting-down"
+)
+
+// HealthReport is a struct that describes the health of the server and of each dependency it checked, by name.
+// Liveness reports check no dependencies.
+type HealthReport struct {
+	Status       string                      `json:"status"`
+	Build        BuildInfo                   `json:"build"`
+	Uptime       string                      `json:"uptime"`
+	Dependencies map[string]DependencyHealth `json:"dependencies,omitempty"`
+}
+
+// DependencyHealth is a struct that describes the outcome of checking a dependency.
+// LatencyMs is how long the check took, in milliseconds.
+type DependencyHealth struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+// BuildInfo is a struct that identifies the build of the server.
+// Version and Commit are set when building, and fall back to what the Go toolchain recorded.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"buildTime,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// IdempotencyRecord is a struct that remembers the response to a request sent with an Idempotency-Key header, so
+// that retries of the request receive the same response instead of repeating it.
+// Fingerprint is a hash of the method, URL and body of the request. The response is only stored once the request is
+// Completed. ExpiresAt is a date, rather than a Unix millisecond timestamp, so that MongoDB can expire the record.
+type IdempotencyRecord struct {
+	Key         string    `bson:"_id"`
+	Fingerprint string    `bson:"fingerprint"`
+	Completed   bool      `bson:"completed"`
+	Status      int       `bson:"status,omitempty"`
+	ContentType string    `bson:"contentType,omitempty"`
+	Location    string    `bson:"location,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	CreatedAt   int64     `bson:"createdAt"`
+	ExpiresAt   time.Time `bson:"expiresAt"`
+}
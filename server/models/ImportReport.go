@@ -0,0 +1,51 @@
+package models
+
+// Statuses of a single row in an ImportReport.
+const (
+	ImportCreated   = "created"
+	ImportValid     = "valid"
+	ImportDuplicate = "duplicate"
+	ImportInvalid   = "invalid"
+	ImportFailed    = "failed"
+)
+
+// ImportReport is a struct that describes the outcome of an import.
+// In a dry run nothing is created, and rows that would have been created are reported as valid.
+type ImportReport struct {
+	DryRun     bool              `json:"dryRun"`
+	Total      int               `json:"total"`
+	Created    int               `json:"created"`
+	Valid      int               `json:"valid"`
+	Duplicates int               `json:"duplicates"`
+	Invalid    int               `json:"invalid"`
+	Failed     int               `json:"failed"`
+	Rows       []ImportRowResult `json:"rows"`
+}
+
+// ImportRowResult is a struct that describes the outcome of importing a single row.
+// Row is the 1-based record number in the imported file, ID is set for created items and Error for invalid or failed rows.
+type ImportRowResult struct {
+	Row        int    `json:"row"`
+	Status     string `json:"status"`
+	ExternalID string `json:"externalId,omitempty"`
+	ID         string `json:"id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Add records the outcome of a row and updates the totals.
+func (r *ImportReport) Add(row ImportRowResult) {
+	r.Total++
+	switch row.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportValid:
+		r.Valid++
+	case ImportDuplicate:
+		r.Duplicates++
+	case ImportInvalid:
+		r.Invalid++
+	case ImportFailed:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, row)
+}
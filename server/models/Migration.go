@@ -0,0 +1,9 @@
+package models
+
+// Migration is a struct that records a migration of the DB that has been applied, so that it is not applied again.
+// Version orders the migrations, and AppliedAt is represented as a Unix millisecond timestamp.
+type Migration struct {
+	Version     int    `bson:"_id" json:"version"`
+	Description string `bson:"description" json:"description"`
+	AppliedAt   int64  `bson:"appliedAt" json:"appliedAt"`
+}
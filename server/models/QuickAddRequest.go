@@ -0,0 +1,7 @@
+package models
+
+// QuickAddRequest is the body of a quick-add request.
+// Text is a free-form description of the item, e.g. "Submit expense report tomorrow 5pm #finance !high every month".
+type QuickAddRequest struct {
+	Text string `json:"text"`
+}
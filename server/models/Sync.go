@@ -0,0 +1,83 @@
+package models
+
+// Strategies for resolving conflicts in a SyncRequest.
+const (
+	// SyncLastWriterWins applies every field of a change that is newer than the server's, and drops the others.
+	SyncLastWriterWins = "lww"
+	// SyncReport applies a change only if none of its fields conflict, and otherwise leaves it for the client to resolve.
+	SyncReport = "report"
+)
+
+// Statuses of a single change in a SyncResponse.
+const (
+	SyncApplied    = "applied"
+	SyncUnchanged  = "unchanged"
+	SyncConflicted = "conflict"
+	SyncRejected   = "rejected"
+)
+
+// SyncRequest is a struct that holds the changes an offline client made since its last sync.
+// Token is the token of the last SyncResponse the client received, or empty on its first sync. Strategy is one of
+// SyncLastWriterWins, the default, or SyncReport.
+type SyncRequest struct {
+	Token    string       `json:"token,omitempty"`
+	Strategy string       `json:"strategy,omitempty"`
+	Changes  []SyncChange `json:"changes,omitempty"`
+}
+
+// SyncChange is a struct that describes a change made by an offline client.
+// ClientID is the identifier the client gave the item, and is required for items the client created, which have no
+// ID yet. Fields holds the fields that were set, and ModifiedAt is the Unix millisecond timestamp at which the client
+// made the change. If Deleted is set, the item was deleted and Fields is ignored.
+type SyncChange struct {
+	ID         string      `json:"id,omitempty"`
+	ClientID   string      `json:"clientId,omitempty"`
+	Deleted    bool        `json:"deleted,omitempty"`
+	Fields     *SyncFields `json:"fields,omitempty"`
+	ModifiedAt int64       `json:"modifiedAt"`
+}
+
+// SyncFields is a struct that holds the fields of a ToDoItem set by a SyncChange. Fields that are nil were not changed.
+type SyncFields struct {
+	Title      *string   `json:"title,omitempty"`
+	Completed  *bool     `json:"completed,omitempty"`
+	Deadline   *int64    `json:"deadline,omitempty"`
+	List       *string   `json:"list,omitempty"`
+	Tags       *[]string `json:"tags,omitempty"`
+	Priority   *string   `json:"priority,omitempty"`
+	Recurrence *string   `json:"recurrence,omitempty"`
+}
+
+// SyncResponse is a struct that holds the outcome of the changes in a SyncRequest, in the same order, and the changes
+// made on the server since the request's token.
+// Token is to be sent with the next SyncRequest. If More is set, there are further changes, which the client should
+// fetch straight away with Token. If Reset is set, the request's token is unknown to the server and the client must
+// replace everything it has with Items.
+type SyncResponse struct {
+	Token   string       `json:"token"`
+	More    bool         `json:"more,omitempty"`
+	Reset   bool         `json:"reset,omitempty"`
+	Results []SyncResult `json:"results"`
+	Items   []ToDoItem   `json:"items"`
+	Deleted []Tombstone  `json:"deleted"`
+}
+
+// SyncResult is a struct that describes the outcome of a single SyncChange.
+// ID is the ID of the item on the server, also for items the client created. Conflicts lists the fields that were
+// changed on the server after the client changed them, and Error is set for rejected changes.
+type SyncResult struct {
+	ID        string         `json:"id,omitempty"`
+	ClientID  string         `json:"clientId,omitempty"`
+	Status    string         `json:"status"`
+	Conflicts []SyncConflict `json:"conflicts,omitempty"`
+	Error     *ErrorResponse `json:"error,omitempty"`
+}
+
+// SyncConflict is a struct that describes a field that was changed both by the client and on the server.
+// Value is the value the server kept, and ModifiedAt is the Unix millisecond timestamp at which it was written.
+// For a change to a deleted item, Field is empty.
+type SyncConflict struct {
+	Field      string      `json:"field,omitempty"`
+	Value      interface{} `json:"value,omitempty"`
+	ModifiedAt int64       `json:"modifiedAt"`
+}
@@ -2,13 +2,48 @@ package models
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
+// Priorities that can be assigned to a ToDoItem.
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+)
+
 // ToDoItem is a struct that contains the ToDoItem data.
 // CreatedAt is a timestamp that are automatically set when the ToDoItem is created, and is represented as a Unix millisecond timestamp.
 // Similarly, deadline is an optional timestamp that represents the deadline of the ToDoItem.
+// List, Tags, Priority and Recurrence are optional; Recurrence is stored as an iCalendar RRULE value (e.g. "FREQ=MONTHLY").
+// UpdatedAt is set on every write, and CompletedAt when the item is marked as completed, both as Unix millisecond timestamps.
+// ExternalID is the identifier of an item imported from another tool, and is used to detect duplicate imports.
+// ClientID is the identifier an offline client gave an item it created, and is used to detect replayed syncs.
+// ResourceName is the name a CalDAV client stored an item it created under, which may differ from its UID.
+// Sequence is the position of the item's last write in the change sequence, and Versions holds the Unix millisecond
+// timestamp of the last write to each field, by JSON name, to resolve conflicting syncs field by field.
 type ToDoItem struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	Title     string             `bson:"title" json:"title"`
-	Completed bool               `bson:"completed" json:"completed,omitempty"`
-	CreatedAt int64              `bson:"createdAt" json:"createdAt,omitempty"`
-	Deadline  int64              `bson:"deadline,omitempty" json:"deadline,omitempty"`
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	Title       string             `bson:"title" json:"title"`
+	Completed   bool               `bson:"completed" json:"completed,omitempty"`
+	CreatedAt   int64              `bson:"createdAt" json:"createdAt,omitempty"`
+	Deadline    int64              `bson:"deadline,omitempty" json:"deadline,omitempty"`
+	List        string             `bson:"list,omitempty" json:"list,omitempty"`
+	Tags        []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+	Priority    string             `bson:"priority,omitempty" json:"priority,omitempty"`
+	Recurrence  string             `bson:"recurrence,omitempty" json:"recurrence,omitempty"`
+	ExternalID  string             `bson:"externalId,omitempty" json:"externalId,omitempty"`
+	UpdatedAt   int64              `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+	CompletedAt int64              `bson:"completedAt,omitempty" json:"completedAt,omitempty"`
+	ClientID    string             `bson:"clientId,omitempty" json:"clientId,omitempty"`
+	Sequence    int64              `bson:"sequence,omitempty" json:"sequence,omitempty"`
+	Versions    map[string]int64   `bson:"versions,omitempty" json:"-"`
+	// The name a CalDAV client chose is of no use to other clients
+	ResourceName string `bson:"resourceName,omitempty" json:"-"`
+}
+
+// IsValidPriority reports whether priority is empty or one of the known priorities.
+func IsValidPriority(priority string) bool {
+	switch priority {
+	case "", PriorityLow, PriorityMedium, PriorityHigh:
+		return true
+	}
+	return false
 }
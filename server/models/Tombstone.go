@@ -0,0 +1,16 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Tombstone is a struct that records the deletion of a ToDoItem, so that clients syncing changes can learn about it.
+// DeletedAt is represented as a Unix millisecond timestamp, and Sequence is the position of the delete in the change sequence.
+// ResourceName is the name the item had in the CalDAV collection, if a CalDAV client chose it.
+type Tombstone struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	ItemID       primitive.ObjectID `bson:"itemId" json:"itemId"`
+	ExternalID   string             `bson:"externalId,omitempty" json:"externalId,omitempty"`
+	ClientID     string             `bson:"clientId,omitempty" json:"clientId,omitempty"`
+	DeletedAt    int64              `bson:"deletedAt" json:"deletedAt"`
+	Sequence     int64              `bson:"sequence,omitempty" json:"sequence,omitempty"`
+	ResourceName string             `bson:"resourceName,omitempty" json:"-"`
+}
@@ -0,0 +1,188 @@
+// Package offline reconciles the changes made by clients while they were offline with those made on the server.
+//
+// Conflicts are resolved field by field: each field of an item records when it was last written, in
+// ToDoItem.Versions, and a client's change to a field only replaces it if the client made the change at or after
+// that time. Clients date their changes with their own clock, so a change dated in the future is taken as made now.
+package offline
+
+import (
+	"reflect"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+// field is a field of a ToDoItem that clients can change.
+type field struct {
+	name string
+	get  func(item *models.ToDoItem) interface{}
+	// set sets the field of item from fields, and reports whether fields holds it.
+	set func(item *models.ToDoItem, fields *models.SyncFields) bool
+}
+
+// fields are the fields that clients can change, by JSON name.
+var fields = []field{
+	{
+		name: "title",
+		get:  func(item *models.ToDoItem) interface{} { return item.Title },
+		set: func(item *models.ToDoItem, fields *models.SyncFields) bool {
+			if fields.Title != nil {
+				item.Title = *fields.Title
+			}
+			return fields.Title != nil
+		},
+	},
+	{
+		name: "completed",
+		get:  func(item *models.ToDoItem) interface{} { return item.Completed },
+		set: func(item *models.ToDoItem, fields *models.SyncFields) bool {
+			if fields.Completed != nil {
+				item.Completed = *fields.Completed
+			}
+			return fields.Completed != nil
+		},
+	},
+	{
+		name: "deadline",
+		get:  func(item *models.ToDoItem) interface{} { return item.Deadline },
+		set: func(item *models.ToDoItem, fields *models.SyncFields) bool {
+			if fields.Deadline != nil {
+				item.Deadline = *fields.Deadline
+			}
+			return fields.Deadline != nil
+		},
+	},
+	{
+		name: "list",
+		get:  func(item *models.ToDoItem) interface{} { return item.List },
+		set: func(item *models.ToDoItem, fields *models.SyncFields) bool {
+			if fields.List != nil {
+				item.List = *fields.List
+			}
+			return fields.List != nil
+		},
+	},
+	{
+		name: "tags",
+		get: func(item *models.ToDoItem) interface{} {
+			// An item without tags may hold either nil or an empty slice, which are the same to clients
+			if len(item.Tags) == 0 {
+				return []string{}
+			}
+			return item.Tags
+		},
+		set: func(item *models.ToDoItem, fields *models.SyncFields) bool {
+			if fields.Tags != nil {
+				item.Tags = *fields.Tags
+			}
+			return fields.Tags != nil
+		},
+	},
+	{
+		name: "priority",
+		get:  func(item *models.ToDoItem) interface{} { return item.Priority },
+		set: func(item *models.ToDoItem, fields *models.SyncFields) bool {
+			if fields.Priority != nil {
+				item.Priority = *fields.Priority
+			}
+			return fields.Priority != nil
+		},
+	},
+	{
+		name: "recurrence",
+		get:  func(item *models.ToDoItem) interface{} { return item.Recurrence },
+		set: func(item *models.ToDoItem, fields *models.SyncFields) bool {
+			if fields.Recurrence != nil {
+				item.Recurrence = *fields.Recurrence
+			}
+			return fields.Recurrence != nil
+		},
+	},
+}
+
+// Version returns when a field of an item was last written. Items written before versions were recorded fall back to
+// the time of their last write.
+func Version(item *models.ToDoItem, name string) int64 {
+	if version, ok := item.Versions[name]; ok {
+		return version
+	}
+	return item.UpdatedAt
+}
+
+// Stamp records that every field of item that differs from previous was written at the given time, and that the
+// others were written when previous says they were. previous is nil for a new item, whose fields are all written.
+func Stamp(item *models.ToDoItem, previous *models.ToDoItem, at int64) {
+	versions := map[string]int64{}
+	for _, f := range fields {
+		if previous == nil || !reflect.DeepEqual(f.get(item), f.get(previous)) {
+			versions[f.name] = at
+		} else {
+			versions[f.name] = Version(previous, f.name)
+		}
+	}
+	item.Versions = versions
+}
+
+// Merge applies a change made by a client at modifiedAt to item, field by field. Fields the client changed before
+// the server last wrote them are kept, and returned as conflicts if their values differ. If strategy is
+// models.SyncReport and there is any conflict, nothing is applied.
+// It returns whether item was changed.
+func Merge(item *models.ToDoItem, change *models.SyncFields, modifiedAt int64, strategy string) (bool, []models.SyncConflict) {
+	if change == nil {
+		return false, nil
+	}
+
+	merged := *item
+	merged.Versions = map[string]int64{}
+	for _, f := range fields {
+		merged.Versions[f.name] = Version(item, f.name)
+	}
+
+	changed := false
+	var conflicts []models.SyncConflict
+	for _, f := range fields {
+		candidate := merged
+		if !f.set(&candidate, change) || reflect.DeepEqual(f.get(&candidate), f.get(&merged)) {
+			continue
+		}
+
+		version := Version(item, f.name)
+		if modifiedAt < version {
+			conflicts = append(conflicts, models.SyncConflict{Field: f.name, Value: f.get(item), ModifiedAt: version})
+			continue
+		}
+
+		f.set(&merged, change)
+		merged.Versions[f.name] = modifiedAt
+		changed = true
+	}
+
+	if strategy == models.SyncReport && len(conflicts) > 0 {
+		return false, conflicts
+	}
+	if changed {
+		*item = merged
+	}
+	return changed, conflicts
+}
+
+// Newer returns the fields of item that were written after modifiedAt, as conflicts with a change made then.
+func Newer(item *models.ToDoItem, modifiedAt int64) []models.SyncConflict {
+	var conflicts []models.SyncConflict
+	for _, f := range fields {
+		if version := Version(item, f.name); version > modifiedAt {
+			conflicts = append(conflicts, models.SyncConflict{Field: f.name, Value: f.get(item), ModifiedAt: version})
+		}
+	}
+	return conflicts
+}
+
+// Apply sets the fields of a change on a new item, recording every field, set or not, as written at modifiedAt.
+func Apply(item *models.ToDoItem, change *models.SyncFields, modifiedAt int64) {
+	item.Versions = map[string]int64{}
+	for _, f := range fields {
+		if change != nil {
+			f.set(item, change)
+		}
+		item.Versions[f.name] = modifiedAt
+	}
+}
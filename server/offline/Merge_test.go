@@ -0,0 +1,248 @@
+package offline
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+func text(s string) *string { return &s }
+func flag(b bool) *bool     { return &b }
+
+// versioned returns an item whose title was last written at 100, completed at 200 and the other fields at 50.
+func versioned() models.ToDoItem {
+	return models.ToDoItem{
+		Title:     "Pay rent",
+		Completed: false,
+		Tags:      []string{"home"},
+		UpdatedAt: 200,
+		Versions: map[string]int64{
+			"title": 100, "completed": 200, "deadline": 50, "list": 50, "tags": 50, "priority": 50, "recurrence": 50,
+		},
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name       string
+		item       models.ToDoItem
+		change     *models.SyncFields
+		modifiedAt int64
+		strategy   string
+		changed    bool
+		conflicts  []models.SyncConflict
+		want       models.ToDoItem
+	}{
+		{
+			name:       "newer change",
+			item:       versioned(),
+			change:     &models.SyncFields{Title: text("Pay the rent")},
+			modifiedAt: 150,
+			changed:    true,
+			want: func() models.ToDoItem {
+				item := versioned()
+				item.Title = "Pay the rent"
+				item.Versions["title"] = 150
+				return item
+			}(),
+		},
+		{
+			name:       "older change",
+			item:       versioned(),
+			change:     &models.SyncFields{Completed: flag(true)},
+			modifiedAt: 150,
+			conflicts:  []models.SyncConflict{{Field: "completed", Value: false, ModifiedAt: 200}},
+			want:       versioned(),
+		},
+		{
+			// A change made at the same time as the server's write wins
+			name:       "tie",
+			item:       versioned(),
+			change:     &models.SyncFields{Completed: flag(true)},
+			modifiedAt: 200,
+			changed:    true,
+			want: func() models.ToDoItem {
+				item := versioned()
+				item.Completed = true
+				return item
+			}(),
+		},
+		{
+			// The client's newer title is applied while the server's newer completion is kept
+			name:       "concurrent edits of different fields",
+			item:       versioned(),
+			change:     &models.SyncFields{Title: text("Pay the rent"), Completed: flag(true)},
+			modifiedAt: 150,
+			changed:    true,
+			conflicts:  []models.SyncConflict{{Field: "completed", Value: false, ModifiedAt: 200}},
+			want: func() models.ToDoItem {
+				item := versioned()
+				item.Title = "Pay the rent"
+				item.Versions["title"] = 150
+				return item
+			}(),
+		},
+		{
+			name:       "concurrent edits reported",
+			item:       versioned(),
+			change:     &models.SyncFields{Title: text("Pay the rent"), Completed: flag(true)},
+			modifiedAt: 150,
+			strategy:   models.SyncReport,
+			conflicts:  []models.SyncConflict{{Field: "completed", Value: false, ModifiedAt: 200}},
+			want:       versioned(),
+		},
+		{
+			name:       "reported without conflicts",
+			item:       versioned(),
+			change:     &models.SyncFields{Title: text("Pay the rent")},
+			modifiedAt: 150,
+			strategy:   models.SyncReport,
+			changed:    true,
+			want: func() models.ToDoItem {
+				item := versioned()
+				item.Title = "Pay the rent"
+				item.Versions["title"] = 150
+				return item
+			}(),
+		},
+		{
+			// An old change that the item already holds is no conflict, which makes replays harmless
+			name:       "old change to the same value",
+			item:       versioned(),
+			change:     &models.SyncFields{Title: text("Pay rent"), Completed: flag(false)},
+			modifiedAt: 10,
+			want:       versioned(),
+		},
+		{
+			name:       "no tags are the same as empty tags",
+			item:       func() models.ToDoItem { item := versioned(); item.Tags = nil; return item }(),
+			change:     &models.SyncFields{Tags: &[]string{}},
+			modifiedAt: 10,
+			want:       func() models.ToDoItem { item := versioned(); item.Tags = nil; return item }(),
+		},
+		{
+			name:       "no change",
+			item:       versioned(),
+			modifiedAt: 300,
+			want:       versioned(),
+		},
+		{
+			// Items written before versions were recorded fall back to UpdatedAt
+			name:       "unversioned item",
+			item:       models.ToDoItem{Title: "Pay rent", UpdatedAt: 200},
+			change:     &models.SyncFields{Title: text("Pay the rent"), List: text("home")},
+			modifiedAt: 100,
+			conflicts: []models.SyncConflict{
+				{Field: "title", Value: "Pay rent", ModifiedAt: 200},
+				{Field: "list", Value: "", ModifiedAt: 200},
+			},
+			want: models.ToDoItem{Title: "Pay rent", UpdatedAt: 200},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item := test.item
+			strategy := test.strategy
+			if strategy == "" {
+				strategy = models.SyncLastWriterWins
+			}
+
+			changed, conflicts := Merge(&item, test.change, test.modifiedAt, strategy)
+
+			if changed != test.changed {
+				t.Errorf("changed = %t, want %t", changed, test.changed)
+			}
+			if !reflect.DeepEqual(conflicts, test.conflicts) {
+				t.Errorf("conflicts = %+v, want %+v", conflicts, test.conflicts)
+			}
+			if !reflect.DeepEqual(item, test.want) {
+				t.Errorf("item = %+v, want %+v", item, test.want)
+			}
+		})
+	}
+}
+
+// TestMergeReplay merges the same change twice, as a client does that resends a sync whose response it missed.
+func TestMergeReplay(t *testing.T) {
+	item := versioned()
+	change := &models.SyncFields{Title: text("Pay the rent"), Completed: flag(true)}
+
+	if changed, _ := Merge(&item, change, 250, models.SyncLastWriterWins); !changed {
+		t.Fatal("first merge changed nothing")
+	}
+	merged := item
+
+	changed, conflicts := Merge(&item, change, 250, models.SyncLastWriterWins)
+	if changed || conflicts != nil || !reflect.DeepEqual(item, merged) {
+		t.Errorf("replay = %t, %+v, %+v, want nothing changed", changed, conflicts, item)
+	}
+}
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		modifiedAt int64
+		want       []models.SyncConflict
+	}{
+		{modifiedAt: 300},
+		{modifiedAt: 200},
+		{modifiedAt: 150, want: []models.SyncConflict{{Field: "completed", Value: false, ModifiedAt: 200}}},
+		{modifiedAt: 60, want: []models.SyncConflict{
+			{Field: "title", Value: "Pay rent", ModifiedAt: 100},
+			{Field: "completed", Value: false, ModifiedAt: 200},
+		}},
+	}
+
+	for _, test := range tests {
+		item := versioned()
+		if got := Newer(&item, test.modifiedAt); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Newer(%d) = %+v, want %+v", test.modifiedAt, got, test.want)
+		}
+	}
+}
+
+func TestStamp(t *testing.T) {
+	previous := versioned()
+	previous.Tags = nil
+
+	item := versioned()
+	item.Title = "Pay the rent"
+	item.Tags = []string{}
+	item.Priority = models.PriorityHigh
+	item.Versions = nil
+	Stamp(&item, &previous, 500)
+
+	// Empty tags are no change from no tags, as they are the same to clients
+	want := map[string]int64{
+		"title": 500, "completed": 200, "deadline": 50, "list": 50, "tags": 50, "priority": 500, "recurrence": 50,
+	}
+	if !reflect.DeepEqual(item.Versions, want) {
+		t.Errorf("Versions = %v, want %v", item.Versions, want)
+	}
+
+	created := models.ToDoItem{Title: "Pay rent"}
+	Stamp(&created, nil, 500)
+	for _, f := range fields {
+		if created.Versions[f.name] != 500 {
+			t.Errorf("new item: Versions[%s] = %d, want 500", f.name, created.Versions[f.name])
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	item := models.ToDoItem{ClientID: "client-1"}
+	Apply(&item, &models.SyncFields{Title: text("Pay rent"), Tags: &[]string{"home"}}, 500)
+
+	want := models.ToDoItem{
+		ClientID: "client-1",
+		Title:    "Pay rent",
+		Tags:     []string{"home"},
+		Versions: map[string]int64{
+			"title": 500, "completed": 500, "deadline": 500, "list": 500, "tags": 500, "priority": 500, "recurrence": 500,
+		},
+	}
+	if !reflect.DeepEqual(item, want) {
+		t.Errorf("item = %+v, want %+v", item, want)
+	}
+}
@@ -0,0 +1,131 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var objectIDType = reflect.TypeOf(primitive.ObjectID{})
+
+// schemas generates JSON schemas from Go types, following their json tags. Named structs are added to the
+// components and referenced, so that each model is described once.
+type schemas struct {
+	components map[string]*Schema
+
+	// properties documents and constrains individual properties, keyed by "Type.property"
+	properties map[string]Schema
+}
+
+// Of returns the schema of a Go value's type.
+func (s *schemas) Of(value interface{}) *Schema {
+	return s.of(reflect.TypeOf(value))
+}
+
+func (s *schemas) of(t reflect.Type) *Schema {
+	if t == objectIDType {
+		return &Schema{Type: "string", Format: "objectid", Description: "A 24 character hexadecimal MongoDB ObjectID."}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return s.of(t.Elem())
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		if _, ok := s.components[t.Name()]; !ok {
+			// Registered before recursing, so that self-referencing types terminate
+			s.components[t.Name()] = &Schema{}
+			*s.components[t.Name()] = *s.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	}
+
+	// Interfaces, e.g. the IDs of MongoDB results, can hold any value
+	return &Schema{}
+}
+
+// Partial returns the schema of a named struct with none of its properties required, added to the components under
+// name. It describes bodies that only carry the fields they change.
+func (s *schemas) Partial(value interface{}, name string) *Schema {
+	t := reflect.TypeOf(value)
+	s.of(t)
+
+	partial := *s.components[t.Name()]
+	partial.Required = nil
+	s.components[name] = &partial
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// object returns the schema of a struct. Fields without omitempty are required.
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		omitEmpty := false
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, option := range parts[1:] {
+				omitEmpty = omitEmpty || option == "omitempty"
+			}
+		}
+
+		property := s.of(field.Type)
+		// A $ref cannot carry anything else in OpenAPI 3.0
+		if override, ok := s.properties[t.Name()+"."+name]; ok && property.Ref == "" {
+			merge(property, &override)
+		}
+		schema.Properties[name] = property
+
+		if !omitEmpty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+// merge copies the documentation and constraints that are set in override onto a generated schema.
+func merge(schema *Schema, override *Schema) {
+	if override.Description != "" {
+		schema.Description = override.Description
+	}
+	if override.Enum != nil {
+		schema.Enum = override.Enum
+	}
+	if override.MinLength != nil {
+		schema.MinLength = override.MinLength
+	}
+	if override.Pattern != "" {
+		schema.Pattern = override.Pattern
+	}
+	schema.ReadOnly = schema.ReadOnly || override.ReadOnly
+}
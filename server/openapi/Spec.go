@@ -0,0 +1,418 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Version is the version of the API described by the document.
+const Version = "1.0.0"
+
+var (
+	spec     *Document
+	specOnce sync.Once
+)
+
+// Spec returns the OpenAPI document describing the routes in routes.ToDoRoutes. It is built once, on first use.
+func Spec() *Document {
+	specOnce.Do(func() {
+		spec = build()
+	})
+	return spec
+}
+
+// Path converts a Gin route path to an OpenAPI path, e.g. /todo/:id to /todo/{id}.
+func Path(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// ObjectIDPattern matches the hex form of a MongoDB ObjectID.
+const ObjectIDPattern = "^[0-9a-fA-F]{24}$"
+
+var minLength = 1
+
+// properties documents and constrains model properties. Read-only properties are set by the server, are ignored in
+// request bodies and are not required in them.
+var properties = map[string]Schema{
+	"ToDoItem._id":            {ReadOnly: true, Description: "Assigned by the server."},
+	"ToDoItem.title":          {MinLength: &minLength},
+	"ToDoItem.createdAt":      {ReadOnly: true, Description: "Unix millisecond timestamp, set by the server when the item is created."},
+	"ToDoItem.deadline":       {Description: "Unix millisecond timestamp."},
+	"ToDoItem.priority":       {Enum: []string{models.PriorityLow, models.PriorityMedium, models.PriorityHigh}},
+	"ToDoItem.recurrence":     {Description: "An iCalendar RRULE value, e.g. FREQ=MONTHLY."},
+	"ToDoItem.externalId":     {Description: "The identifier of an item imported from another tool, used to detect duplicate imports."},
+	"ToDoItem.updatedAt":      {ReadOnly: true, Description: "Unix millisecond timestamp, set by the server on every write."},
+	"ToDoItem.completedAt":    {ReadOnly: true, Description: "Unix millisecond timestamp, set by the server when the item is completed."},
+	"ToDoItem.clientId":       {ReadOnly: true, Description: "The identifier an offline client gave the item it created, see syncItems."},
+	"ToDoItem.sequence":       {ReadOnly: true, Description: "The position of the item's last write in the change sequence, see syncItems."},
+	"Tombstone.itemId":        {Description: "The ID of the deleted item."},
+	"Tombstone.deletedAt":     {Description: "Unix millisecond timestamp of the delete."},
+	"SyncRequest.token":       {Description: "The token of the last response, or empty for the first sync."},
+	"SyncRequest.strategy":    {Enum: []string{models.SyncLastWriterWins, models.SyncReport}, Description: "lww applies every field changed after the server last wrote it and reports the others; report applies a change only if none of its fields conflict. Defaults to lww."},
+	"SyncRequest.changes":     {Description: "The changes made by the client since its last sync, in the order they were made. At most 1000."},
+	"SyncChange.id":           {Description: "The ID of the item, if the server has assigned one.", Pattern: ObjectIDPattern},
+	"SyncChange.clientId":     {Description: "The identifier the client gave the item. Required for items the client created."},
+	"SyncChange.deleted":      {Description: "Whether the client deleted the item."},
+	"SyncChange.modifiedAt":   {Description: "Unix millisecond timestamp at which the client made the change, by its clock."},
+	"SyncResponse.token":      {Description: "The token to send with the next request."},
+	"SyncResponse.more":       {Description: "Whether there are further changes, to be requested straight away with token."},
+	"SyncResponse.reset":      {Description: "Whether the request's token was unknown, so that items holds every item and the client must discard the others."},
+	"SyncResponse.results":    {Description: "The outcome of each change of the request, in the same order."},
+	"SyncResponse.items":      {Description: "The items written since the request's token, in order."},
+	"SyncResponse.deleted":    {Description: "The items deleted since the request's token, in order."},
+	"SyncResult.status":       {Enum: []string{models.SyncApplied, models.SyncUnchanged, models.SyncConflicted, models.SyncRejected}},
+	"SyncResult.conflicts":    {Description: "The fields the server wrote after the client changed them, with the values the server kept. A conflict without a field means the item was deleted."},
+	"SyncConflict.modifiedAt": {Description: "Unix millisecond timestamp at which the server wrote the value."},
+	"ErrorResponse.type":      {Description: "A URI reference identifying the kind of problem. It resolves to a description of it."},
+	"ErrorResponse.code":      {Enum: problemCodes(), Description: "A stable, machine-readable name for the kind of problem."},
+	"ErrorResponse.status":    {Description: "The HTTP status code."},
+	"ErrorResponse.path":      {Description: "The method and URL of the request."},
+	"ErrorResponse.timestamp": {Description: "Unix millisecond timestamp of the error."},
+	"Violation.pointer":       {Description: "A JSON pointer to the offending value in the request body."},
+	"Violation.parameter":     {Description: "The name of the offending path or query parameter."},
+	"FeedToken._id":           {ReadOnly: true},
+	"FeedToken.user":          {MinLength: &minLength},
+	"FeedToken.token":         {ReadOnly: true, Description: "The secret token. Only returned when the token is created."},
+	"FeedToken.createdAt":     {ReadOnly: true},
+	"FeedToken.revokedAt":     {ReadOnly: true},
+	"QuickAddRequest.text":    {MinLength: &minLength, Description: "A free-form description, e.g. \"Submit expense report tomorrow 5pm #finance !high every month\"."},
+}
+
+// Query parameters shared by several operations.
+var (
+	idParameter = &Parameter{
+		Name: "id", In: "path", Required: true,
+		Description: "The ObjectID of the item.",
+		Schema:      &Schema{Type: "string", Pattern: ObjectIDPattern},
+	}
+	queryParameters = []*Parameter{
+		{
+			Name: "attrib", In: "query",
+			Description: "The attribute to sort by, and to compare with before, after, start and end. Defaults to createdAt.",
+			Schema:      &Schema{Type: "string", Enum: []string{"title", "completed", "createdAt", "deadline"}},
+		},
+		{
+			Name: "sort", In: "query",
+			Description: "The sort order.",
+			Schema:      &Schema{Type: "string", Enum: []string{"asc", "desc", "1", "-1"}, Default: "asc"},
+		},
+		{
+			Name: "before", In: "query",
+			Description: "Only items whose attrib is at or before this Unix millisecond timestamp. Requires attrib.",
+			Schema:      &Schema{Type: "integer", Format: "int64"},
+		},
+		{
+			Name: "after", In: "query",
+			Description: "Only items whose attrib is at or after this Unix millisecond timestamp. Requires attrib.",
+			Schema:      &Schema{Type: "integer", Format: "int64"},
+		},
+		{
+			Name: "start", In: "query",
+			Description: "Start of a range of Unix millisecond timestamps, used together with end. Requires attrib.",
+			Schema:      &Schema{Type: "integer", Format: "int64"},
+		},
+		{
+			Name: "end", In: "query",
+			Description: "End of a range of Unix millisecond timestamps, used together with start. Requires attrib.",
+			Schema:      &Schema{Type: "integer", Format: "int64"},
+		},
+	}
+	formatParameter = &Parameter{
+		Name: "format", In: "query",
+		Description: "The file format.",
+		Schema:      &Schema{Type: "string", Enum: []string{"json", "csv", "ics", "todotxt"}, Default: "json"},
+	}
+	tzParameter = &Parameter{
+		Name: "tz", In: "query",
+		Description: "An IANA time zone name used to interpret dates, e.g. Europe/Berlin. Defaults to the server's time zone.",
+		Schema:      &Schema{Type: "string"},
+	}
+	idempotencyKeyParameter = &Parameter{
+		Name: "Idempotency-Key", In: "header",
+		Description: "A unique key, at most 255 characters long, that makes retries of the request return the first response instead of repeating it. Keys expire after 24 hours by default.",
+		Schema:      &Schema{Type: "string"},
+	}
+	ifNoneMatchParameter = &Parameter{
+		Name: "If-None-Match", In: "header",
+		Description: "The ETag of a response received before. If it still matches, 304 Not Modified is returned without a body.",
+		Schema:      &Schema{Type: "string"},
+	}
+	dryRunParameter = &Parameter{
+		Name: "dryRun", In: "query",
+		Description: "If true, nothing is created.",
+		Schema:      &Schema{Type: "boolean", Default: false},
+	}
+)
+
+func build() *Document {
+	s := &schemas{components: map[string]*Schema{}, properties: properties}
+
+	item := s.Of(models.ToDoItem{})
+	errorResponse := s.Of(models.ErrorResponse{})
+	feedToken := s.Of(models.FeedToken{})
+
+	errors := func(responses map[string]*Response, codes ...int) map[string]*Response {
+		// Every operation is rate limited
+		codes = append(codes, http.StatusTooManyRequests)
+		for _, code := range codes {
+			// Operations that can fail on the server use the DB, which can time out
+			if code == http.StatusInternalServerError {
+				codes = append(codes, http.StatusGatewayTimeout)
+			}
+		}
+		for _, code := range codes {
+			responses[strconv.Itoa(code)] = &Response{
+				Description: http.StatusText(code),
+				Content:     map[string]*MediaType{problem.ContentType: {Schema: errorResponse}},
+			}
+		}
+		return responses
+	}
+
+	document := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "ToDo-Go",
+			Description: "A REST API for managing to-do items. Timestamps are Unix millisecond timestamps.",
+			Version:     Version,
+		},
+		Paths: map[string]PathItem{
+			"/todo/up": {
+				"get": {
+					OperationID: "healthCheck",
+					Summary:     "Check that the ToDo routes are up",
+					Tags:        []string{"Health"},
+					Responses: map[string]*Response{
+						"200": {Description: "The routes are up", Content: textContent("text/plain")},
+					},
+				},
+			},
+			"/todo/": {
+				"get": {
+					OperationID: "listItems",
+					Summary:     "List items",
+					Description: "Lists items sorted by attrib, optionally filtered by before, after, or start and end.",
+					Tags:        []string{"Items"},
+					Parameters:  append([]*Parameter{ifNoneMatchParameter}, queryParameters...),
+					Responses: errors(map[string]*Response{
+						"200": {
+							Description: "The matching items, streamed as they are read; as NDJSON, one item per line, if Accept asks for application/x-ndjson",
+							Content: map[string]*MediaType{
+								"application/json":     {Schema: &Schema{Type: "array", Items: item}},
+								"application/x-ndjson": {Schema: item},
+							},
+						},
+						"304": {Description: "The items have not changed since the response with the ETag in If-None-Match"},
+					}, http.StatusBadRequest, http.StatusInternalServerError),
+				},
+				"post": {
+					OperationID: "createItem",
+					Summary:     "Create an item",
+					Tags:        []string{"Items"},
+					Parameters:  []*Parameter{idempotencyKeyParameter},
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(item)},
+					Responses: errors(map[string]*Response{
+						"201": {Description: "The ID of the created item", Content: jsonContent(s.Of(mongo.InsertOneResult{}))},
+					}, http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError),
+				},
+			},
+			"/todo/quick": {
+				"post": {
+					OperationID: "quickAddItem",
+					Summary:     "Create an item from a free-form description",
+					Description: "Parses tags (#tag), priorities (!high), recurrences (every month), dates and times from the text.",
+					Tags:        []string{"Items"},
+					Parameters:  []*Parameter{dryRunParameter, tzParameter, idempotencyKeyParameter},
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(s.Of(models.QuickAddRequest{}))},
+					Responses: errors(map[string]*Response{
+						"200": {Description: "The parsed item, in a dry run", Content: jsonContent(item)},
+						"201": {Description: "The ID of the created item", Content: jsonContent(s.Of(mongo.InsertOneResult{}))},
+					}, http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError),
+				},
+			},
+			"/todo/export": {
+				"get": {
+					OperationID: "exportItems",
+					Summary:     "Export items to a file",
+					Description: "Exports the items selected by the same parameters as listItems.",
+					Tags:        []string{"Transfer"},
+					Parameters:  append([]*Parameter{formatParameter, tzParameter}, queryParameters...),
+					Responses: errors(map[string]*Response{
+						"200": {Description: "The exported items", Content: fileContent()},
+					}, http.StatusBadRequest, http.StatusInternalServerError),
+				},
+			},
+			"/todo/import": {
+				"post": {
+					OperationID: "importItems",
+					Summary:     "Import items from a file",
+					Description: "Rows whose external ID already exists are skipped as duplicates.",
+					Tags:        []string{"Transfer"},
+					Parameters: []*Parameter{
+						formatParameter,
+						tzParameter,
+						{
+							Name: "map", In: "query",
+							Description: "Renames columns or keys to item fields, e.g. title:Name,deadline:Due.",
+							Schema:      &Schema{Type: "string"},
+						},
+						dryRunParameter,
+						idempotencyKeyParameter,
+					},
+					RequestBody: &RequestBody{Required: true, Content: fileContent()},
+					Responses: errors(map[string]*Response{
+						"200": {Description: "Nothing was created", Content: jsonContent(s.Of(models.ImportReport{}))},
+						"201": {Description: "At least one item was created", Content: jsonContent(s.Of(models.ImportReport{}))},
+					}, http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError),
+				},
+			},
+			"/todo/sync": {
+				"post": {
+					OperationID: "syncItems",
+					Summary:     "Reconcile an offline client",
+					Description: "Applies the changes an offline client made, resolving conflicts field by field, and returns the items written and deleted since the client's last sync. Requests can safely be replayed.",
+					Tags:        []string{"Sync"},
+					Parameters:  []*Parameter{idempotencyKeyParameter},
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(s.Of(models.SyncRequest{}))},
+					Responses: errors(map[string]*Response{
+						"200": {Description: "The outcome of the changes, and the changes made on the server", Content: jsonContent(s.Of(models.SyncResponse{}))},
+					}, http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError, http.StatusServiceUnavailable),
+				},
+			},
+			"/todo/feed.ics": {
+				"get": {
+					OperationID: "feed",
+					Summary:     "Subscribe to the deadlines of open items as an iCalendar feed",
+					Description: "Items are not owned by users, so the feed is the same whichever user the token was issued to.",
+					Tags:        []string{"Feed"},
+					Parameters: []*Parameter{
+						{
+							Name: "token", In: "query", Required: true,
+							Description: "A feed token.",
+							Schema:      &Schema{Type: "string"},
+						},
+						{
+							Name: "component", In: "query",
+							Description: "Whether items are published as to-dos or as all-day events on their deadline.",
+							Schema:      &Schema{Type: "string", Enum: []string{"vtodo", "vevent"}, Default: "vtodo"},
+						},
+						{Name: "list", In: "query", Description: "Only items in this list.", Schema: &Schema{Type: "string"}},
+						{Name: "tag", In: "query", Description: "Only items with this tag.", Schema: &Schema{Type: "string"}},
+						tzParameter,
+					},
+					Responses: errors(map[string]*Response{
+						"200": {Description: "The feed", Content: textContent("text/calendar")},
+					}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError),
+				},
+			},
+			"/todo/feed/tokens": {
+				"get": {
+					OperationID: "listFeedTokens",
+					Summary:     "List your feed tokens",
+					Description: "Lists the tokens of the user whose token is sent as a Bearer token.",
+					Tags:        []string{"Feed"},
+					Responses: errors(map[string]*Response{
+						"200": {Description: "The tokens, without their secrets", Content: jsonContent(&Schema{Type: "array", Items: feedToken})},
+					}, http.StatusUnauthorized, http.StatusInternalServerError),
+				},
+				"post": {
+					OperationID: "createFeedToken",
+					Summary:     "Create a feed token",
+					Description: "Requires a Bearer token: the admin token configured on the server creates tokens for any user, and a feed token creates more tokens for its own user.",
+					Tags:        []string{"Feed"},
+					Parameters:  []*Parameter{idempotencyKeyParameter},
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(feedToken)},
+					Responses: errors(map[string]*Response{
+						"201": {Description: "The token, including its secret", Content: jsonContent(feedToken)},
+					}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError),
+				},
+			},
+			"/todo/feed/tokens/{id}": {
+				"delete": {
+					OperationID: "revokeFeedToken",
+					Summary:     "Revoke one of your feed tokens",
+					Description: "Revokes a token of the user whose token is sent as a Bearer token. Tokens of other users are not found.",
+					Tags:        []string{"Feed"},
+					Parameters: []*Parameter{
+						{Name: "id", In: "path", Required: true, Description: "The ObjectID of the token.", Schema: &Schema{Type: "string", Pattern: ObjectIDPattern}},
+					},
+					Responses: errors(map[string]*Response{
+						"200": {Description: "The revoked token", Content: jsonContent(feedToken)},
+					}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError),
+				},
+			},
+			"/todo/{id}": {
+				"get": {
+					OperationID: "getItem",
+					Summary:     "Get an item",
+					Tags:        []string{"Items"},
+					Parameters:  []*Parameter{idParameter, ifNoneMatchParameter},
+					Responses: errors(map[string]*Response{
+						"200": {Description: "The item", Content: jsonContent(item)},
+						"304": {Description: "The item has not changed since the response with the ETag in If-None-Match"},
+					}, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+				},
+				"put": {
+					OperationID: "updateItem",
+					Summary:     "Update an item",
+					Description: "Sets the fields in the body, leaving the others as they are.",
+					Tags:        []string{"Items"},
+					Parameters:  []*Parameter{idParameter},
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(s.Partial(models.ToDoItem{}, "ToDoItemUpdate"))},
+					Responses: errors(map[string]*Response{
+						"200": {Description: "The item was updated", Content: jsonContent(s.Of(mongo.UpdateResult{}))},
+					}, http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError),
+				},
+				"delete": {
+					OperationID: "deleteItem",
+					Summary:     "Delete an item",
+					Tags:        []string{"Items"},
+					Parameters:  []*Parameter{idParameter},
+					Responses: errors(map[string]*Response{
+						"200": {Description: "The item was deleted", Content: jsonContent(s.Of(mongo.DeleteResult{}))},
+					}, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+				},
+			},
+		},
+	}
+
+	document.Components.Schemas = s.components
+	return document
+}
+
+func problemCodes() []string {
+	var codes []string
+	for _, p := range problem.All {
+		codes = append(codes, p.Code)
+	}
+	return codes
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+func textContent(mediaType string) map[string]*MediaType {
+	return map[string]*MediaType{mediaType: {Schema: &Schema{Type: "string"}}}
+}
+
+// fileContent describes a body in any of the interchange formats.
+func fileContent() map[string]*MediaType {
+	content := map[string]*MediaType{}
+	for _, mediaType := range []string{"application/json", "text/csv", "text/calendar", "text/plain"} {
+		content[mediaType] = &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+	}
+	return content
+}
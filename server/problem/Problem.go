@@ -0,0 +1,188 @@
+package problem
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/L4TTiCe/ToDo-Go/server/dao"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"go.uber.org/zap"
+)
+
+// ContentType is the media type of problem responses (RFC 7807).
+const ContentType = "application/problem+json"
+
+// TypePrefix is the path under which problem types are documented. The type URI of a problem is TypePrefix followed
+// by its code, relative to the API, e.g. /problems/not-found.
+const TypePrefix = "/problems/"
+
+// StatusClientClosedRequest is the non-standard status, introduced by nginx, of requests that the client abandoned
+// before they were handled. The client never sees it, but logs and metrics do.
+const StatusClientClosedRequest = 499
+
+// Problem is a kind of problem reported to clients. Its code is stable and never reused for a different problem.
+type Problem struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// The problems reported by the API.
+var (
+	InvalidRequest = Problem{
+		Code: "invalid-request", Status: http.StatusBadRequest, Title: "Invalid Request",
+		Description: "The request is malformed: its body cannot be parsed, or a parameter is missing or has the wrong type.",
+	}
+	InvalidID = Problem{
+		Code: "invalid-id", Status: http.StatusBadRequest, Title: "Invalid ID",
+		Description: "An ID in the request is not a 24 character hexadecimal ObjectID.",
+	}
+	Unauthorized = Problem{
+		Code: "unauthorized", Status: http.StatusUnauthorized, Title: "Unauthorized",
+		Description: "The request lacks a valid token.",
+	}
+	NotFound = Problem{
+		Code: "not-found", Status: http.StatusNotFound, Title: "Not Found",
+		Description: "The requested resource does not exist.",
+	}
+	MethodNotAllowed = Problem{
+		Code: "method-not-allowed", Status: http.StatusMethodNotAllowed, Title: "Method Not Allowed",
+		Description: "The resource does not support the request method.",
+	}
+	Conflict = Problem{
+		Code: "conflict", Status: http.StatusConflict, Title: "Conflict",
+		Description: "The request conflicts with the current state of a resource, e.g. a duplicate key.",
+	}
+	ValidationFailed = Problem{
+		Code: "validation-failed", Status: http.StatusUnprocessableEntity, Title: "Validation Failed",
+		Description: "The request is well-formed but has invalid values. The violations list each of them.",
+	}
+	IdempotencyKeyReused = Problem{
+		Code: "idempotency-key-reused", Status: http.StatusUnprocessableEntity, Title: "Idempotency Key Reused",
+		Description: "The Idempotency-Key header was already used for a request with a different method, URL or body.",
+	}
+	RateLimited = Problem{
+		Code: "rate-limited", Status: http.StatusTooManyRequests, Title: "Too Many Requests",
+		Description: "The client made too many requests. The Retry-After header says how many seconds to wait before retrying.",
+	}
+	Internal = Problem{
+		Code: "internal", Status: http.StatusInternalServerError, Title: "Internal Server Error",
+		Description: "The server failed unexpectedly. The error has been logged.",
+	}
+	Unavailable = Problem{
+		Code: "unavailable", Status: http.StatusServiceUnavailable, Title: "Service Unavailable",
+		Description: "A service the API depends on, such as the database, is unavailable. The request can be retried.",
+	}
+	Timeout = Problem{
+		Code: "timeout", Status: http.StatusGatewayTimeout, Title: "Gateway Timeout",
+		Description: "A service the API depends on, such as the database, did not respond in time. The request can be retried.",
+	}
+	ClientClosedRequest = Problem{
+		Code: "client-closed-request", Status: StatusClientClosedRequest, Title: "Client Closed Request",
+		Description: "The client cancelled the request before it was handled.",
+	}
+)
+
+// All lists every problem, for documentation.
+var All = []Problem{
+	InvalidRequest, InvalidID, Unauthorized, NotFound, MethodNotAllowed, Conflict, ValidationFailed, IdempotencyKeyReused,
+	RateLimited, Internal, Unavailable, Timeout, ClientClosedRequest,
+}
+
+// New returns an ErrorResponse for an occurrence of the problem. It is not yet populated with the request.
+func (p Problem) New(detail string) *models.ErrorResponse {
+	return &models.ErrorResponse{
+		Type:   TypePrefix + p.Code,
+		Code:   p.Code,
+		Status: p.Status,
+		Title:  p.Title,
+		Detail: detail,
+	}
+}
+
+// From maps an error returned by a DAO to an ErrorResponse. Unexpected errors are logged and reported as internal
+// errors without their message, which may contain details of the database.
+func From(err error) *models.ErrorResponse {
+	var daoError *dao.Error
+	if !errors.As(err, &daoError) {
+		logging.L().Error("Unexpected error", zap.Error(err))
+		return Internal.New("")
+	}
+
+	var p Problem
+	switch daoError.Kind {
+	case dao.ErrNotFound:
+		p = NotFound
+	case dao.ErrInvalidID:
+		p = InvalidID
+	case dao.ErrValidation:
+		p = ValidationFailed
+	case dao.ErrConflict:
+		p = Conflict
+	case dao.ErrUnavailable:
+		logging.L().Error("Database unavailable", zap.Error(err))
+		p = Unavailable
+	case dao.ErrTimeout:
+		logging.L().Warn("Database timed out", zap.Error(err))
+		p = Timeout
+	case dao.ErrCanceled:
+		p = ClientClosedRequest
+	default:
+		logging.L().Error("Unexpected error", zap.Error(err))
+		return Internal.New("")
+	}
+
+	errorResponse := p.New(daoError.Detail)
+	errorResponse.Violations = daoError.Violations
+	return errorResponse
+}
+
+// ForStatus returns the problem reported with an HTTP status, for ErrorResponses built without one.
+func ForStatus(status int) Problem {
+	for _, p := range All {
+		if p.Status == status && p != InvalidID && p != IdempotencyKeyReused {
+			return p
+		}
+	}
+	if status >= http.StatusInternalServerError {
+		return Internal
+	}
+	return InvalidRequest
+}
+
+// Lookup returns the problem with the given code.
+func Lookup(code string) (Problem, bool) {
+	for _, p := range All {
+		if p.Code == code {
+			return p, true
+		}
+	}
+	return Problem{}, false
+}
+
+// Summary describes an ErrorResponse in a single line, including its violations, for clients that cannot receive
+// the ErrorResponse itself, such as GraphQL and gRPC clients, and for reports that hold many of them.
+func Summary(errorResponse *models.ErrorResponse) string {
+	message := errorResponse.Title
+	if errorResponse.Detail != "" {
+		message += ": " + errorResponse.Detail
+	}
+	for i, violation := range errorResponse.Violations {
+		if i == 0 {
+			message += " ("
+		} else {
+			message += ", "
+		}
+		location := violation.Pointer
+		if violation.Parameter != "" {
+			location = violation.Parameter
+		}
+		message += location + " " + violation.Message
+	}
+	if len(errorResponse.Violations) > 0 {
+		message += ")"
+	}
+	return message
+}
@@ -0,0 +1,349 @@
+package quickadd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+// The grammar understood by Parse is deliberately small and deterministic. The input is split on
+// whitespace and every word is matched, left to right, against the following rules. Words that
+// match no rule (or a rule whose component has already been set) become part of the title.
+//
+//	#tag                            adds a tag (lowercased, duplicates ignored)
+//	!low | !medium | !high | !1-!3  sets the priority
+//	every day|week|month|year       sets the recurrence, also "every N days", "every other week"
+//	                                and "every monday"
+//	today | tomorrow | <weekday>    sets the deadline date, also "next <weekday>", "next week",
+//	                                "next month", "in N days|weeks|months" and YYYY-MM-DD
+//	5pm | 5:30pm | 17:00 | noon     sets the deadline time, also "midnight"
+//	by | on | at | due              are dropped when directly followed by a date or time
+//
+// A date without a time is due at the end of that day (23:59), and a time without a date is due
+// today, or tomorrow if that time has already passed. Midnight is the end of a day, so "friday
+// midnight" is due at 00:00 on Saturday, and "midnight" alone at 00:00 tomorrow.
+
+var (
+	twelveHourClock = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)$`)
+	twentyFourClock = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+var byDay = map[time.Weekday]string{
+	time.Sunday:    "SU",
+	time.Monday:    "MO",
+	time.Tuesday:   "TU",
+	time.Wednesday: "WE",
+	time.Thursday:  "TH",
+	time.Friday:    "FR",
+	time.Saturday:  "SA",
+}
+
+var priorities = map[string]string{
+	"!low":    models.PriorityLow,
+	"!1":      models.PriorityLow,
+	"!medium": models.PriorityMedium,
+	"!med":    models.PriorityMedium,
+	"!2":      models.PriorityMedium,
+	"!high":   models.PriorityHigh,
+	"!3":      models.PriorityHigh,
+}
+
+var frequencies = map[string]string{
+	"day":   "DAILY",
+	"week":  "WEEKLY",
+	"month": "MONTHLY",
+	"year":  "YEARLY",
+}
+
+var connectors = map[string]bool{
+	"by":  true,
+	"on":  true,
+	"at":  true,
+	"due": true,
+}
+
+// clock is a time of day. The hour is 24 for midnight at the end of the day.
+type clock struct {
+	hour   int
+	minute int
+}
+
+// parser holds the state accumulated while scanning the input.
+type parser struct {
+	now   time.Time
+	words []string
+
+	title []string
+	item  models.ToDoItem
+
+	date *time.Time
+	time *clock
+}
+
+// Parse parses a free-form string such as "Submit expense report tomorrow 5pm #finance !high every month"
+// into a ToDoItem. Relative dates are resolved against now, in now's location.
+// The returned item has not been validated; an input made up only of keywords yields an empty Title.
+func Parse(text string, now time.Time) models.ToDoItem {
+	p := &parser{now: now, words: strings.Fields(text)}
+
+	for i := 0; i < len(p.words); {
+		i += p.next(i)
+	}
+
+	p.item.Title = strings.Join(p.title, " ")
+	p.item.Deadline = p.deadline()
+
+	return p.item
+}
+
+// next consumes the rule starting at words[i] and returns the number of words consumed.
+func (p *parser) next(i int) int {
+	word := p.words[i]
+	lower := normalize(word)
+
+	// Tags
+	if strings.HasPrefix(word, "#") && len(word) > 1 {
+		p.addTag(strings.ToLower(word[1:]))
+		return 1
+	}
+
+	// Priority
+	if priority, ok := priorities[lower]; ok && p.item.Priority == "" {
+		p.item.Priority = priority
+		return 1
+	}
+
+	// Recurrence
+	if lower == "every" && p.item.Recurrence == "" {
+		if n, rule := parseRecurrence(p.words[i+1:]); n > 0 {
+			p.item.Recurrence = rule
+			return 1 + n
+		}
+	}
+
+	// Connectors are only dropped when they introduce a date or time
+	if connectors[lower] && i+1 < len(p.words) {
+		if n := p.when(i + 1); n > 0 {
+			return 1 + n
+		}
+	}
+
+	if n := p.when(i); n > 0 {
+		return n
+	}
+
+	p.title = append(p.title, word)
+	return 1
+}
+
+// when consumes a date or time phrase starting at words[i], if the corresponding component is still unset.
+func (p *parser) when(i int) int {
+	if p.date == nil {
+		if n, date := parseDate(p.words[i:], p.now); n > 0 {
+			p.date = &date
+			return n
+		}
+	}
+
+	if p.time == nil {
+		if c, ok := parseClock(normalize(p.words[i])); ok {
+			p.time = &c
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func (p *parser) addTag(tag string) {
+	tag = strings.TrimRight(tag, ".,;:")
+	if tag == "" {
+		return
+	}
+	for _, existing := range p.item.Tags {
+		if existing == tag {
+			return
+		}
+	}
+	p.item.Tags = append(p.item.Tags, tag)
+}
+
+// deadline combines the parsed date and time into a Unix millisecond timestamp, or 0 if neither was given.
+func (p *parser) deadline() int64 {
+	switch {
+	case p.date != nil && p.time != nil:
+		return at(*p.date, *p.time).UnixMilli()
+	case p.date != nil:
+		return at(*p.date, clock{hour: 23, minute: 59}).UnixMilli()
+	case p.time != nil:
+		deadline := at(p.now, *p.time)
+		if deadline.Before(p.now) {
+			deadline = deadline.AddDate(0, 0, 1)
+		}
+		return deadline.UnixMilli()
+	}
+	return 0
+}
+
+// parseDate parses a date phrase at the start of words, returning the number of words consumed and the date.
+func parseDate(words []string, now time.Time) (int, time.Time) {
+	today := at(now, clock{})
+	first := normalize(words[0])
+
+	switch first {
+	case "today":
+		return 1, today
+	case "tomorrow":
+		return 1, today.AddDate(0, 0, 1)
+	}
+
+	if weekday, ok := weekdays[first]; ok {
+		return 1, nextWeekday(today, weekday)
+	}
+
+	if date, err := time.ParseInLocation("2006-01-02", first, now.Location()); err == nil {
+		return 1, date
+	}
+
+	if len(words) < 2 {
+		return 0, time.Time{}
+	}
+	second := normalize(words[1])
+
+	if first == "next" {
+		if weekday, ok := weekdays[second]; ok {
+			return 2, nextWeekday(today, weekday)
+		}
+		switch second {
+		case "week":
+			return 2, today.AddDate(0, 0, 7)
+		case "month":
+			return 2, today.AddDate(0, 1, 0)
+		}
+	}
+
+	if first == "in" && len(words) >= 3 {
+		n, err := strconv.Atoi(second)
+		if err != nil || n <= 0 {
+			return 0, time.Time{}
+		}
+		switch strings.TrimSuffix(normalize(words[2]), "s") {
+		case "day":
+			return 3, today.AddDate(0, 0, n)
+		case "week":
+			return 3, today.AddDate(0, 0, 7*n)
+		case "month":
+			return 3, today.AddDate(0, n, 0)
+		}
+	}
+
+	return 0, time.Time{}
+}
+
+// parseClock parses a time of day such as "5pm", "5:30pm", "17:00", "noon" or "midnight".
+func parseClock(word string) (clock, bool) {
+	switch word {
+	case "noon":
+		return clock{hour: 12}, true
+	case "midnight":
+		return clock{hour: 24}, true
+	}
+
+	if match := twelveHourClock.FindStringSubmatch(word); match != nil {
+		hour, _ := strconv.Atoi(match[1])
+		minute := 0
+		if match[2] != "" {
+			minute, _ = strconv.Atoi(match[2])
+		}
+		if hour < 1 || hour > 12 || minute > 59 {
+			return clock{}, false
+		}
+		hour %= 12
+		if match[3] == "pm" {
+			hour += 12
+		}
+		return clock{hour: hour, minute: minute}, true
+	}
+
+	if match := twentyFourClock.FindStringSubmatch(word); match != nil {
+		hour, _ := strconv.Atoi(match[1])
+		minute, _ := strconv.Atoi(match[2])
+		if hour > 23 || minute > 59 {
+			return clock{}, false
+		}
+		return clock{hour: hour, minute: minute}, true
+	}
+
+	return clock{}, false
+}
+
+// parseRecurrence parses the phrase following "every", returning the number of words consumed and an RRULE.
+func parseRecurrence(words []string) (int, string) {
+	if len(words) == 0 {
+		return 0, ""
+	}
+	first := normalize(words[0])
+
+	if freq, ok := frequencies[first]; ok {
+		return 1, "FREQ=" + freq
+	}
+
+	if weekday, ok := weekdays[first]; ok {
+		return 1, "FREQ=WEEKLY;BYDAY=" + byDay[weekday]
+	}
+
+	if len(words) < 2 {
+		return 0, ""
+	}
+
+	interval := 0
+	if first == "other" {
+		interval = 2
+	} else if n, err := strconv.Atoi(first); err == nil && n > 0 {
+		interval = n
+	}
+	if interval == 0 {
+		return 0, ""
+	}
+
+	if freq, ok := frequencies[strings.TrimSuffix(normalize(words[1]), "s")]; ok {
+		if interval == 1 {
+			return 2, "FREQ=" + freq
+		}
+		return 2, "FREQ=" + freq + ";INTERVAL=" + strconv.Itoa(interval)
+	}
+
+	return 0, ""
+}
+
+// nextWeekday returns the first date strictly after today that falls on weekday.
+func nextWeekday(today time.Time, weekday time.Weekday) time.Time {
+	days := (int(weekday) - int(today.Weekday()) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+	return today.AddDate(0, 0, days)
+}
+
+// at returns the given day at the given time of day, in the day's location. 24:00 is 00:00 of the following day.
+func at(day time.Time, c clock) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), c.hour, c.minute, 0, 0, day.Location())
+}
+
+// normalize lowercases a word and strips trailing punctuation so that "tomorrow," matches "tomorrow".
+func normalize(word string) string {
+	return strings.TrimRight(strings.ToLower(word), ".,;:")
+}
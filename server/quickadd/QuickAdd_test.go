@@ -0,0 +1,140 @@
+package quickadd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+)
+
+func TestParse(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A Wednesday
+	now := time.Date(2022, time.June, 15, 10, 30, 0, 0, location)
+	on := func(month time.Month, day int, hour int, minute int) int64 {
+		return time.Date(2022, month, day, hour, minute, 0, 0, location).UnixMilli()
+	}
+
+	tests := []struct {
+		text string
+		want models.ToDoItem
+	}{
+		{
+			text: "Submit expense report tomorrow 5pm #finance !high every month",
+			want: models.ToDoItem{
+				Title: "Submit expense report", Deadline: on(time.June, 16, 17, 0), Tags: []string{"finance"},
+				Priority: models.PriorityHigh, Recurrence: "FREQ=MONTHLY",
+			},
+		},
+		{
+			text: "Buy milk",
+			want: models.ToDoItem{Title: "Buy milk"},
+		},
+
+		// Dates, due at the end of the day
+		{text: "Call mum today", want: models.ToDoItem{Title: "Call mum", Deadline: on(time.June, 15, 23, 59)}},
+		{text: "Call mum friday", want: models.ToDoItem{Title: "Call mum", Deadline: on(time.June, 17, 23, 59)}},
+		{text: "Call mum wednesday", want: models.ToDoItem{Title: "Call mum", Deadline: on(time.June, 22, 23, 59)}},
+		{text: "Call mum next monday", want: models.ToDoItem{Title: "Call mum", Deadline: on(time.June, 20, 23, 59)}},
+		{text: "Call mum next week", want: models.ToDoItem{Title: "Call mum", Deadline: on(time.June, 22, 23, 59)}},
+		{text: "Call mum next month", want: models.ToDoItem{Title: "Call mum", Deadline: on(time.July, 15, 23, 59)}},
+		{text: "Call mum in 3 days", want: models.ToDoItem{Title: "Call mum", Deadline: on(time.June, 18, 23, 59)}},
+		{text: "Call mum in 2 weeks", want: models.ToDoItem{Title: "Call mum", Deadline: on(time.June, 29, 23, 59)}},
+		{text: "Call mum in 1 month", want: models.ToDoItem{Title: "Call mum", Deadline: on(time.July, 15, 23, 59)}},
+		{text: "Call mum 2022-12-24", want: models.ToDoItem{Title: "Call mum", Deadline: on(time.December, 24, 23, 59)}},
+		{text: "Call mum Tomorrow.", want: models.ToDoItem{Title: "Call mum", Deadline: on(time.June, 16, 23, 59)}},
+
+		// Times, due today or, once passed, tomorrow
+		{text: "Stand-up 11am", want: models.ToDoItem{Title: "Stand-up", Deadline: on(time.June, 15, 11, 0)}},
+		{text: "Stand-up 9:15am", want: models.ToDoItem{Title: "Stand-up", Deadline: on(time.June, 16, 9, 15)}},
+		{text: "Stand-up 17:45", want: models.ToDoItem{Title: "Stand-up", Deadline: on(time.June, 15, 17, 45)}},
+		{text: "Lunch noon", want: models.ToDoItem{Title: "Lunch", Deadline: on(time.June, 15, 12, 0)}},
+		{text: "Stand-up 12am", want: models.ToDoItem{Title: "Stand-up", Deadline: on(time.June, 16, 0, 0)}},
+		{text: "Stand-up 12pm", want: models.ToDoItem{Title: "Stand-up", Deadline: on(time.June, 15, 12, 0)}},
+
+		// Midnight ends the day
+		{text: "Submit thesis midnight", want: models.ToDoItem{Title: "Submit thesis", Deadline: on(time.June, 16, 0, 0)}},
+		{text: "Submit thesis friday midnight", want: models.ToDoItem{Title: "Submit thesis", Deadline: on(time.June, 18, 0, 0)}},
+
+		// Connectors are dropped only before a date or time
+		{text: "Pay rent by friday at 9am", want: models.ToDoItem{Title: "Pay rent", Deadline: on(time.June, 17, 9, 0)}},
+		{text: "Meet due tomorrow", want: models.ToDoItem{Title: "Meet", Deadline: on(time.June, 16, 23, 59)}},
+		{text: "Work on the report", want: models.ToDoItem{Title: "Work on the report"}},
+		{text: "Look at", want: models.ToDoItem{Title: "Look at"}},
+
+		// Only the first date and time count
+		{text: "Move meeting from monday to tuesday", want: models.ToDoItem{Title: "Move meeting from to tuesday", Deadline: on(time.June, 20, 23, 59)}},
+		{text: "Ring at 3pm or 4pm", want: models.ToDoItem{Title: "Ring or 4pm", Deadline: on(time.June, 15, 15, 0)}},
+
+		// Invalid dates and times are part of the title
+		{text: "Room 13pm", want: models.ToDoItem{Title: "Room 13pm"}},
+		{text: "Score 24:00", want: models.ToDoItem{Title: "Score 24:00"}},
+		{text: "In 0 days", want: models.ToDoItem{Title: "In 0 days"}},
+		{text: "Release 2022-13-01", want: models.ToDoItem{Title: "Release 2022-13-01"}},
+
+		// Tags
+		{text: "Plan #Work #trip, #work", want: models.ToDoItem{Title: "Plan", Tags: []string{"work", "trip"}}},
+		{text: "Issue # 5", want: models.ToDoItem{Title: "Issue # 5"}},
+
+		// Priorities, of which only the first counts
+		{text: "Fix bug !1", want: models.ToDoItem{Title: "Fix bug", Priority: models.PriorityLow}},
+		{text: "Fix bug !med", want: models.ToDoItem{Title: "Fix bug", Priority: models.PriorityMedium}},
+		{text: "Fix bug !high !low", want: models.ToDoItem{Title: "Fix bug !low", Priority: models.PriorityHigh}},
+		{text: "Wow !", want: models.ToDoItem{Title: "Wow !"}},
+
+		// Recurrence
+		{text: "Water plants every day", want: models.ToDoItem{Title: "Water plants", Recurrence: "FREQ=DAILY"}},
+		{text: "Water plants every 3 days", want: models.ToDoItem{Title: "Water plants", Recurrence: "FREQ=DAILY;INTERVAL=3"}},
+		{text: "Water plants every 1 week", want: models.ToDoItem{Title: "Water plants", Recurrence: "FREQ=WEEKLY"}},
+		{text: "Team sync every other week", want: models.ToDoItem{Title: "Team sync", Recurrence: "FREQ=WEEKLY;INTERVAL=2"}},
+		{text: "Team sync every monday", want: models.ToDoItem{Title: "Team sync", Recurrence: "FREQ=WEEKLY;BYDAY=MO"}},
+		{text: "Taxes every year", want: models.ToDoItem{Title: "Taxes", Recurrence: "FREQ=YEARLY"}},
+		{text: "Eat every", want: models.ToDoItem{Title: "Eat every"}},
+		{text: "Read every book", want: models.ToDoItem{Title: "Read every book"}},
+
+		// Keywords alone leave the title empty
+		{text: "tomorrow #home", want: models.ToDoItem{Deadline: on(time.June, 16, 23, 59), Tags: []string{"home"}}},
+		{text: "", want: models.ToDoItem{}},
+	}
+
+	for _, test := range tests {
+		got := Parse(test.text, now)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", test.text, got, test.want)
+		}
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		word string
+		want clock
+		ok   bool
+	}{
+		{"5pm", clock{hour: 17}, true},
+		{"5:30pm", clock{hour: 17, minute: 30}, true},
+		{"12am", clock{hour: 0}, true},
+		{"12pm", clock{hour: 12}, true},
+		{"0:00", clock{hour: 0}, true},
+		{"23:59", clock{hour: 23, minute: 59}, true},
+		{"noon", clock{hour: 12}, true},
+		{"midnight", clock{hour: 24}, true},
+		{"0am", clock{}, false},
+		{"13pm", clock{}, false},
+		{"5:60pm", clock{}, false},
+		{"24:00", clock{}, false},
+		{"5", clock{}, false},
+		{"five", clock{}, false},
+	}
+
+	for _, test := range tests {
+		got, ok := parseClock(test.word)
+		if got != test.want || ok != test.ok {
+			t.Errorf("parseClock(%q) = %+v, %t, want %+v, %t", test.word, got, ok, test.want, test.ok)
+		}
+	}
+}
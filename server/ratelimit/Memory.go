@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/config"
+)
+
+// sweepInterval is how often the memory store forgets the buckets that have refilled.
+const sweepInterval = time.Minute
+
+// Memory keeps the buckets in memory. Each instance of the server then limits requests on its own.
+type Memory struct {
+	mutex   sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+	// now tells the time, and is replaced in tests
+	now func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+	// full is when the bucket will be full again, and can be forgotten
+	full time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: map[string]*bucket{}, swept: time.Now(), now: time.Now}
+}
+
+func (m *Memory) Take(_ context.Context, key string, limit config.Limit) (Result, error) {
+	now := m.now()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if now.Sub(m.swept) >= sweepInterval {
+		m.sweep(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Requests), at: now}
+		m.buckets[key] = b
+	}
+
+	b.tokens = refill(limit, b.tokens, now.Sub(b.at))
+	b.at = now
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	result := newResult(limit, b.tokens, allowed)
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// sweep forgets the buckets that are full, since a missing bucket is full too. It must be called with the mutex held.
+func (m *Memory) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+	m.swept = now
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,137 @@
+// Package ratelimit limits how many requests each client may make, so that a misbehaving client cannot overwhelm
+// the server.
+//
+// Clients are identified by their API key, sent in the X-API-Key header or as a bearer token, else by their user,
+// else by their IP. Keys and users are not verified here, so a client inventing new ones gets new buckets; every
+// request from an IP therefore also counts against the per-IP limit, whichever key or user it is made with. The IP
+// is only as reliable as the trusted proxies make it. Every route counts against a client's default limit, except
+// for the routes with a limit of their own, which each have a bucket of their own.
+//
+// Responses carry RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers, as in the IETF
+// RateLimit header fields draft. Requests over the limit are rejected with 429 and a Retry-After header. If the store
+// fails, requests are allowed rather than rejected.
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/config"
+	"github.com/L4TTiCe/ToDo-Go/server/controller"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// APIKeyHeader is the request header holding the client's API key.
+const APIKeyHeader = "X-API-Key"
+
+// Headers are the response headers describing the limit, for browsers to be allowed to see.
+var Headers = []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"}
+
+// ipPrefix starts the keys of clients identified by their IP.
+const ipPrefix = "ip:"
+
+// unlimited are the routes polled by monitoring, which must keep answering however often they are polled.
+var unlimited = map[string]bool{"/metrics": true, "/healthz": true, "/readyz": true, "/up": true}
+
+// Middleware limits the requests of every client as settings say, keeping the buckets in store.
+func Middleware(settings *config.RateLimitConfig, store Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !settings.Enabled || unlimited[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		key := clientKey(c)
+		route := c.Request.Method + " " + c.FullPath()
+		limit, own := settings.Routes[route]
+		if own {
+			key += " " + route
+		} else {
+			limit = settings.Default
+		}
+
+		// Requests identified by their IP already count against a limit for the IP
+		if settings.PerIP.Requests > 0 && !strings.HasPrefix(key, ipPrefix) {
+			if !take(c, store, ipPrefix+c.ClientIP(), settings.PerIP) {
+				return
+			}
+		}
+		if limit.Requests > 0 && !take(c, store, key, limit) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// take takes a token from the bucket of the key and describes the limit in the response headers. If the bucket is
+// empty, it rejects the request and returns false.
+func take(c *gin.Context, store Store, key string, limit config.Limit) bool {
+	result, err := store.Take(c.Request.Context(), key, limit)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Warn("Rate limiting failed, allowing the request", zap.Error(err))
+		return true
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(limit.Requests))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", seconds(result.Reset))
+	c.Header("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+seconds(limit.Period))
+
+	if !result.Allowed {
+		c.Header("Retry-After", seconds(result.RetryAfter))
+		abort(c, problem.RateLimited.New("The rate limit of "+limit.String()+" was exceeded"))
+		return false
+	}
+	return true
+}
+
+// CheckRoutes warns about the routes limited in settings that are not registered, which are probably typos.
+func CheckRoutes(settings *config.RateLimitConfig, routes gin.RoutesInfo) {
+	registered := map[string]bool{}
+	for _, route := range routes {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for route := range settings.Routes {
+		if !registered[route] {
+			logging.L().Warn("Rate limit configured for a route that does not exist", zap.String("route", route))
+		}
+	}
+}
+
+// clientKey identifies the client making the request.
+func clientKey(c *gin.Context) string {
+	apiKey := c.GetHeader(APIKeyHeader)
+	if authorization := c.GetHeader("Authorization"); apiKey == "" && strings.HasPrefix(authorization, "Bearer ") {
+		apiKey = strings.TrimPrefix(authorization, "Bearer ")
+	}
+	if apiKey != "" {
+		// The key is a secret, which must not end up in the store
+		hash := sha256.Sum256([]byte(apiKey))
+		return "key:" + hex.EncodeToString(hash[:16])
+	}
+
+	if user, _, ok := c.Request.BasicAuth(); ok && user != "" {
+		return "user:" + user
+	}
+
+	return ipPrefix + c.ClientIP()
+}
+
+// seconds formats a duration as whole seconds, rounded up so that clients do not retry too early.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+func abort(c *gin.Context, errorResponse *models.ErrorResponse) {
+	controller.PopulateErrorResponse(c, errorResponse)
+	c.AbortWithStatusJSON(errorResponse.Status, errorResponse)
+}
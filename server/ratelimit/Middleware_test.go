@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/config"
+	"github.com/gin-gonic/gin"
+)
+
+// request is a request made to the router of TestMiddleware, and the response expected. Headers that are expected
+// empty must be missing.
+type request struct {
+	path   string
+	apiKey string
+	status int
+	header map[string]string
+}
+
+func TestMiddleware(t *testing.T) {
+	settings := config.RateLimitConfig{
+		Enabled: true,
+		Default: config.Limit{Requests: 2, Period: time.Minute},
+		Routes:  map[string]config.Limit{"GET /expensive": {Requests: 1, Period: time.Minute}},
+		PerIP:   config.Limit{Requests: 3, Period: time.Minute},
+	}
+
+	tests := []struct {
+		name     string
+		disabled bool
+		requests []request
+	}{
+		{
+			name: "headers",
+			requests: []request{
+				{path: "/cheap", status: http.StatusOK, header: map[string]string{
+					"RateLimit-Limit": "2", "RateLimit-Remaining": "1", "RateLimit-Reset": "30", "RateLimit-Policy": "2;w=60", "Retry-After": "",
+				}},
+				{path: "/cheap", status: http.StatusOK, header: map[string]string{
+					"RateLimit-Limit": "2", "RateLimit-Remaining": "0", "RateLimit-Reset": "60", "RateLimit-Policy": "2;w=60", "Retry-After": "",
+				}},
+				{path: "/cheap", status: http.StatusTooManyRequests, header: map[string]string{
+					"RateLimit-Limit": "2", "RateLimit-Remaining": "0", "RateLimit-Reset": "60", "Retry-After": "30",
+				}},
+			},
+		},
+		{
+			name: "routes with a limit of their own",
+			requests: []request{
+				{path: "/expensive", status: http.StatusOK, header: map[string]string{"RateLimit-Limit": "1", "RateLimit-Policy": "1;w=60"}},
+				{path: "/expensive", status: http.StatusTooManyRequests, header: map[string]string{"RateLimit-Limit": "1", "Retry-After": "60"}},
+				{path: "/cheap", status: http.StatusOK, header: map[string]string{"RateLimit-Limit": "2", "RateLimit-Remaining": "1"}},
+			},
+		},
+		{
+			name: "API keys have buckets of their own",
+			requests: []request{
+				{path: "/cheap", apiKey: "a", status: http.StatusOK, header: map[string]string{"RateLimit-Limit": "2", "RateLimit-Remaining": "1"}},
+				{path: "/cheap", apiKey: "a", status: http.StatusOK, header: map[string]string{"RateLimit-Limit": "2", "RateLimit-Remaining": "0"}},
+				{path: "/cheap", apiKey: "b", status: http.StatusOK, header: map[string]string{"RateLimit-Limit": "2", "RateLimit-Remaining": "1"}},
+			},
+		},
+		{
+			name: "new API keys count against the IP",
+			requests: []request{
+				{path: "/cheap", apiKey: "1", status: http.StatusOK},
+				{path: "/cheap", apiKey: "2", status: http.StatusOK},
+				{path: "/cheap", apiKey: "3", status: http.StatusOK},
+				{path: "/cheap", apiKey: "4", status: http.StatusTooManyRequests, header: map[string]string{"RateLimit-Limit": "3", "Retry-After": "20"}},
+			},
+		},
+		{
+			name: "monitoring",
+			requests: []request{
+				{path: "/healthz", status: http.StatusOK, header: map[string]string{"RateLimit-Limit": ""}},
+				{path: "/healthz", status: http.StatusOK},
+				{path: "/healthz", status: http.StatusOK},
+				{path: "/healthz", status: http.StatusOK},
+			},
+		},
+		{
+			name:     "disabled",
+			disabled: true,
+			requests: []request{
+				{path: "/cheap", status: http.StatusOK, header: map[string]string{"RateLimit-Limit": ""}},
+				{path: "/cheap", status: http.StatusOK},
+				{path: "/cheap", status: http.StatusOK},
+			},
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settings := settings
+			settings.Enabled = !test.disabled
+
+			store := NewMemory()
+			at := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+			store.now = func() time.Time { return at }
+
+			router := gin.New()
+			router.Use(Middleware(&settings, store))
+			for _, path := range []string{"/cheap", "/expensive", "/healthz"} {
+				router.GET(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+			}
+
+			for i, r := range test.requests {
+				req := httptest.NewRequest(http.MethodGet, r.path, nil)
+				if r.apiKey != "" {
+					req.Header.Set(APIKeyHeader, r.apiKey)
+				}
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				if w.Code != r.status {
+					t.Errorf("request %d: status = %d, want %d", i+1, w.Code, r.status)
+				}
+				for name, want := range r.header {
+					if got := w.Header().Get(name); got != want {
+						t.Errorf("request %d: %s = %q, want %q", i+1, name, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSeconds(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want int
+	}{
+		{0, 0},
+		{time.Millisecond, 1},
+		{time.Second, 1},
+		{1500 * time.Millisecond, 2},
+		{time.Minute, 60},
+	}
+
+	for _, test := range tests {
+		if got := seconds(test.d); got != strconv.Itoa(test.want) {
+			t.Errorf("seconds(%v) = %s, want %d", test.d, got, test.want)
+		}
+	}
+}
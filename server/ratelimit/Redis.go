@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces the keys of the buckets in Redis.
+const keyPrefix = "todo:ratelimit:"
+
+// takeScript refills and takes a token from a bucket in a single step, so that instances taking tokens from the same
+// bucket at once do not overwrite each other. A bucket is a hash holding its tokens and the time in milliseconds at
+// which it held them, and expires once it is full. Times come from the instances, whose clocks are assumed to agree
+// closely; a bucket is never refilled for time before it was last used.
+var takeScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(bucket[1]) or capacity
+local at = tonumber(bucket[2]) or now
+if now > at then
+	tokens = math.min(capacity, tokens + (now - at) * capacity / period)
+	at = now
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', tostring(at))
+redis.call('PEXPIRE', KEYS[1], math.ceil((capacity - tokens) * period / capacity) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// Redis keeps the buckets in Redis, or a server compatible with it, so that every instance of the server shares them.
+type Redis struct {
+	client *redis.Client
+	// now tells the time, and is replaced in tests
+	now func() time.Time
+}
+
+// NewRedis connects to the server at url, e.g. redis://localhost:6379/0.
+func NewRedis(url string) (*Redis, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Redis{client: redis.NewClient(options), now: time.Now}, nil
+}
+
+func (r *Redis) Take(ctx context.Context, key string, limit config.Limit) (Result, error) {
+	values, err := takeScript.Run(ctx, r.client, []string{keyPrefix + key},
+		limit.Requests, limit.Period.Milliseconds(), r.now().UnixMilli(),
+	).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(values) != 2 {
+		return Result{}, fmt.Errorf("unexpected reply %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	text, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("unexpected reply %v", values)
+	}
+
+	return newResult(limit, tokens, allowed == 1), nil
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
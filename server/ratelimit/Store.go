@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/config"
+)
+
+// Store keeps a token bucket for every key: it holds up to limit.Requests tokens, and is refilled continuously at
+// limit.Requests tokens per limit.Period. A bucket that is not used yet is full.
+type Store interface {
+	// Take takes a token from the bucket of the key, if it has one, after refilling it.
+	Take(ctx context.Context, key string, limit config.Limit) (Result, error)
+	// Close releases the store's resources.
+	Close() error
+}
+
+// NewStore returns the store configured in settings.
+func NewStore(settings *config.RateLimitConfig) (Store, error) {
+	switch settings.Store {
+	case "memory":
+		return NewMemory(), nil
+	case "redis":
+		return NewRedis(settings.RedisURL)
+	}
+	return nil, errors.New("unknown rate limit store " + settings.Store + ", must be one of the following: memory, redis")
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed bool
+	Limit   config.Limit
+	// Remaining is the number of whole tokens left in the bucket.
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the bucket has a token again, if the request was not allowed.
+	RetryAfter time.Duration
+}
+
+// newResult describes a bucket of the limit that has tokens left after a token was taken, or not.
+func newResult(limit config.Limit, tokens float64, allowed bool) Result {
+	perToken := float64(limit.Period) / float64(limit.Requests)
+
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit.Requests) - tokens) * perToken),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * perToken)
+	}
+	return result
+}
+
+// refill returns the tokens in a bucket of the limit that held tokens elapsed ago.
+func refill(limit config.Limit, tokens float64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return tokens
+	}
+	tokens += float64(limit.Requests) * float64(elapsed) / float64(limit.Period)
+	return math.Min(tokens, float64(limit.Requests))
+}
@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/L4TTiCe/ToDo-Go/server/config"
+	"github.com/alicebob/miniredis/v2"
+)
+
+// clock is a time that only moves when told to.
+type clock struct {
+	at time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.at
+}
+
+// step is a token taken from a bucket, after moving the clock on by advance, and the result expected.
+type step struct {
+	advance time.Duration
+	key     string
+	want    Result
+}
+
+// bucketTests take tokens from buckets of 3 requests per 3 seconds, which refill one token per second.
+var bucketTests = []struct {
+	name  string
+	steps []step
+}{
+	{
+		name: "burst",
+		steps: []step{
+			{want: Result{Allowed: true, Remaining: 2, Reset: time.Second}},
+			{want: Result{Allowed: true, Remaining: 1, Reset: 2 * time.Second}},
+			{want: Result{Allowed: true, Remaining: 0, Reset: 3 * time.Second}},
+			{want: Result{Allowed: false, Remaining: 0, Reset: 3 * time.Second, RetryAfter: time.Second}},
+		},
+	},
+	{
+		name: "refill",
+		steps: []step{
+			{want: Result{Allowed: true, Remaining: 2, Reset: time.Second}},
+			{want: Result{Allowed: true, Remaining: 1, Reset: 2 * time.Second}},
+			{want: Result{Allowed: true, Remaining: 0, Reset: 3 * time.Second}},
+			{advance: 500 * time.Millisecond, want: Result{Allowed: false, Remaining: 0, Reset: 2500 * time.Millisecond, RetryAfter: 500 * time.Millisecond}},
+			{advance: 500 * time.Millisecond, want: Result{Allowed: true, Remaining: 0, Reset: 3 * time.Second}},
+			{advance: 2 * time.Second, want: Result{Allowed: true, Remaining: 1, Reset: 2 * time.Second}},
+		},
+	},
+	{
+		name: "refill up to the limit",
+		steps: []step{
+			{want: Result{Allowed: true, Remaining: 2, Reset: time.Second}},
+			{want: Result{Allowed: true, Remaining: 1, Reset: 2 * time.Second}},
+			{advance: time.Hour, want: Result{Allowed: true, Remaining: 2, Reset: time.Second}},
+		},
+	},
+	{
+		name: "keys have buckets of their own",
+		steps: []step{
+			{key: "a", want: Result{Allowed: true, Remaining: 2, Reset: time.Second}},
+			{key: "a", want: Result{Allowed: true, Remaining: 1, Reset: 2 * time.Second}},
+			{key: "b", want: Result{Allowed: true, Remaining: 2, Reset: time.Second}},
+			{key: "a", want: Result{Allowed: true, Remaining: 0, Reset: 3 * time.Second}},
+		},
+	},
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, func(c *clock) Store {
+		m := NewMemory()
+		m.now = c.now
+		return m
+	})
+}
+
+func TestRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	testStore(t, func(c *clock) Store {
+		server.FlushAll()
+		r, err := NewRedis("redis://" + server.Addr() + "/0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.now = c.now
+		return r
+	})
+}
+
+// testStore runs bucketTests against the stores returned by newStore, which tell the time by the clock.
+func testStore(t *testing.T, newStore func(c *clock) Store) {
+	limit := config.Limit{Requests: 3, Period: 3 * time.Second}
+
+	for _, test := range bucketTests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &clock{at: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+			store := newStore(c)
+			defer store.Close()
+
+			for i, step := range test.steps {
+				c.at = c.at.Add(step.advance)
+				key := step.key
+				if key == "" {
+					key = "client"
+				}
+
+				got, err := store.Take(context.Background(), key, limit)
+				if err != nil {
+					t.Fatalf("take %d: %v", i+1, err)
+				}
+
+				want := step.want
+				want.Limit = limit
+				// Redis keeps times in milliseconds
+				if got.Allowed != want.Allowed || got.Remaining != want.Remaining || got.Limit != want.Limit ||
+					!near(got.Reset, want.Reset) || !near(got.RetryAfter, want.RetryAfter) {
+					t.Errorf("take %d = %+v, want %+v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func near(a time.Duration, b time.Duration) bool {
+	difference := a - b
+	return difference > -time.Millisecond && difference < time.Millisecond
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/L4TTiCe/ToDo-Go/server/controller/CalDAVController"
+	"github.com/gin-gonic/gin"
+)
+
+// CalDAVRoutes contains the routes for the CalDAV server, which exposes ToDoItems as VTODO resources.
+func CalDAVRoutes(router *gin.Engine) {
+	router.Any("/.well-known/caldav", CalDAVController.WellKnown)
+
+	routerGroup := router.Group(CalDAVController.HomePath)
+
+	routerGroup.OPTIONS("/", CalDAVController.Options)
+	routerGroup.Handle("PROPFIND", "/", CalDAVController.PropfindHome)
+
+	routerGroup.OPTIONS("/todos/", CalDAVController.Options)
+	routerGroup.Handle("PROPFIND", "/todos/", CalDAVController.PropfindCollection)
+	routerGroup.Handle("REPORT", "/todos/", CalDAVController.Report)
+
+	routerGroup.OPTIONS("/todos/:name", CalDAVController.Options)
+	routerGroup.Handle("PROPFIND", "/todos/:name", CalDAVController.PropfindObject)
+	routerGroup.GET("/todos/:name", CalDAVController.Get)
+	routerGroup.PUT("/todos/:name", CalDAVController.Put)
+	routerGroup.DELETE("/todos/:name", CalDAVController.Delete)
+}
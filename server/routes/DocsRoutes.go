@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/L4TTiCe/ToDo-Go/server/controller/DocsController"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// DocsRoutes contains the routes for the OpenAPI document, Swagger UI and the descriptions of problem types.
+func DocsRoutes(router *gin.Engine) {
+	router.GET("/openapi.json", DocsController.OpenAPI)
+	router.GET(problem.TypePrefix+":code", DocsController.Problem)
+
+	router.GET("/docs", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, "/docs/")
+	})
+	router.GET("/docs/*file", DocsController.SwaggerUI)
+}
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/L4TTiCe/ToDo-Go/server/controller/GraphQLController"
+	"github.com/gin-gonic/gin"
+)
+
+// GraphQLRoutes contains the route for the GraphQL endpoint, which serves queries, mutations and subscriptions.
+func GraphQLRoutes(router *gin.Engine) {
+	router.GET("/graphql", GraphQLController.Query)
+	router.POST("/graphql", GraphQLController.Query)
+}
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/L4TTiCe/ToDo-Go/server/controller/HealthController"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthRoutes contains the routes for liveness and readiness probes.
+func HealthRoutes(router *gin.Engine) {
+	router.GET("/healthz", HealthController.Liveness)
+	router.GET("/readyz", HealthController.Readiness)
+}
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"github.com/L4TTiCe/ToDo-Go/server/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsRoutes contains the route Prometheus scrapes metrics from.
+func MetricsRoutes(router *gin.Engine) {
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Register registers every route of the server.
+func Register(router *gin.Engine) {
+	router.GET("/up", func(c *gin.Context) {
+		c.String(http.StatusOK, "Server is Up!")
+	})
+
+	ToDoRoutes(router)
+	CalDAVRoutes(router)
+	GraphQLRoutes(router)
+	DocsRoutes(router)
+	HealthRoutes(router)
+	MetricsRoutes(router)
+}
@@ -0,0 +1,70 @@
+package routes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/L4TTiCe/ToDo-Go/server/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+// undocumented are the paths that the OpenAPI document leaves out, as they are not part of the REST API, with the
+// reason. Every method on them is left out.
+var undocumented = map[string]string{
+	"/up":                 "plain-text check that the server is up, like /healthz",
+	"/healthz":            "Kubernetes probe",
+	"/readyz":             "Kubernetes probe",
+	"/metrics":            "Prometheus exposition format",
+	"/openapi.json":       "the OpenAPI document itself",
+	"/docs":               "Swagger UI",
+	"/docs/*file":         "Swagger UI",
+	"/problems/:code":     "descriptions of the problem types the document refers to",
+	"/graphql":            "GraphQL, which describes itself through introspection",
+	"/.well-known/caldav": "CalDAV service discovery",
+	"/caldav/":            "CalDAV, whose PROPFIND and REPORT methods OpenAPI cannot describe",
+	"/caldav/todos/":      "CalDAV",
+	"/caldav/todos/:name": "CalDAV",
+}
+
+// TestRoutesAreDocumented fails if a route registered by Register is missing from the OpenAPI document and is not
+// undocumented on purpose, or if the document describes a route that is not registered.
+func TestRoutesAreDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	Register(router)
+
+	spec := openapi.Spec()
+
+	registered := map[string]bool{}
+	for _, route := range router.Routes() {
+		registered[route.Path] = true
+		if _, ok := undocumented[route.Path]; ok {
+			continue
+		}
+
+		path := openapi.Path(route.Path)
+		method := strings.ToLower(route.Method)
+		registered[method+" "+path] = true
+
+		if _, ok := spec.Paths[path][method]; !ok {
+			t.Errorf("%s %s is registered but missing from the OpenAPI document", route.Method, route.Path)
+		}
+	}
+
+	for path, item := range spec.Paths {
+		for method := range item {
+			if !registered[method+" "+path] {
+				t.Errorf("%s %s is in the OpenAPI document but not registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+
+	for path := range undocumented {
+		if !registered[path] {
+			t.Errorf("%s is undocumented on purpose but not registered", path)
+		}
+		if _, ok := spec.Paths[openapi.Path(path)]; ok {
+			t.Errorf("%s is undocumented on purpose but in the OpenAPI document", path)
+		}
+	}
+}
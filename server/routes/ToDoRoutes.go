@@ -1,7 +1,9 @@
 package routes
 
 import (
+	"github.com/L4TTiCe/ToDo-Go/server/controller/FeedTokenController"
 	"github.com/L4TTiCe/ToDo-Go/server/controller/ToDoItemController"
+	"github.com/L4TTiCe/ToDo-Go/server/idempotency"
 	"github.com/gin-gonic/gin"
 )
 
@@ -9,9 +11,22 @@ import (
 func ToDoRoutes(router *gin.Engine) {
 	routerGroup := router.Group("/todo")
 
+	// Requests that create items can be retried safely with an Idempotency-Key header
+	idempotent := idempotency.Middleware(idempotency.TTL())
+
 	routerGroup.GET("/up", ToDoItemController.HealthCheck)
 
-	routerGroup.POST("/", ToDoItemController.Create)
+	routerGroup.POST("/", idempotent, ToDoItemController.Create)
+	routerGroup.POST("/quick", idempotent, ToDoItemController.QuickAdd)
+	routerGroup.GET("/export", ToDoItemController.Export)
+	routerGroup.POST("/import", idempotent, ToDoItemController.Import)
+	routerGroup.POST("/sync", idempotent, ToDoItemController.Sync)
+
+	routerGroup.GET("/feed.ics", ToDoItemController.Feed)
+	routerGroup.POST("/feed/tokens", idempotent, FeedTokenController.Create)
+	routerGroup.GET("/feed/tokens", FeedTokenController.RetrieveAll)
+	routerGroup.DELETE("/feed/tokens/:id", FeedTokenController.Revoke)
+
 	routerGroup.GET("/", ToDoItemController.RetrieveAll)
 	routerGroup.GET("/:id", ToDoItemController.RetrieveOne)
 	routerGroup.PUT("/:id", ToDoItemController.UpdateOne)
@@ -0,0 +1,252 @@
+package rpc
+
+//go:generate buf generate proto
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/L4TTiCe/ToDo-Go/server/dao/ToDoItemDao"
+	"github.com/L4TTiCe/ToDo-Go/server/events"
+	"github.com/L4TTiCe/ToDo-Go/server/listing"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+	"github.com/L4TTiCe/ToDo-Go/server/rpc/todopb"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// server implements todopb.ToDoServiceServer on top of the same DAO as the REST API.
+type server struct {
+	todopb.UnimplementedToDoServiceServer
+}
+
+// NewServer returns a gRPC server with the ToDo service registered.
+func NewServer() *grpc.Server {
+	grpcServer := grpc.NewServer()
+	todopb.RegisterToDoServiceServer(grpcServer, &server{})
+	return grpcServer
+}
+
+func (s *server) Create(ctx context.Context, request *todopb.CreateRequest) (*todopb.CreateResponse, error) {
+	item := fromProto(request.GetItem())
+
+	result, err := ToDoItemDao.Create(ctx, &item)
+	if err != nil {
+		return nil, Error(problem.From(err))
+	}
+
+	id, _ := result.InsertedID.(primitive.ObjectID)
+	return &todopb.CreateResponse{Id: id.Hex()}, nil
+}
+
+func (s *server) List(ctx context.Context, request *todopb.ListRequest) (*todopb.ListResponse, error) {
+	params := url.Values{}
+	if request.Attrib != "" {
+		params.Set("attrib", request.Attrib)
+	}
+	if request.Sort != "" {
+		params.Set("sort", request.Sort)
+	}
+	if request.Before != nil {
+		params.Set("before", strconv.FormatInt(request.GetBefore(), 10))
+	}
+	if request.After != nil {
+		params.Set("after", strconv.FormatInt(request.GetAfter(), 10))
+	}
+	if request.Start != nil {
+		params.Set("start", strconv.FormatInt(request.GetStart(), 10))
+	}
+	if request.End != nil {
+		params.Set("end", strconv.FormatInt(request.GetEnd(), 10))
+	}
+
+	list, errorResponse := listing.Parse(params)
+	if errorResponse != nil {
+		return nil, Error(errorResponse)
+	}
+	items, err := list.Retrieve(ctx)
+	if err != nil {
+		return nil, Error(problem.From(err))
+	}
+
+	response := &todopb.ListResponse{Items: make([]*todopb.ToDoItem, len(items))}
+	for i := range items {
+		response.Items[i] = toProto(&items[i])
+	}
+	return response, nil
+}
+
+func (s *server) Get(ctx context.Context, request *todopb.GetRequest) (*todopb.ToDoItem, error) {
+	item, err := ToDoItemDao.RetrieveOne(ctx, request.GetId())
+	if err != nil {
+		return nil, Error(problem.From(err))
+	}
+	return toProto(item), nil
+}
+
+func (s *server) Update(ctx context.Context, request *todopb.UpdateRequest) (*todopb.ToDoItem, error) {
+	item, err := ToDoItemDao.RetrieveOne(ctx, request.GetId())
+	if err != nil {
+		return nil, Error(problem.From(err))
+	}
+
+	if err := merge(item, fromProto(request.GetItem()), request.GetUpdateMask()); err != nil {
+		return nil, err
+	}
+
+	if err = ToDoItemDao.Validate(item); err != nil {
+		return nil, Error(problem.From(err))
+	}
+
+	if _, err = ToDoItemDao.UpdateOne(ctx, request.GetId(), item); err != nil {
+		return nil, Error(problem.From(err))
+	}
+	return toProto(item), nil
+}
+
+func (s *server) Delete(ctx context.Context, request *todopb.DeleteRequest) (*todopb.DeleteResponse, error) {
+	if _, err := ToDoItemDao.DeleteOne(ctx, request.GetId()); err != nil {
+		return nil, Error(problem.From(err))
+	}
+	return &todopb.DeleteResponse{DeletedCount: 1}, nil
+}
+
+func (s *server) Watch(request *todopb.WatchRequest, stream todopb.ToDoService_WatchServer) error {
+	changes, unsubscribe := events.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case change, ok := <-changes:
+			if !ok {
+				if events.Closed() {
+					return status.Error(codes.Unavailable, "server is shutting down")
+				}
+				return status.Error(codes.Aborted, "watcher fell too far behind; watch again and reload")
+			}
+			if err := stream.Send(changeToProto(&change)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Error converts an ErrorResponse into a gRPC status error, mapping its HTTP status to the closest gRPC code.
+func Error(errorResponse *models.ErrorResponse) error {
+	return status.Error(code(errorResponse.Status), problem.Summary(errorResponse))
+}
+
+// code maps an HTTP status to a gRPC code.
+func code(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case 499:
+		return codes.Canceled
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	return codes.Internal
+}
+
+// merge copies the fields named in mask from update to item, or all writable fields if mask is empty.
+func merge(item *models.ToDoItem, update models.ToDoItem, mask []string) error {
+	if len(mask) == 0 {
+		mask = []string{"title", "completed", "deadline", "list", "tags", "priority", "recurrence", "external_id"}
+	}
+
+	for _, field := range mask {
+		switch field {
+		case "title":
+			item.Title = update.Title
+		case "completed":
+			item.Completed = update.Completed
+		case "deadline":
+			item.Deadline = update.Deadline
+		case "list":
+			item.List = update.List
+		case "tags":
+			item.Tags = update.Tags
+		case "priority":
+			item.Priority = update.Priority
+		case "recurrence":
+			item.Recurrence = update.Recurrence
+		case "external_id":
+			item.ExternalID = update.ExternalID
+		default:
+			return status.Error(codes.InvalidArgument, "unknown or read-only field in update mask: "+field)
+		}
+	}
+
+	return nil
+}
+
+func toProto(item *models.ToDoItem) *todopb.ToDoItem {
+	return &todopb.ToDoItem{
+		Id:          item.ID.Hex(),
+		Title:       item.Title,
+		Completed:   item.Completed,
+		CreatedAt:   item.CreatedAt,
+		Deadline:    item.Deadline,
+		List:        item.List,
+		Tags:        item.Tags,
+		Priority:    item.Priority,
+		Recurrence:  item.Recurrence,
+		ExternalId:  item.ExternalID,
+		UpdatedAt:   item.UpdatedAt,
+		CompletedAt: item.CompletedAt,
+	}
+}
+
+// fromProto converts the writable fields of a protobuf item. IDs and server-managed timestamps are ignored.
+func fromProto(item *todopb.ToDoItem) models.ToDoItem {
+	return models.ToDoItem{
+		Title:      item.GetTitle(),
+		Completed:  item.GetCompleted(),
+		Deadline:   item.GetDeadline(),
+		List:       item.GetList(),
+		Tags:       item.GetTags(),
+		Priority:   item.GetPriority(),
+		Recurrence: item.GetRecurrence(),
+		ExternalID: item.GetExternalId(),
+	}
+}
+
+var changeTypes = map[string]todopb.Change_Type{
+	models.ChangeCreated: todopb.Change_TYPE_CREATED,
+	models.ChangeUpdated: todopb.Change_TYPE_UPDATED,
+	models.ChangeDeleted: todopb.Change_TYPE_DELETED,
+}
+
+func changeToProto(change *models.Change) *todopb.Change {
+	return &todopb.Change{
+		Type:      changeTypes[change.Type],
+		Id:        change.ID.Hex(),
+		Item:      toProto(change.Item),
+		Timestamp: change.Timestamp,
+	}
+}
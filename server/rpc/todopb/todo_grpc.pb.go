@@ -0,0 +1,325 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: todo/v1/todo.proto
+
+package todopb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// ToDoServiceClient is the client API for ToDoService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ToDoServiceClient interface {
+	// Create creates a new item and returns its ID.
+	Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	// List returns items, sorted and filtered like GET /todo/.
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	// Get returns a single item.
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*ToDoItem, error)
+	// Update changes the fields of an item listed in the update mask, or all fields if it is empty.
+	Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*ToDoItem, error)
+	// Delete deletes an item.
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	// Watch streams every change to an item from the time of the call.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (ToDoService_WatchClient, error)
+}
+
+type toDoServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewToDoServiceClient(cc grpc.ClientConnInterface) ToDoServiceClient {
+	return &toDoServiceClient{cc}
+}
+
+func (c *toDoServiceClient) Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	out := new(CreateResponse)
+	err := c.cc.Invoke(ctx, "/todo.v1.ToDoService/Create", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *toDoServiceClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error) {
+	out := new(ListResponse)
+	err := c.cc.Invoke(ctx, "/todo.v1.ToDoService/List", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *toDoServiceClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*ToDoItem, error) {
+	out := new(ToDoItem)
+	err := c.cc.Invoke(ctx, "/todo.v1.ToDoService/Get", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *toDoServiceClient) Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*ToDoItem, error) {
+	out := new(ToDoItem)
+	err := c.cc.Invoke(ctx, "/todo.v1.ToDoService/Update", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *toDoServiceClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, "/todo.v1.ToDoService/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *toDoServiceClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (ToDoService_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ToDoService_ServiceDesc.Streams[0], "/todo.v1.ToDoService/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &toDoServiceWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ToDoService_WatchClient interface {
+	Recv() (*Change, error)
+	grpc.ClientStream
+}
+
+type toDoServiceWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *toDoServiceWatchClient) Recv() (*Change, error) {
+	m := new(Change)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ToDoServiceServer is the server API for ToDoService service.
+// All implementations must embed UnimplementedToDoServiceServer
+// for forward compatibility
+type ToDoServiceServer interface {
+	// Create creates a new item and returns its ID.
+	Create(context.Context, *CreateRequest) (*CreateResponse, error)
+	// List returns items, sorted and filtered like GET /todo/.
+	List(context.Context, *ListRequest) (*ListResponse, error)
+	// Get returns a single item.
+	Get(context.Context, *GetRequest) (*ToDoItem, error)
+	// Update changes the fields of an item listed in the update mask, or all fields if it is empty.
+	Update(context.Context, *UpdateRequest) (*ToDoItem, error)
+	// Delete deletes an item.
+	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	// Watch streams every change to an item from the time of the call.
+	Watch(*WatchRequest, ToDoService_WatchServer) error
+	mustEmbedUnimplementedToDoServiceServer()
+}
+
+// UnimplementedToDoServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedToDoServiceServer struct {
+}
+
+func (UnimplementedToDoServiceServer) Create(context.Context, *CreateRequest) (*CreateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedToDoServiceServer) List(context.Context, *ListRequest) (*ListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedToDoServiceServer) Get(context.Context, *GetRequest) (*ToDoItem, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedToDoServiceServer) Update(context.Context, *UpdateRequest) (*ToDoItem, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedToDoServiceServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedToDoServiceServer) Watch(*WatchRequest, ToDoService_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedToDoServiceServer) mustEmbedUnimplementedToDoServiceServer() {}
+
+// UnsafeToDoServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ToDoServiceServer will
+// result in compilation errors.
+type UnsafeToDoServiceServer interface {
+	mustEmbedUnimplementedToDoServiceServer()
+}
+
+func RegisterToDoServiceServer(s grpc.ServiceRegistrar, srv ToDoServiceServer) {
+	s.RegisterService(&ToDoService_ServiceDesc, srv)
+}
+
+func _ToDoService_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ToDoServiceServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/todo.v1.ToDoService/Create",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ToDoServiceServer).Create(ctx, req.(*CreateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ToDoService_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ToDoServiceServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/todo.v1.ToDoService/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ToDoServiceServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ToDoService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ToDoServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/todo.v1.ToDoService/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ToDoServiceServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ToDoService_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ToDoServiceServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/todo.v1.ToDoService/Update",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ToDoServiceServer).Update(ctx, req.(*UpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ToDoService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ToDoServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/todo.v1.ToDoService/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ToDoServiceServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ToDoService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ToDoServiceServer).Watch(m, &toDoServiceWatchServer{stream})
+}
+
+type ToDoService_WatchServer interface {
+	Send(*Change) error
+	grpc.ServerStream
+}
+
+type toDoServiceWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *toDoServiceWatchServer) Send(m *Change) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ToDoService_ServiceDesc is the grpc.ServiceDesc for ToDoService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ToDoService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "todo.v1.ToDoService",
+	HandlerType: (*ToDoServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _ToDoService_Create_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _ToDoService_List_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _ToDoService_Get_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _ToDoService_Update_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _ToDoService_Delete_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _ToDoService_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "todo/v1/todo.proto",
+}
@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CommandMonitor returns a monitor for the MongoDB driver that records a span for every command, as a child of the
+// span in the context the command was run with.
+func CommandMonitor() *event.CommandMonitor {
+	var spans sync.Map
+
+	end := func(requestID int64, err string) {
+		value, ok := spans.LoadAndDelete(requestID)
+		if !ok {
+			return
+		}
+		span := value.(trace.Span)
+		if err != "" {
+			span.SetStatus(codes.Error, err)
+		}
+		span.End()
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			attributes := []attribute.KeyValue{
+				semconv.DBSystemMongoDB,
+				semconv.DBName(e.DatabaseName),
+				semconv.DBOperation(e.CommandName),
+			}
+			// Commands on a collection name it as their first element, e.g. {"find": "ToDoItems", ...}
+			if elements, err := e.Command.Elements(); err == nil && len(elements) > 0 && elements[0].Value().Type == bsontype.String {
+				attributes = append(attributes, semconv.DBMongoDBCollection(elements[0].Value().StringValue()))
+			}
+			// Connection IDs are the server's address followed by a counter, e.g. localhost:27017[-3]
+			address := e.ConnectionID
+			if i := strings.Index(address, "[-"); i >= 0 {
+				address = address[:i]
+			}
+			if host, port, err := net.SplitHostPort(address); err == nil {
+				attributes = append(attributes, semconv.NetPeerName(host))
+				if number, err := strconv.Atoi(port); err == nil {
+					attributes = append(attributes, semconv.NetPeerPort(number))
+				}
+			}
+
+			_, span := tracer().Start(ctx, "mongodb."+e.CommandName,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attributes...),
+			)
+			spans.Store(e.RequestID, span)
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			end(e.RequestID, "")
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			end(e.RequestID, e.Failure)
+		},
+	}
+}
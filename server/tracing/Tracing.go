@@ -0,0 +1,87 @@
+// Package tracing records OpenTelemetry traces of the requests the server handles and of the MongoDB commands they
+// run, and exports them.
+//
+// The exporter is chosen by the tracing.exporter setting, or OTEL_TRACES_EXPORTER: otlp sends spans over OTLP/gRPC to
+// OTEL_EXPORTER_OTLP_ENDPOINT (localhost:4317 by default), stdout prints them, and none, the default, records nothing
+// while still passing W3C trace context on. The other OTEL_* variables, such as OTEL_SERVICE_NAME and
+// OTEL_TRACES_SAMPLER, are honoured as usual.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/L4TTiCe/ToDo-Go/server/buildinfo"
+	"github.com/L4TTiCe/ToDo-Go/server/logging"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// ServiceName names the server in traces, unless OTEL_SERVICE_NAME says otherwise.
+const ServiceName = "todo-go"
+
+// instrumentation names the tracer of the spans recorded by this package.
+const instrumentation = "github.com/L4TTiCe/ToDo-Go/server/tracing"
+
+// untraced are the routes polled by monitoring, whose spans would only be noise.
+var untraced = map[string]bool{"/metrics": true, "/healthz": true, "/readyz": true, "/up": true}
+
+// Setup installs the tracer provider and the W3C trace context propagator, exporting spans with the named exporter:
+// otlp, stdout or none. The returned function flushes the spans not exported yet, and must be called before exiting.
+func Setup(exporterName string) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch exporterName {
+	case "", "none":
+		return func(ctx context.Context) error { return nil }, nil
+	case "otlp":
+		exporter, err = otlptracegrpc.New(context.Background())
+	case "stdout", "console":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, errors.New("unknown trace exporter " + exporterName + ", must be one of the following: otlp, stdout, none")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	build := buildinfo.Get()
+	serviceResource, err := resource.Merge(
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName), semconv.ServiceVersion(build.Version)),
+		// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(serviceResource))
+	otel.SetTracerProvider(provider)
+	logging.L().Info("Exporting traces", zap.String("exporter", exporterName))
+
+	return provider.Shutdown, nil
+}
+
+// Middleware starts a span for every request, continuing the trace given in its traceparent header, and puts it in
+// the request's context for the handlers to pass on.
+func Middleware() gin.HandlerFunc {
+	return otelgin.Middleware(ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+		return !untraced[r.URL.Path]
+	}))
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
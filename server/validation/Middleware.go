@@ -0,0 +1,141 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/L4TTiCe/ToDo-Go/server/controller"
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/openapi"
+	"github.com/L4TTiCe/ToDo-Go/server/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// maxBodySize is the largest JSON body that is read for validation. Larger bodies are passed on unvalidated, so that
+// handlers with their own limits, like imports, can enforce them.
+const maxBodySize = 1 << 20
+
+// Middleware validates requests to the operations described by an OpenAPI document before they reach their handler.
+// Path and query parameters that do not match their schema are rejected with 400, as are JSON bodies that cannot be
+// parsed; JSON bodies that parse but do not match their schema are rejected with 422. Either way, the ErrorResponse
+// lists every violation. Requests to routes the document does not describe are passed through.
+func Middleware(document *openapi.Document) gin.HandlerFunc {
+	v := &validator{document: document}
+
+	return func(c *gin.Context) {
+		operation := document.Paths[openapi.Path(c.FullPath())][strings.ToLower(c.Request.Method)]
+		if operation == nil {
+			c.Next()
+			return
+		}
+
+		if violations := v.parameters(c, operation); len(violations) > 0 {
+			errorResponse := problem.InvalidRequest.New("One or more parameters are invalid")
+			errorResponse.Violations = violations
+			abort(c, errorResponse)
+			return
+		}
+
+		if errorResponse := v.body(c, operation); errorResponse != nil {
+			abort(c, errorResponse)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// parameters checks the path, query and header parameters of a request.
+func (v *validator) parameters(c *gin.Context, operation *openapi.Operation) []models.Violation {
+	var violations []models.Violation
+	query := c.Request.URL.Query()
+
+	for _, parameter := range operation.Parameters {
+		var values []string
+		switch parameter.In {
+		case "path":
+			values = []string{c.Param(parameter.Name)}
+		case "query":
+			values = query[parameter.Name]
+		case "header":
+			values = c.Request.Header.Values(parameter.Name)
+		}
+
+		if len(values) == 0 || values[0] == "" {
+			if parameter.Required {
+				violations = append(violations, models.Violation{Parameter: parameter.Name, Message: "is required"})
+			}
+			continue
+		}
+
+		for _, value := range values {
+			violations = append(violations, checkParameter(parameter, value)...)
+		}
+	}
+
+	return violations
+}
+
+// body checks a JSON request body, and puts it back for the handler to read.
+func (v *validator) body(c *gin.Context, operation *openapi.Operation) *models.ErrorResponse {
+	if operation.RequestBody == nil || c.Request.Body == nil {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if mediaType == "" {
+		mediaType = "application/json"
+	}
+	content, ok := operation.RequestBody.Content[mediaType]
+	if !ok || mediaType != "application/json" || content.Schema.Format == "binary" {
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBodySize+1))
+	if err != nil {
+		return problem.InvalidRequest.New("Error reading request body: " + err.Error())
+	}
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), c.Request.Body))
+	if len(data) > maxBodySize {
+		return nil
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		if !operation.RequestBody.Required {
+			return nil
+		}
+		return problem.InvalidRequest.New("Request body is empty")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return problem.InvalidRequest.New("Error parsing JSON: " + err.Error())
+	}
+	if decoder.More() {
+		return problem.InvalidRequest.New("Request body must hold a single JSON value")
+	}
+
+	if value == nil {
+		return problem.ValidationFailed.New("Request body must not be null")
+	}
+
+	if violations := v.check(content.Schema, value, ""); len(violations) > 0 {
+		errorResponse := problem.ValidationFailed.New("One or more fields are invalid")
+		errorResponse.Violations = violations
+		return errorResponse
+	}
+
+	return nil
+}
+
+func abort(c *gin.Context, errorResponse *models.ErrorResponse) {
+	controller.PopulateErrorResponse(c, errorResponse)
+
+	c.AbortWithStatusJSON(errorResponse.Status, errorResponse)
+}
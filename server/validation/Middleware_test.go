@@ -0,0 +1,49 @@
+package validation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/L4TTiCe/ToDo-Go/server/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{name: "create", method: http.MethodPost, path: "/todo/", body: `{"title": "Pay rent"}`, status: http.StatusOK},
+		{name: "create without a title", method: http.MethodPost, path: "/todo/", body: `{"completed": true}`, status: http.StatusUnprocessableEntity},
+		{name: "create with a wrong type", method: http.MethodPost, path: "/todo/", body: `{"title": 5}`, status: http.StatusUnprocessableEntity},
+		{name: "create with broken JSON", method: http.MethodPost, path: "/todo/", body: `{"title": `, status: http.StatusBadRequest},
+		// Updates only carry the fields they change
+		{name: "partial update", method: http.MethodPut, path: "/todo/62a9a5c0e4b0a1b2c3d4e5f6", body: `{"completed": true}`, status: http.StatusOK},
+		{name: "empty update", method: http.MethodPut, path: "/todo/62a9a5c0e4b0a1b2c3d4e5f6", body: `{}`, status: http.StatusOK},
+		{name: "update with a wrong type", method: http.MethodPut, path: "/todo/62a9a5c0e4b0a1b2c3d4e5f6", body: `{"completed": "yes"}`, status: http.StatusUnprocessableEntity},
+		{name: "update with an invalid ID", method: http.MethodPut, path: "/todo/42", body: `{"completed": true}`, status: http.StatusBadRequest},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(openapi.Spec()))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.POST("/todo/", ok)
+	router.PUT("/todo/:id", ok)
+
+	for _, test := range tests {
+		request := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		request.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request)
+
+		if w.Code != test.status {
+			t.Errorf("%s: status = %d, want %d: %s", test.name, w.Code, test.status, w.Body)
+		}
+	}
+}
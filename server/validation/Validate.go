@@ -0,0 +1,199 @@
+package validation
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/L4TTiCe/ToDo-Go/server/models"
+	"github.com/L4TTiCe/ToDo-Go/server/openapi"
+)
+
+// validator checks decoded JSON values against the schemas of an OpenAPI document.
+type validator struct {
+	document *openapi.Document
+}
+
+// check checks a value decoded with json.Decoder.UseNumber against a schema. Violations are reported with JSON
+// pointers relative to pointer. Read-only properties are ignored, since they are set by the server.
+// A null is accepted wherever a property is optional, because it decodes to the zero value just like an absent one.
+func (v *validator) check(schema *openapi.Schema, value interface{}, pointer string) []models.Violation {
+	schema = v.resolve(schema)
+	if schema == nil || value == nil {
+		return nil
+	}
+
+	violation := func(message string) []models.Violation {
+		return []models.Violation{{Pointer: pointer, Message: message}}
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return violation("must be an object")
+		}
+		return v.object(schema, object, pointer)
+
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return violation("must be an array")
+		}
+		var violations []models.Violation
+		for i, element := range array {
+			elementPointer := pointer + "/" + strconv.Itoa(i)
+			if element == nil {
+				violations = append(violations, models.Violation{Pointer: elementPointer, Message: "must not be null"})
+				continue
+			}
+			violations = append(violations, v.check(schema.Items, element, elementPointer)...)
+		}
+		return violations
+
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return violation("must be a string")
+		}
+		return stringViolations(schema, s, func(message string) models.Violation {
+			return models.Violation{Pointer: pointer, Message: message}
+		})
+
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return violation("must be an integer")
+		}
+		if _, err := strconv.ParseInt(number.String(), 10, 64); err != nil {
+			return violation("must be an integer")
+		}
+
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return violation("must be a number")
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return violation("must be a boolean")
+		}
+	}
+
+	return nil
+}
+
+// object checks the properties of an object. Unknown properties are allowed, as the JSON decoder ignores them.
+func (v *validator) object(schema *openapi.Schema, object map[string]interface{}, pointer string) []models.Violation {
+	var violations []models.Violation
+
+	for _, name := range schema.Required {
+		property := v.resolve(schema.Properties[name])
+		if property != nil && property.ReadOnly {
+			continue
+		}
+		if value, ok := object[name]; !ok || value == nil {
+			violations = append(violations, models.Violation{Pointer: pointer + "/" + escape(name), Message: "is required"})
+		}
+	}
+
+	// Properties are checked in a stable order, so that the violations are too
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property := v.resolve(schema.Properties[name])
+		if property == nil || property.ReadOnly {
+			continue
+		}
+		violations = append(violations, v.check(property, object[name], pointer+"/"+escape(name))...)
+	}
+
+	return violations
+}
+
+// resolve follows a reference to a component schema.
+func (v *validator) resolve(schema *openapi.Schema) *openapi.Schema {
+	if schema == nil || schema.Ref == "" {
+		return schema
+	}
+	return v.document.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+}
+
+// checkParameter checks the raw value of a path or query parameter against its schema.
+func checkParameter(parameter *openapi.Parameter, raw string) []models.Violation {
+	violation := func(message string) []models.Violation {
+		return []models.Violation{{Parameter: parameter.Name, Message: message}}
+	}
+
+	switch parameter.Schema.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return violation("must be an integer")
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return violation("must be a number")
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(raw); err != nil {
+			return violation("must be a boolean")
+		}
+	case "string":
+		return stringViolations(parameter.Schema, raw, func(message string) models.Violation {
+			return models.Violation{Parameter: parameter.Name, Message: message}
+		})
+	}
+
+	return nil
+}
+
+// stringViolations checks the enum, minLength and pattern of a string.
+func stringViolations(schema *openapi.Schema, s string, violation func(message string) models.Violation) []models.Violation {
+	if schema.Enum != nil && !contains(schema.Enum, s) {
+		return []models.Violation{violation("must be one of the following: " + strings.Join(schema.Enum, ", "))}
+	}
+	if schema.MinLength != nil && utf8.RuneCountInString(s) < *schema.MinLength {
+		if *schema.MinLength == 1 {
+			return []models.Violation{violation("must not be empty")}
+		}
+		return []models.Violation{violation("must be at least " + strconv.Itoa(*schema.MinLength) + " characters long")}
+	}
+	if schema.Pattern != "" && !pattern(schema.Pattern).MatchString(s) {
+		return []models.Violation{violation("must match " + schema.Pattern)}
+	}
+	return nil
+}
+
+var patterns sync.Map
+
+// pattern returns the compiled form of a schema pattern. Patterns come from the document, so they are trusted to compile.
+func pattern(expression string) *regexp.Regexp {
+	if compiled, ok := patterns.Load(expression); ok {
+		return compiled.(*regexp.Regexp)
+	}
+	compiled := regexp.MustCompile(expression)
+	patterns.Store(expression, compiled)
+	return compiled
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// escape escapes a property name for use in a JSON pointer.
+func escape(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}